@@ -5,40 +5,45 @@ import (
 	"log/slog"
 	"os"
 
-	"github.com/ivan-penchev/manga-updates/internal/config"
-	"github.com/ivan-penchev/manga-updates/internal/provider"
-	"github.com/ivan-penchev/manga-updates/internal/store"
 	"github.com/spf13/cobra"
 )
 
+var addUsers []string
+
 var addCmd = &cobra.Command{
 	Use:   "add [url]",
 	Short: "Add a new manga series",
 	Long: `Add a new manga series to the tracking list by providing its URL.
-It automatically detects the provider (MangaDex or Manganelo), fetches the manga details,
-and saves it to the local store for tracking updates.`,
+It automatically detects the provider (MangaDex, Manganelo or an RSS/Atom feed), fetches the manga details,
+and saves it to the local store for tracking updates.
+
+With --user, the new chapters of the series are only sent to the given users of the config file,
+adding a series already tracked subscribes the users to it.`,
 	Example: `  manga-cli add https://mangadex.org/title/0328cd58-d519-45b6-abd2-049cfe63790b/kanmuri-san-no-tokei-koubou
-  manga-cli add https://manganel.me/manga/god-of-martial-arts`,
+  manga-cli add https://manganel.me/manga/god-of-martial-arts
+  manga-cli add https://scans.example.com/series/solo-leveling/feed
+  manga-cli add --user alice https://manganel.me/manga/god-of-martial-arts`,
 	Args: cobra.ExactArgs(1),
 	Run: func(cmd *cobra.Command, args []string) {
 		logger := slog.Default()
 		url := args[0]
 
-		cfg, err := config.Load(cfgFile)
+		application, err := loadApp()
 		if err != nil {
-			logger.Error("failed to parse configuration", "error", err)
+			logger.Error("failed to load configuration", "error", err)
 			os.Exit(1)
 		}
 
-		store := store.NewStore(cfg.SeriesDataFolder)
+		for _, user := range addUsers {
+			if _, ok := application.Config.User(user); !ok {
+				logger.Error("unknown user, users are declared in the config file", "user", user)
+				os.Exit(1)
+			}
+		}
+
+		store := application.Store()
 
-		providerRouter, err := provider.NewProviderRouter(
-			provider.NewMangaNelProviderFactory(provider.MangaNelProviderConfig{
-				GraphQLEndpoint: cfg.MangaNelGraphQLEndpoint,
-				RemoteChromeURL: cfg.RemoteChromeURL,
-			}),
-			provider.NewMangaDexProviderFactory(),
-		)
+		providerRouter, err := application.ProviderRouter()
 		if err != nil {
 			logger.Error("failed to create provider router", "error", err)
 			os.Exit(1)
@@ -59,6 +64,23 @@ and saves it to the local store for tracking updates.`,
 
 		manga.ShouldNotify = true
 		manga.Source = p.Kind()
+		manga.Subscribe(addUsers...)
+
+		if len(addUsers) > 0 {
+			for path, existing := range store.GetMangaSeries(ctx) {
+				if existing.Source != manga.Source || existing.Slug != manga.Slug {
+					continue
+				}
+				if existing.Subscribe(addUsers...) {
+					if err := store.PersistMangaTitle(ctx, path, existing); err != nil {
+						logger.Error("failed to save series to store", "manga", existing.Name, "error", err)
+						os.Exit(1)
+					}
+				}
+				logger.Info("Series already tracked, subscribed users", "title", existing.Name, "subscribers", existing.Subscribers)
+				return
+			}
+		}
 
 		err = store.AddManga(ctx, manga)
 		if err != nil {
@@ -71,5 +93,6 @@ and saves it to the local store for tracking updates.`,
 }
 
 func init() {
+	addCmd.Flags().StringSliceVar(&addUsers, "user", nil, "Notify only these users of the config file about the series, repeatable")
 	rootCmd.AddCommand(addCmd)
 }
@@ -0,0 +1,93 @@
+package cmd
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"net/http"
+	"os"
+	"os/signal"
+	"syscall"
+	"time"
+
+	"github.com/ivan-penchev/manga-updates/internal/app"
+	"github.com/ivan-penchev/manga-updates/internal/metrics"
+	"github.com/spf13/cobra"
+)
+
+var daemonInterval time.Duration
+var daemonListenAddr string
+
+var daemonCmd = &cobra.Command{
+	Use:   "daemon",
+	Short: "Check for updates periodically and expose Prometheus metrics",
+	Long: `Run an update right away and then every --interval until interrupted, keeping the
+providers, their rate limits and caches alive between runs. Prometheus metrics are served
+on /metrics: checks per provider and outcome, provider latency and errors, notifications
+sent and failed per channel, series by status and the time of the last successful run.`,
+	Example: `  manga-cli daemon --interval 1h --listen :9090`,
+	Args:    cobra.NoArgs,
+	Run: func(cmd *cobra.Command, args []string) {
+		logger := slog.Default()
+
+		ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+		defer stop()
+
+		m := metrics.New()
+		application, err := loadApp(app.WithMetrics(m))
+		if err != nil {
+			logger.Error("failed to load configuration", "error", err)
+			os.Exit(1)
+		}
+		defer closeApp(application, logger)
+
+		updatecheckerService, err := application.UpdateChecker(ctx)
+		if err != nil {
+			logger.Error("failed to create update checker service", "error", err)
+			os.Exit(1)
+		}
+
+		mux := http.NewServeMux()
+		mux.Handle("/metrics", m.Handler())
+		server := &http.Server{Addr: daemonListenAddr, Handler: mux}
+		go func() {
+			logger.Info("Serving metrics", "addr", daemonListenAddr)
+			if err := server.ListenAndServe(); err != nil && !errors.Is(err, http.ErrServerClosed) {
+				logger.Error("metrics server stopped", "error", err)
+				stop()
+			}
+		}()
+
+		ticker := time.NewTicker(daemonInterval)
+		defer ticker.Stop()
+		for {
+			runCtx, cancel := ctx, context.CancelFunc(func() {})
+			if application.Config.RunTimeout > 0 {
+				runCtx, cancel = context.WithTimeout(ctx, application.Config.RunTimeout)
+			}
+			ts := time.Now()
+			if err := updatecheckerService.CheckForUpdates(runCtx); err != nil {
+				logger.Error("failed to check for updates", "error", err)
+			} else {
+				logger.Info("Completed update run", "durationInSeconds", time.Since(ts).Seconds())
+			}
+			cancel()
+
+			select {
+			case <-ctx.Done():
+				shutdownCtx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+				defer cancel()
+				_ = server.Shutdown(shutdownCtx)
+				logger.Info("Daemon stopped")
+				return
+			case <-ticker.C:
+			}
+		}
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(daemonCmd)
+	daemonCmd.Flags().DurationVar(&daemonInterval, "interval", 6*time.Hour, "Time between two update runs")
+	daemonCmd.Flags().StringVar(&daemonListenAddr, "listen", ":9090", "Address to serve the metrics on")
+}
@@ -0,0 +1,70 @@
+package cmd
+
+import (
+	"io"
+	"log/slog"
+	"os"
+	"strings"
+
+	"github.com/ivan-penchev/manga-updates/internal/backup"
+	"github.com/spf13/cobra"
+)
+
+var exportFormat string
+
+var exportCmd = &cobra.Command{
+	Use:   "export [file]",
+	Short: "Back up the tracking library to a single archive",
+	Long: `Write every tracked series, with its chapters, settings and read progress, to a versioned
+archive that can be restored with the restore command. The archive is a JSON document or a
+tar.gz containing it, the format is taken from the file extension unless --format is given.
+Without a file, or with -, the archive is written to stdout.`,
+	Example: `  manga-cli export library.tar.gz
+  manga-cli export --format json > library.json`,
+	Args: cobra.MaximumNArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		logger := slog.Default()
+
+		path := "-"
+		if len(args) == 1 {
+			path = args[0]
+		}
+		format := backup.Format(exportFormat)
+		if format == "" {
+			format = backup.FormatJSON
+			if strings.HasSuffix(path, ".tar.gz") || strings.HasSuffix(path, ".tgz") {
+				format = backup.FormatTarGz
+			}
+		}
+
+		application, err := loadApp()
+		if err != nil {
+			logger.Error("failed to load configuration", "error", err)
+			os.Exit(1)
+		}
+
+		archive := backup.Export(cmd.Context(), application.Store())
+
+		var out io.Writer = os.Stdout
+		if path != "-" {
+			file, err := os.Create(path)
+			if err != nil {
+				logger.Error("failed to create archive", "file", path, "error", err)
+				os.Exit(1)
+			}
+			defer file.Close()
+			out = file
+		}
+		if err := backup.Write(out, archive, format); err != nil {
+			logger.Error("failed to write archive", "file", path, "error", err)
+			os.Exit(1)
+		}
+
+		logger.Info("Exported library", "series", len(archive.Series), "format", format, "file", path)
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(exportCmd)
+	exportCmd.Flags().StringVarP(&exportFormat, "format", "f", "", "Archive format (json, tar.gz), detected from the file extension by default")
+}
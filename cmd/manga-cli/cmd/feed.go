@@ -0,0 +1,86 @@
+package cmd
+
+import (
+	"context"
+	"log/slog"
+	"net/http"
+	"os"
+
+	"github.com/ivan-penchev/manga-updates/internal/feed"
+	"github.com/spf13/cobra"
+)
+
+var feedOutputDir string
+var feedListenAddr string
+
+var feedCmd = &cobra.Command{
+	Use:   "feed",
+	Short: "Generate Atom/RSS feeds of new chapters",
+}
+
+var feedWriteCmd = &cobra.Command{
+	Use:   "write",
+	Short: "Write the global and per-series feeds to a directory",
+	Example: `  manga-cli feed write --output ./public
+  manga-cli feed write # uses feed.output_dir from the config`,
+	Run: func(cmd *cobra.Command, args []string) {
+		logger := slog.Default()
+
+		application, err := loadApp()
+		if err != nil {
+			logger.Error("failed to load configuration", "error", err)
+			os.Exit(1)
+		}
+
+		outputDir := feedOutputDir
+		if outputDir == "" {
+			outputDir = application.Config.Feed.OutputDir
+		}
+		if outputDir == "" {
+			logger.Error("no output directory, use --output or set feed.output_dir")
+			os.Exit(1)
+		}
+
+		store := application.Store()
+		writer := feed.NewFileWriter(store, outputDir, application.FeedConfig())
+		if err := writer.Write(context.Background()); err != nil {
+			logger.Error("failed to write feeds", "error", err)
+			os.Exit(1)
+		}
+
+		logger.Info("Successfully wrote feeds", "outputDir", outputDir)
+	},
+}
+
+var feedServeCmd = &cobra.Command{
+	Use:   "serve",
+	Short: "Serve the feeds over HTTP",
+	Long: `Serve the feeds over HTTP, built from the store on every request.
+Available paths: /feed.atom, /feed.rss, /series/<source>-<slug>.atom and /series/<source>-<slug>.rss`,
+	Run: func(cmd *cobra.Command, args []string) {
+		logger := slog.Default()
+
+		application, err := loadApp()
+		if err != nil {
+			logger.Error("failed to load configuration", "error", err)
+			os.Exit(1)
+		}
+
+		store := application.Store()
+
+		logger.Info("Serving feeds", "address", feedListenAddr)
+		err = http.ListenAndServe(feedListenAddr, feed.NewHandler(store, application.FeedConfig()))
+		if err != nil {
+			logger.Error("feed server stopped", "error", err)
+			os.Exit(1)
+		}
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(feedCmd)
+	feedCmd.AddCommand(feedWriteCmd)
+	feedCmd.AddCommand(feedServeCmd)
+	feedWriteCmd.Flags().StringVarP(&feedOutputDir, "output", "o", "", "Directory to write the feeds to (default is feed.output_dir)")
+	feedServeCmd.Flags().StringVar(&feedListenAddr, "listen", ":8080", "Address to serve the feeds on")
+}
@@ -0,0 +1,88 @@
+package cmd
+
+import (
+	"fmt"
+	"log/slog"
+	"os"
+	"text/tabwriter"
+	"time"
+
+	"github.com/ivan-penchev/manga-updates/internal/history"
+	"github.com/spf13/cobra"
+)
+
+var historyChecks int
+
+var historyCmd = &cobra.Command{
+	Use:   "history [series]",
+	Short: "Show when chapters were first seen and the outcome of recent checks",
+	Long: `Show the history of a tracked series, identified by its slug, title or data file path:
+every chapter with the time it was first seen by an update run, followed by the outcome
+of the most recent check attempts and their errors.`,
+	Example: `  manga-cli history solo-leveling
+  manga-cli history solo-leveling --checks 50`,
+	Args: cobra.ExactArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		logger := slog.Default()
+
+		application, err := loadApp()
+		if err != nil {
+			logger.Error("failed to load configuration", "error", err)
+			os.Exit(1)
+		}
+
+		_, manga, err := findSeries(cmd.Context(), application.Store(), args[0])
+		if err != nil {
+			logger.Error("failed to find series", "error", err)
+			os.Exit(1)
+		}
+
+		events, err := history.Read(application.Config.SeriesHistoryDir(), manga)
+		if err != nil {
+			logger.Error("failed to read history", "manga", manga.Name, "error", err)
+			os.Exit(1)
+		}
+		if len(events) == 0 {
+			fmt.Printf("No history recorded for %s yet\n", manga.Name)
+			return
+		}
+
+		var checks []history.Event
+		fmt.Printf("Chapters of %s:\n", manga.Name)
+		w := tabwriter.NewWriter(os.Stdout, 0, 0, 3, ' ', 0)
+		_, _ = fmt.Fprintln(w, "CHAPTER\tFIRST SEEN\tURL")
+		for _, e := range events {
+			switch e.Type {
+			case history.EventChapter:
+				number := "-"
+				if e.Chapter != nil {
+					number = formatChapterNumber(*e.Chapter)
+				}
+				_, _ = fmt.Fprintf(w, "%s\t%s\t%s\n", number, e.Time.Local().Format(time.DateTime), e.URI)
+			case history.EventCheck:
+				checks = append(checks, e)
+			}
+		}
+		_ = w.Flush()
+
+		if historyChecks > 0 && len(checks) > historyChecks {
+			checks = checks[len(checks)-historyChecks:]
+		}
+		fmt.Printf("\nRecent checks:\n")
+		w = tabwriter.NewWriter(os.Stdout, 0, 0, 3, ' ', 0)
+		_, _ = fmt.Fprintln(w, "TIME\tOUTCOME\tNEW CHAPTERS\tERROR")
+		for _, e := range checks {
+			errorStr := e.Error
+			if errorStr == "" {
+				errorStr = "-"
+			}
+			_, _ = fmt.Fprintf(w, "%s\t%s\t%d\t%s\n", e.Time.Local().Format(time.DateTime), e.Outcome, e.NewChapters, errorStr)
+		}
+		_ = w.Flush()
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(historyCmd)
+	historyCmd.Flags().IntVar(&historyChecks, "checks", 20, "Number of recent checks to show, 0 shows all of them")
+}
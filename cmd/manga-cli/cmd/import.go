@@ -0,0 +1,173 @@
+package cmd
+
+import (
+	"fmt"
+	"io"
+	"log/slog"
+	"os"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/ivan-penchev/manga-updates/internal/domain"
+	"github.com/ivan-penchev/manga-updates/internal/importer"
+	"github.com/spf13/cobra"
+)
+
+var importFormat string
+var importDryRun bool
+var importSearchProviders []string
+
+var importCmd = &cobra.Command{
+	Use:   "import [file]",
+	Short: "Import a tracking list exported from another service",
+	Long: `Import the series of a MangaDex follows export (mangadex), a MyAnimeList XML export (mal),
+an AniList JSON export (anilist) or a plain list of URLs, one per line (urls).
+Entries with a URL are resolved to the provider handling it, the others are matched by searching
+their titles in the search providers. Only entries with a single exact title match are imported,
+ambiguous and unmatched entries are reported so they can be added manually. Dropped and
+planned entries of MyAnimeList and AniList are reported as skipped.
+The format is detected from the file name unless --format is given, use - to read from stdin.`,
+	Example: `  manga-cli import animelist_1700000000_-_123456.xml --dry-run
+  manga-cli import anilist.json --providers mangadex,manganel
+  manga-cli import urls.txt`,
+	Args: cobra.ExactArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		logger := slog.Default()
+		ctx := cmd.Context()
+		path := args[0]
+
+		format := importer.Format(importFormat)
+		if format == "" {
+			var ok bool
+			if format, ok = importer.DetectFormat(path); !ok {
+				logger.Error("cannot detect the export format, use --format", "file", path)
+				os.Exit(1)
+			}
+		}
+
+		var input io.Reader = os.Stdin
+		if path != "-" {
+			file, err := os.Open(path)
+			if err != nil {
+				logger.Error("failed to open export", "file", path, "error", err)
+				os.Exit(1)
+			}
+			defer file.Close()
+			input = file
+		}
+		entries, err := importer.Parse(format, input)
+		if err != nil {
+			logger.Error("failed to read export", "file", path, "error", err)
+			os.Exit(1)
+		}
+
+		application, err := loadApp()
+		if err != nil {
+			logger.Error("failed to load configuration", "error", err)
+			os.Exit(1)
+		}
+
+		providerRouter, err := application.ProviderRouter()
+		if err != nil {
+			logger.Error("failed to create provider router", "error", err)
+			os.Exit(1)
+		}
+
+		searchProviders, err := application.SearchProviders(importSearchProviders...)
+		if err != nil {
+			logger.Error("failed to create provider router", "error", err)
+			os.Exit(1)
+		}
+
+		results, err := importer.NewResolver(providerRouter, searchProviders...).Resolve(ctx, entries)
+		if err != nil {
+			logger.Error("import interrupted", "error", err)
+			os.Exit(1)
+		}
+
+		if !importDryRun {
+			store := application.Store()
+			tracked := make(map[domain.SourceRef]bool)
+			for _, manga := range store.GetMangaSeries(ctx) {
+				for _, ref := range manga.AllSources() {
+					tracked[ref] = true
+				}
+			}
+
+			for i, result := range results {
+				if result.Status != importer.StatusMatched {
+					continue
+				}
+				manga, err := result.Provider.GetMangaFromURL(ctx, result.URL)
+				if err != nil {
+					results[i].Status = importer.StatusFailed
+					results[i].Reason = fmt.Sprintf("failed to fetch manga details: %v", err)
+					continue
+				}
+				manga.ShouldNotify = true
+				manga.Source = result.Provider.Kind()
+
+				ref := domain.SourceRef{Source: manga.Source, Slug: manga.Slug}
+				if tracked[ref] {
+					results[i].Reason = "already tracked"
+					continue
+				}
+				if err := store.AddManga(ctx, manga); err != nil {
+					results[i].Status = importer.StatusFailed
+					results[i].Reason = fmt.Sprintf("failed to save series to store: %v", err)
+					continue
+				}
+				tracked[ref] = true
+				results[i].Reason = "imported"
+			}
+		}
+
+		printImportReport(os.Stdout, results)
+	},
+}
+
+// printImportReport writes one line per entry followed by the count of each status
+func printImportReport(out io.Writer, results []importer.Result) {
+	counts := make(map[importer.Status]int)
+	w := tabwriter.NewWriter(out, 0, 0, 3, ' ', 0)
+	_, _ = fmt.Fprintln(w, "STATUS\tTITLE\tPROVIDER\tURL\tNOTE")
+	for _, r := range results {
+		counts[r.Status]++
+
+		title := r.Entry.Title
+		if title == "" {
+			title = "-"
+		}
+		kind, url := "-", "-"
+		if r.Provider != nil {
+			kind = string(r.Provider.Kind())
+		}
+		if r.URL != "" {
+			url = r.URL
+		} else if len(r.Candidates) > 0 {
+			var candidates []string
+			for _, c := range r.Candidates {
+				candidates = append(candidates, c.URL)
+			}
+			url = strings.Join(candidates, ", ")
+		} else if r.Entry.URL != "" {
+			url = r.Entry.URL
+		}
+		note := r.Reason
+		if note == "" {
+			note = "-"
+		}
+		_, _ = fmt.Fprintf(w, "%s\t%s\t%s\t%s\t%s\n", r.Status, title, kind, url, note)
+	}
+	_ = w.Flush()
+	_, _ = fmt.Fprintf(out, "\n%d matched, %d ambiguous, %d unmatched, %d skipped, %d failed\n",
+		counts[importer.StatusMatched], counts[importer.StatusAmbiguous], counts[importer.StatusUnmatched],
+		counts[importer.StatusSkipped], counts[importer.StatusFailed])
+}
+
+func init() {
+	rootCmd.AddCommand(importCmd)
+	importCmd.Flags().StringVarP(&importFormat, "format", "f", "", "Export format (mangadex, mal, anilist, urls), detected from the file name by default")
+	importCmd.Flags().BoolVar(&importDryRun, "dry-run", false, "Only report how the entries resolve, without adding them")
+	importCmd.Flags().StringSliceVarP(&importSearchProviders, "providers", "p", []string{"mangadex"}, "Providers searched for entries without a supported URL")
+}
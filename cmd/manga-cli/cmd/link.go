@@ -0,0 +1,76 @@
+package cmd
+
+import (
+	"log/slog"
+	"os"
+
+	"github.com/ivan-penchev/manga-updates/internal/domain"
+	"github.com/spf13/cobra"
+)
+
+var linkCmd = &cobra.Command{
+	Use:   "link [series] [url]",
+	Short: "Track a series on an additional source",
+	Long: `Attach another source publishing the same series to a tracked series.
+The series is identified by its slug, title or data file path, the url is resolved like in add.
+Every update checks the primary source first and then the linked sources in the order they
+were linked, chapter lists are merged by chapter number and new chapters are notified from
+whichever source published them first, so a stalled or removed source does not miss chapters.`,
+	Example: `  manga-cli link solo-leveling https://mangadex.org/title/32d76d19-8a05-4db0-9fc2-e0b0648fe9d0/solo-leveling`,
+	Args:    cobra.ExactArgs(2),
+	Run: func(cmd *cobra.Command, args []string) {
+		logger := slog.Default()
+		query, url := args[0], args[1]
+		ctx := cmd.Context()
+
+		application, err := loadApp()
+		if err != nil {
+			logger.Error("failed to load configuration", "error", err)
+			os.Exit(1)
+		}
+
+		store := application.Store()
+		path, manga, err := findSeries(ctx, store, query)
+		if err != nil {
+			logger.Error("failed to find series", "error", err)
+			os.Exit(1)
+		}
+
+		providerRouter, err := application.ProviderRouter()
+		if err != nil {
+			logger.Error("failed to create provider router", "error", err)
+			os.Exit(1)
+		}
+
+		p, err := providerRouter.GetProviderForURL(url)
+		if err != nil {
+			logger.Error("failed to find provider for url", "url", url, "error", err)
+			os.Exit(1)
+		}
+		linked, err := p.GetMangaFromURL(ctx, url)
+		if err != nil {
+			logger.Error("failed to fetch manga details", "url", url, "error", err)
+			os.Exit(1)
+		}
+
+		ref := domain.SourceRef{Source: p.Kind(), Slug: linked.Slug}
+		for _, existing := range manga.AllSources() {
+			if existing == ref {
+				logger.Info("Source is already tracked for this series", "title", manga.Name, "source", ref.Source, "slug", ref.Slug)
+				return
+			}
+		}
+		manga.Sources = append(manga.Sources, ref)
+
+		if err := store.PersistMangaTitle(ctx, path, manga); err != nil {
+			logger.Error("failed to save series to store", "manga", manga.Name, "error", err)
+			os.Exit(1)
+		}
+
+		logger.Info("Successfully linked source", "title", manga.Name, "source", ref.Source, "slug", ref.Slug, "linkedTitle", linked.Name)
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(linkCmd)
+}
@@ -0,0 +1,67 @@
+package cmd
+
+import (
+	"fmt"
+	"log/slog"
+	"os"
+	"sort"
+	"strconv"
+	"text/tabwriter"
+	"time"
+
+	"github.com/spf13/cobra"
+)
+
+var listCmd = &cobra.Command{
+	Use:   "list",
+	Short: "List the tracked series and their reading progress",
+	Long: `List every tracked series with its latest chapter, the last chapter read
+and how many chapters are left to read. Series without a read marker show "-".`,
+	Example: `  manga-cli list`,
+	Args:    cobra.NoArgs,
+	Run: func(cmd *cobra.Command, args []string) {
+		logger := slog.Default()
+
+		application, err := loadApp()
+		if err != nil {
+			logger.Error("failed to load configuration", "error", err)
+			os.Exit(1)
+		}
+
+		series := application.Store().GetMangaSeries(cmd.Context())
+		paths := make([]string, 0, len(series))
+		for path := range series {
+			paths = append(paths, path)
+		}
+		sort.Slice(paths, func(i, j int) bool {
+			return series[paths[i]].Name < series[paths[j]].Name
+		})
+
+		w := tabwriter.NewWriter(os.Stdout, 0, 0, 3, ' ', 0)
+		_, _ = fmt.Fprintln(w, "TITLE\tSOURCE\tLATEST\tREAD\tUNREAD\tLAST UPDATE")
+		for _, path := range paths {
+			manga := series[path]
+			latest, read, unread, lastUpdate := "-", "-", "-", "-"
+			if number, ok := manga.LatestChapterNumber(); ok {
+				latest = formatChapterNumber(number)
+			}
+			if count, ok := manga.UnreadCount(); ok {
+				read = formatChapterNumber(manga.Read.Chapter)
+				unread = strconv.Itoa(count)
+			}
+			if !manga.LastUpdate.IsZero() {
+				lastUpdate = manga.LastUpdate.Format(time.DateOnly)
+			}
+			_, _ = fmt.Fprintf(w, "%s\t%s\t%s\t%s\t%s\t%s\n", manga.Name, manga.Source, latest, read, unread, lastUpdate)
+		}
+		_ = w.Flush()
+	},
+}
+
+func formatChapterNumber(number float64) string {
+	return strconv.FormatFloat(number, 'f', -1, 64)
+}
+
+func init() {
+	rootCmd.AddCommand(listCmd)
+}
@@ -0,0 +1,152 @@
+package cmd
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"os"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"github.com/ivan-penchev/manga-updates/internal/domain"
+	"github.com/spf13/cobra"
+)
+
+var providersOutput string
+var providersTimeout time.Duration
+
+// providerStatus is the diagnostic result for a single registered provider
+type providerStatus struct {
+	Kind        domain.MangaSource   `json:"kind"`
+	URLPatterns []string             `json:"urlPatterns"`
+	Available   bool                 `json:"available"`
+	Error       string               `json:"error,omitempty"`
+	Health      *domain.HealthReport `json:"health,omitempty"`
+}
+
+var providersCmd = &cobra.Command{
+	Use:   "providers",
+	Short: "Inspect the configured providers",
+}
+
+var providersStatusCmd = &cobra.Command{
+	Use:   "status",
+	Short: "Show the health of every registered provider",
+	Long: `List every provider registered with the router, the URL patterns it handles
+and the result of its health check (endpoint reachable, authentication valid,
+response schema as expected and latency).
+Providers that do not support health checks are only initialized.`,
+	Example: `  manga-cli providers status
+  manga-cli providers status --output json`,
+	Args: cobra.NoArgs,
+	Run: func(cmd *cobra.Command, args []string) {
+		logger := slog.Default()
+
+		if providersOutput != "table" && providersOutput != "json" {
+			logger.Error("unsupported output format", "output", providersOutput)
+			os.Exit(1)
+		}
+
+		application, err := loadApp()
+		if err != nil {
+			logger.Error("failed to load configuration", "error", err)
+			os.Exit(1)
+		}
+
+		providerRouter, err := application.ProviderRouter()
+		if err != nil {
+			logger.Error("failed to create provider router", "error", err)
+			os.Exit(1)
+		}
+
+		statuses := checkProviders(cmd.Context(), providerRouter, providersTimeout)
+
+		if providersOutput == "json" {
+			encoder := json.NewEncoder(os.Stdout)
+			encoder.SetIndent("", "  ")
+			if err := encoder.Encode(statuses); err != nil {
+				logger.Error("failed to encode provider status", "error", err)
+				os.Exit(1)
+			}
+			return
+		}
+
+		w := tabwriter.NewWriter(os.Stdout, 0, 0, 3, ' ', 0)
+		_, _ = fmt.Fprintln(w, "KIND\tSTATUS\tREACHABLE\tAUTH\tSCHEMA\tLATENCY\tURL PATTERNS\tERROR")
+		for _, s := range statuses {
+			reachable, auth, schema, latency := "-", "-", "-", "-"
+			errorStr := s.Error
+			status := "unavailable"
+			if s.Available {
+				status = "ok"
+			}
+			if s.Health != nil {
+				reachable = yesNo(s.Health.Reachable)
+				if s.Health.AuthValid != nil {
+					auth = yesNo(*s.Health.AuthValid)
+				}
+				schema = yesNo(s.Health.SchemaValid)
+				latency = s.Health.Latency.Round(time.Millisecond).String()
+				if !s.Health.Healthy() {
+					status = "unhealthy"
+					errorStr = s.Health.Error
+				}
+			}
+			if errorStr == "" {
+				errorStr = "-"
+			}
+			_, _ = fmt.Fprintf(w, "%s\t%s\t%s\t%s\t%s\t%s\t%s\t%s\n", s.Kind, status, reachable, auth, schema, latency, strings.Join(s.URLPatterns, ", "), errorStr)
+		}
+		_ = w.Flush()
+	},
+}
+
+// checkProviders initializes every provider of the router and runs the
+// health check of those implementing domain.HealthChecker.
+func checkProviders(ctx context.Context, router domain.ProviderRouter, timeout time.Duration) []providerStatus {
+	var statuses []providerStatus
+	for _, info := range router.Providers() {
+		status := providerStatus{
+			Kind:        info.Kind,
+			URLPatterns: info.URLPatterns,
+		}
+		if info.InitError != nil {
+			status.Error = info.InitError.Error()
+			statuses = append(statuses, status)
+			continue
+		}
+
+		p, err := router.GetProvider(domain.MangaEntity{Source: info.Kind})
+		if err != nil {
+			status.Error = err.Error()
+			statuses = append(statuses, status)
+			continue
+		}
+		status.Available = true
+
+		if checker, ok := p.(domain.HealthChecker); ok {
+			checkCtx, cancel := context.WithTimeout(ctx, timeout)
+			report := checker.HealthCheck(checkCtx)
+			cancel()
+			status.Health = &report
+		}
+		statuses = append(statuses, status)
+	}
+	return statuses
+}
+
+func yesNo(b bool) string {
+	if b {
+		return "yes"
+	}
+	return "no"
+}
+
+func init() {
+	rootCmd.AddCommand(providersCmd)
+	providersCmd.AddCommand(providersStatusCmd)
+	providersStatusCmd.Flags().StringVarP(&providersOutput, "output", "o", "table", "Output format (table, json)")
+	providersStatusCmd.Flags().DurationVar(&providersTimeout, "timeout", 30*time.Second, "Timeout of each provider health check")
+}
@@ -0,0 +1,101 @@
+package cmd
+
+import (
+	"log/slog"
+	"os"
+	"strconv"
+	"time"
+
+	"github.com/ivan-penchev/manga-updates/internal/domain"
+	"github.com/spf13/cobra"
+)
+
+var readCmd = &cobra.Command{
+	Use:   "read [series] [chapter]",
+	Short: "Mark a series as read up to a chapter",
+	Long: `Record the last chapter read of a tracked series, the series is identified by
+its slug, title or data file path. When no chapter is given the latest known chapter is used.
+Notifications and the list command then report how many chapters are left to read.`,
+	Example: `  manga-cli read solo-leveling 110
+  manga-cli read solo-leveling`,
+	Args: cobra.RangeArgs(1, 2),
+	Run: func(cmd *cobra.Command, args []string) {
+		logger := slog.Default()
+		ctx := cmd.Context()
+
+		application, err := loadApp()
+		if err != nil {
+			logger.Error("failed to load configuration", "error", err)
+			os.Exit(1)
+		}
+
+		store := application.Store()
+		path, manga, err := findSeries(ctx, store, args[0])
+		if err != nil {
+			logger.Error("failed to find series", "error", err)
+			os.Exit(1)
+		}
+
+		var chapter float64
+		if len(args) == 2 {
+			chapter, err = strconv.ParseFloat(args[1], 64)
+			if err != nil {
+				logger.Error("invalid chapter number", "chapter", args[1], "error", err)
+				os.Exit(1)
+			}
+		} else {
+			var ok bool
+			chapter, ok = manga.LatestChapterNumber()
+			if !ok {
+				logger.Error("series has no numbered chapters, pass the chapter explicitly", "title", manga.Name)
+				os.Exit(1)
+			}
+		}
+
+		manga.Read = &domain.ReadMarker{Chapter: chapter, ReadAt: time.Now()}
+		if err := store.PersistMangaTitle(ctx, path, manga); err != nil {
+			logger.Error("failed to save series to store", "manga", manga.Name, "error", err)
+			os.Exit(1)
+		}
+
+		unread, _ := manga.UnreadCount()
+		logger.Info("Marked series as read", "title", manga.Name, "chapter", chapter, "unread", unread)
+	},
+}
+
+var unreadCmd = &cobra.Command{
+	Use:     "unread [series]",
+	Short:   "Clear the reading progress of a series",
+	Example: `  manga-cli unread solo-leveling`,
+	Args:    cobra.ExactArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		logger := slog.Default()
+		ctx := cmd.Context()
+
+		application, err := loadApp()
+		if err != nil {
+			logger.Error("failed to load configuration", "error", err)
+			os.Exit(1)
+		}
+
+		store := application.Store()
+		path, manga, err := findSeries(ctx, store, args[0])
+		if err != nil {
+			logger.Error("failed to find series", "error", err)
+			os.Exit(1)
+		}
+
+		manga.Read = nil
+		if err := store.PersistMangaTitle(ctx, path, manga); err != nil {
+			logger.Error("failed to save series to store", "manga", manga.Name, "error", err)
+			os.Exit(1)
+		}
+
+		logger.Info("Cleared reading progress", "title", manga.Name)
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(readCmd)
+	rootCmd.AddCommand(unreadCmd)
+}
@@ -0,0 +1,70 @@
+package cmd
+
+import (
+	"io"
+	"log/slog"
+	"os"
+
+	"github.com/ivan-penchev/manga-updates/internal/backup"
+	"github.com/spf13/cobra"
+)
+
+var restoreOnConflict string
+
+var restoreCmd = &cobra.Command{
+	Use:   "restore [file]",
+	Short: "Restore the tracking library from an export archive",
+	Long: `Validate an archive written by the export command and add its series to the store.
+Series already tracked on the same source and slug are handled according to --on-conflict:
+skip keeps the tracked series, overwrite replaces it with the archived one and merge adds the
+archived chapters to it and keeps the furthest read progress. Use - to read from stdin.`,
+	Example: `  manga-cli restore library.tar.gz
+  manga-cli restore library.json --on-conflict merge`,
+	Args: cobra.ExactArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		logger := slog.Default()
+		path := args[0]
+
+		var input io.Reader = os.Stdin
+		if path != "-" {
+			file, err := os.Open(path)
+			if err != nil {
+				logger.Error("failed to open archive", "file", path, "error", err)
+				os.Exit(1)
+			}
+			defer file.Close()
+			input = file
+		}
+		archive, err := backup.Read(input)
+		if err != nil {
+			logger.Error("invalid archive", "file", path, "error", err)
+			os.Exit(1)
+		}
+
+		application, err := loadApp()
+		if err != nil {
+			logger.Error("failed to load configuration", "error", err)
+			os.Exit(1)
+		}
+
+		store := application.Store()
+
+		report, err := backup.Restore(cmd.Context(), store, archive, backup.ConflictPolicy(restoreOnConflict))
+		if err != nil {
+			logger.Error("failed to restore library", "error", err)
+			os.Exit(1)
+		}
+
+		logger.Info("Restored library",
+			"added", len(report.Added),
+			"overwritten", len(report.Overwritten),
+			"merged", len(report.Merged),
+			"skipped", len(report.Skipped),
+		)
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(restoreCmd)
+	restoreCmd.Flags().StringVar(&restoreOnConflict, "on-conflict", string(backup.ConflictSkip), "What to do with series already tracked (skip, overwrite, merge)")
+}
@@ -2,29 +2,58 @@ package cmd
 
 import (
 	"fmt"
+	"io"
 	"log/slog"
 	"os"
 
+	"github.com/ivan-penchev/manga-updates/internal/app"
+	"github.com/ivan-penchev/manga-updates/internal/logging"
 	"github.com/spf13/cobra"
 )
 
 var (
-	cfgFile string
+	cfgFile   string
+	logLevel  string
+	logFormat string
+	logFile   string
+
+	// logCloser releases the log file once the command is done
+	logCloser io.Closer
 )
 
 var rootCmd = &cobra.Command{
 	Use:   "manga-cli",
 	Short: "Manga Updates CLI",
 	Long:  `A command line interface for managing and updating manga series.`,
-	PersistentPreRun: func(cmd *cobra.Command, args []string) {
-		logger := slog.New(slog.NewJSONHandler(os.Stdout, &slog.HandlerOptions{
-			Level: slog.LevelInfo,
-		}))
+	// logs go to stderr so that they never mix with the tables and documents printed on stdout
+	PersistentPreRunE: func(cmd *cobra.Command, args []string) error {
+		logger, closer, err := logging.New(logging.Config{
+			Level:  logLevel,
+			Format: logging.Format(logFormat),
+			File:   logFile,
+		}, os.Stderr)
+		if err != nil {
+			return err
+		}
+		logCloser = closer
 		slog.SetDefault(logger)
+		return nil
+	},
+	PersistentPostRun: func(cmd *cobra.Command, args []string) {
+		if logCloser != nil {
+			_ = logCloser.Close()
+		}
 	},
 }
 
-func Execute() {
+// loadApp wires the application of a command from the --config file, or from the environment
+func loadApp(opts ...app.Option) (*app.App, error) {
+	return app.Load(cfgFile, append([]app.Option{app.WithLogger(slog.Default())}, opts...)...)
+}
+
+// Execute runs the command line, version is printed by --version
+func Execute(version string) {
+	rootCmd.Version = version
 	if err := rootCmd.Execute(); err != nil {
 		fmt.Println(err)
 		os.Exit(1)
@@ -33,4 +62,7 @@ func Execute() {
 
 func init() {
 	rootCmd.PersistentFlags().StringVar(&cfgFile, "config", "", "config file (default is $CONFIG_FILE)")
+	rootCmd.PersistentFlags().StringVar(&logLevel, "log-level", "info", "Minimum level of the logs (debug, info, warn, error)")
+	rootCmd.PersistentFlags().StringVar(&logFormat, "log-format", string(logging.FormatText), "Format of the logs (text, json)")
+	rootCmd.PersistentFlags().StringVar(&logFile, "log-file", "", "Append the logs to this file instead of stderr")
 }
@@ -6,9 +6,7 @@ import (
 	"os"
 	"text/tabwriter"
 
-	"github.com/ivan-penchev/manga-updates/internal/config"
 	"github.com/ivan-penchev/manga-updates/internal/domain"
-	"github.com/ivan-penchev/manga-updates/internal/provider"
 	"github.com/spf13/cobra"
 )
 
@@ -30,44 +28,23 @@ Examples:
 		query := args[0]
 		logger := slog.Default()
 
-		cfg, err := config.Load(cfgFile)
+		application, err := loadApp()
 		if err != nil {
-			logger.Error("failed to parse configuration", "error", err)
+			logger.Error("failed to load configuration", "error", err)
 			os.Exit(1)
 		}
 
-		var factories []func() (domain.Provider, error)
-
-		uniqueProviders := make(map[string]bool)
-		for _, p := range providersList {
-			uniqueProviders[p] = true
-		}
-
-		if uniqueProviders["manganel"] {
-			factories = append(factories, provider.NewMangaNelProviderFactory(provider.MangaNelProviderConfig{
-				GraphQLEndpoint: cfg.MangaNelGraphQLEndpoint,
-				RemoteChromeURL: cfg.RemoteChromeURL,
-			}))
-		}
-		if uniqueProviders["mangadex"] {
-			factories = append(factories, provider.NewMangaDexProviderFactory())
+		providers, err := application.SearchProviders(providersList...)
+		if err != nil {
+			logger.Error("failed to create provider router", "error", err)
+			os.Exit(1)
 		}
-
-		if len(factories) == 0 {
+		if len(providers) == 0 {
 			logger.Warn("No valid providers selected. Defaulting to manganel.")
-			factories = append(factories, provider.NewMangaNelProviderFactory(provider.MangaNelProviderConfig{
-				GraphQLEndpoint: cfg.MangaNelGraphQLEndpoint,
-				RemoteChromeURL: cfg.RemoteChromeURL,
-			}))
+			providers, _ = application.SearchProviders(string(domain.MangaSourceMangaNel))
 		}
 
-		for _, factory := range factories {
-			p, err := factory()
-			if err != nil {
-				logger.Error("failed to init provider", "error", err)
-				continue
-			}
-
+		for _, p := range providers {
 			results, totalCount, err := p.Search(cmd.Context(), query, offset)
 			if err != nil {
 				logger.Warn("search failed for provider", "provider", p.Kind(), "error", err)
@@ -0,0 +1,35 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/ivan-penchev/manga-updates/internal/domain"
+	"github.com/ivan-penchev/manga-updates/internal/store"
+)
+
+// findSeries looks up a tracked series by data file path, slug, file name or title,
+// the query has to match a single series.
+func findSeries(ctx context.Context, s store.Store, query string) (string, domain.MangaEntity, error) {
+	var matches []string
+	series := s.GetMangaSeries(ctx)
+	for path, manga := range series {
+		name := strings.TrimSuffix(filepath.Base(path), filepath.Ext(path))
+		if path == query || manga.Slug == query || name == query || strings.EqualFold(manga.Name, query) {
+			matches = append(matches, path)
+		}
+	}
+
+	switch len(matches) {
+	case 0:
+		return "", domain.MangaEntity{}, fmt.Errorf("no tracked series matches %q", query)
+	case 1:
+		return matches[0], series[matches[0]], nil
+	default:
+		sort.Strings(matches)
+		return "", domain.MangaEntity{}, fmt.Errorf("%q matches several series, use the data file path instead: %s", query, strings.Join(matches, ", "))
+	}
+}
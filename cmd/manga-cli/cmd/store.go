@@ -0,0 +1,63 @@
+package cmd
+
+import (
+	"fmt"
+	"log/slog"
+	"os"
+	"strings"
+
+	"github.com/ivan-penchev/manga-updates/internal/store"
+	"github.com/spf13/cobra"
+)
+
+var storeUpgradeDryRun bool
+
+var storeCmd = &cobra.Command{
+	Use:   "store",
+	Short: "Maintain the series data files",
+}
+
+var storeUpgradeCmd = &cobra.Command{
+	Use:   "upgrade",
+	Short: "Migrate the series data files to the current schema version",
+	Long: `Rewrite every series data file written by an older version in the current schema,
+fixing renamed keys and dropping fields that are no longer used. Older files are also read
+transparently, this only saves the migration to disk. Use --dry-run to list the pending changes.`,
+	Example: `  manga-cli store upgrade --dry-run
+  manga-cli store upgrade`,
+	Args: cobra.NoArgs,
+	Run: func(cmd *cobra.Command, args []string) {
+		logger := slog.Default()
+
+		application, err := loadApp()
+		if err != nil {
+			logger.Error("failed to load configuration", "error", err)
+			os.Exit(1)
+		}
+
+		upgrades, err := store.UpgradeFiles(application.Config.SeriesDataFolder, storeUpgradeDryRun)
+		if err != nil {
+			logger.Error("failed to upgrade data files", "error", err)
+			os.Exit(1)
+		}
+
+		for _, u := range upgrades {
+			changes := "schema version only"
+			if len(u.Changes) > 0 {
+				changes = strings.Join(u.Changes, ", ")
+			}
+			fmt.Printf("%s: v%d -> v%d: %s\n", u.Location, u.From, u.To, changes)
+		}
+		if storeUpgradeDryRun {
+			logger.Info("Dry run, no file was changed", "pending", len(upgrades))
+			return
+		}
+		logger.Info("Upgraded data files", "upgraded", len(upgrades), "schemaVersion", store.CurrentSchemaVersion)
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(storeCmd)
+	storeCmd.AddCommand(storeUpgradeCmd)
+	storeUpgradeCmd.Flags().BoolVar(&storeUpgradeDryRun, "dry-run", false, "Only list the files that would be migrated")
+}
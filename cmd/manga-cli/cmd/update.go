@@ -4,13 +4,11 @@ import (
 	"context"
 	"log/slog"
 	"os"
+	"os/signal"
+	"syscall"
 	"time"
 
-	"github.com/ivan-penchev/manga-updates/internal/config"
-	"github.com/ivan-penchev/manga-updates/internal/notifier"
-	"github.com/ivan-penchev/manga-updates/internal/provider"
-	"github.com/ivan-penchev/manga-updates/internal/store"
-	updatechecker "github.com/ivan-penchev/manga-updates/internal/update-checker"
+	"github.com/ivan-penchev/manga-updates/internal/app"
 	"github.com/spf13/cobra"
 )
 
@@ -21,68 +19,34 @@ var updateCmd = &cobra.Command{
 		logger := slog.Default()
 
 		ts := time.Now()
-		ctx := context.Background()
+		ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+		defer stop()
 
-		cfg, err := config.Load(cfgFile)
+		application, err := loadApp()
 		if err != nil {
-			logger.Error("failed to parse configuration", "error", err)
+			logger.Error("failed to load configuration", "error", err)
 			os.Exit(1)
 		}
-		store := store.NewStore(cfg.SeriesDataFolder)
-		persistedMangaSeries := store.GetMangaSeries(ctx)
+		defer closeApp(application, logger)
 
-		if len(persistedMangaSeries) == 0 {
-			logger.Info("No series to monitor")
-			return
-		}
-
-		notifierOptions := []notifier.NotifierOption{
-			notifier.WithRecipients(cfg.Notifier.RecipientEmail),
-			notifier.WithSenderEmail(cfg.Notifier.SenderEmail),
-		}
-
-		if cfg.Notifier.SendGrid.APIKey != "" {
-			notifierOptions = append(notifierOptions, notifier.WithTemplateID(cfg.Notifier.SendGrid.TemplateID))
-			notifierOptions = append(notifierOptions, notifier.WithSendGridAPIKey(cfg.Notifier.SendGrid.APIKey))
-		} else if cfg.Notifier.SMTP2GO.APIKey != "" {
-			notifierOptions = append(notifierOptions, notifier.WithTemplateID(cfg.Notifier.SMTP2GO.TemplateID))
-			notifierOptions = append(notifierOptions, notifier.WithSMTP2GOAPIKey(cfg.Notifier.SMTP2GO.APIKey))
-		}
-
-		notif, err := notifier.NewNotifier(notifierOptions...)
-		if err != nil {
-			logger.Error("failed to create notifier", "error", err)
-			os.Exit(1)
-		}
-
-		providerRouter, err := provider.NewProviderRouter(
-			provider.NewMangaNelProviderFactory(provider.MangaNelProviderConfig{
-				GraphQLEndpoint: cfg.MangaNelGraphQLEndpoint,
-				RemoteChromeURL: cfg.RemoteChromeURL,
-			}),
-			provider.NewMangaDexProviderFactory(),
-		)
-
-		if err != nil {
-			logger.Error("failed to create provider router", "error", err)
-			os.Exit(1)
-		}
-
-		updatecheckerService, err := updatechecker.NewUpdateCheckerService(notif, store, providerRouter, logger)
-
-		if err != nil {
-			logger.Error("failed to create update checker service", "error", err)
-			os.Exit(1)
-		}
-		err = updatecheckerService.CheckForUpdates(ctx)
-		if err != nil {
+		if err := application.RunUpdate(ctx); err != nil {
 			logger.Error("failed to check for updates", "error", err)
+			closeApp(application, logger)
 			os.Exit(1)
 		}
 		logger.Info("Completed manga-updates update", "durationInSeconds", time.Since(ts).Seconds())
 	},
 }
 
+// closeApp exports the spans still buffered, those of a run interrupted by a signal included
+func closeApp(application *app.App, logger *slog.Logger) {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	if err := application.Close(ctx); err != nil {
+		logger.Error("failed to flush traces", "error", err)
+	}
+}
+
 func init() {
 	rootCmd.AddCommand(updateCmd)
 }
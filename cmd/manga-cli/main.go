@@ -2,6 +2,9 @@ package main
 
 import "github.com/ivan-penchev/manga-updates/cmd/manga-cli/cmd"
 
+// version is set by the release build
+var version = "dev"
+
 func main() {
-	cmd.Execute()
+	cmd.Execute(version)
 }
@@ -1,3 +1,5 @@
+// Command manga-updates checks every tracked series once, it is kept for the workflows
+// written before manga-cli and is equivalent to `manga-cli update`.
 package main
 
 import (
@@ -6,79 +8,60 @@ import (
 	"fmt"
 	"log/slog"
 	"os"
+	"os/signal"
+	"syscall"
 	"time"
 
-	"github.com/ivan-penchev/manga-updates/internal/config"
-	"github.com/ivan-penchev/manga-updates/internal/notifier"
-	"github.com/ivan-penchev/manga-updates/internal/provider"
-	"github.com/ivan-penchev/manga-updates/internal/store"
-	updatechecker "github.com/ivan-penchev/manga-updates/internal/update-checker"
+	"github.com/ivan-penchev/manga-updates/internal/app"
+	"github.com/ivan-penchev/manga-updates/internal/logging"
 )
 
+// version is set by the release build
+var version = "dev"
+
 func main() {
 	configFlag := flag.String("config", "", "config file path")
+	logLevelFlag := flag.String("log-level", "info", "minimum level of the logs (debug, info, warn, error)")
+	logFormatFlag := flag.String("log-format", string(logging.FormatJSON), "format of the logs (text, json)")
+	logFileFlag := flag.String("log-file", "", "append the logs to this file instead of stdout")
+	versionFlag := flag.Bool("version", false, "print the version and exit")
 	flag.Parse()
 
-	logger := slog.New(slog.NewJSONHandler(os.Stdout, &slog.HandlerOptions{
-		Level: slog.LevelInfo,
-	}))
-	slog.SetDefault(logger)
-
-	ts := time.Now()
-	ctx := context.Background()
-
-	cfg, err := config.Load(*configFlag)
-	if err != nil {
-		logger.Error("failed to parse configuration", "error", err)
-		os.Exit(1)
-	}
-	store := store.NewStore(cfg.SeriesDataFolder)
-	persistedMangaSeries := store.GetMangaSeries(ctx)
-
-	if len(persistedMangaSeries) == 0 {
-		fmt.Println("No series to monitor")
+	if *versionFlag {
+		fmt.Println(version)
 		return
 	}
 
-	notifierOptions := []notifier.NotifierOption{
-		notifier.WithRecipients(cfg.Notifier.RecipientEmail),
-		notifier.WithSenderEmail(cfg.Notifier.SenderEmail),
-	}
-
-	if cfg.Notifier.SendGrid.APIKey != "" {
-		notifierOptions = append(notifierOptions, notifier.WithTemplateID(cfg.Notifier.SendGrid.TemplateID))
-		notifierOptions = append(notifierOptions, notifier.WithSendGridAPIKey(cfg.Notifier.SendGrid.APIKey))
-	} else if cfg.Notifier.SMTP2GO.APIKey != "" {
-		notifierOptions = append(notifierOptions, notifier.WithTemplateID(cfg.Notifier.SMTP2GO.TemplateID))
-		notifierOptions = append(notifierOptions, notifier.WithSMTP2GOAPIKey(cfg.Notifier.SMTP2GO.APIKey))
-	}
-
-	notifier, err := notifier.NewNotifier(notifierOptions...)
+	logger, logCloser, err := logging.New(logging.Config{
+		Level:  *logLevelFlag,
+		Format: logging.Format(*logFormatFlag),
+		File:   *logFileFlag,
+	}, os.Stdout)
 	if err != nil {
-		logger.Error("failed to create notifier", "error", err)
-		os.Exit(1)
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(2)
 	}
+	defer logCloser.Close()
+	slog.SetDefault(logger)
 
-	providerRouter, err := provider.NewProviderRouter(
-		provider.NewMangaNelProviderFactory(provider.MangaNelProviderConfig{
-			GraphQLEndpoint: cfg.MangaNelGraphQLEndpoint,
-			RemoteChromeURL: cfg.RemoteChromeURL,
-		}),
-		provider.NewMangaDexProviderFactory(),
-	)
+	ts := time.Now()
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
 
+	application, err := app.Load(*configFlag, app.WithLogger(logger))
 	if err != nil {
-		logger.Error("failed to create provider router", "error", err)
+		logger.Error("failed to load configuration", "error", err)
 		os.Exit(1)
 	}
 
-	updatecheckerService, err := updatechecker.NewUpdateCheckerService(notifier, store, providerRouter, logger)
+	err = application.RunUpdate(ctx)
 
-	if err != nil {
-		logger.Error("failed to create update checker service", "error", err)
-		os.Exit(1)
+	closeCtx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	if closeErr := application.Close(closeCtx); closeErr != nil {
+		logger.Error("failed to flush traces", "error", closeErr)
 	}
-	err = updatecheckerService.CheckForUpdates(ctx)
+
 	if err != nil {
 		logger.Error("failed to check for updates", "error", err)
 		os.Exit(1)
@@ -5,4 +5,5 @@ REMOTE_CHROME_URL=ws://127.0.0.1:3000
 SMTP2GO_API_KEY=
 SMTP2GO_TEMPLATE_ID=
 SENDGRID_API_KEY=
-SENDGRID_TEMPLATE_ID=
\ No newline at end of file
+SENDGRID_TEMPLATE_ID=
+FEED_OUTPUT_DIR=
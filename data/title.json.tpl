@@ -1,10 +1,10 @@
 {
+  "schemaVersion": 1,
   "name": "",
   "shouldNotify": true,
   "lastUpdate": null,
   "slug": "<insert id string>",
   "status": "",
-  "latestChapter": null,
   "source": "<insert manganel | mangadex>",
   "chapters": []
 }
@@ -17,9 +17,9 @@ func TestMangaDexProvider(t *testing.T) {
 		t.Skip("Skipping integration test in short mode")
 	}
 
-	factory := provider.NewMangaDexProviderFactory()
+	factory := provider.NewMangaDexProviderFactory(provider.MangaDexProviderConfig{})
 
-	prov, err := factory()
+	prov, err := factory.New()
 	require.NoError(t, err)
 
 	ctx, cancel := context.WithTimeout(context.Background(), 1*time.Minute)
@@ -0,0 +1,366 @@
+// Package app wires the components of manga-updates from the configuration:
+// config → store → providers → notifier → update checker.
+//
+// Both binaries build an App and run their commands on top of it, components
+// are created on first use so commands only pay for what they need.
+package app
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"path/filepath"
+	"strings"
+	"sync"
+
+	"github.com/ivan-penchev/manga-updates/internal/config"
+	"github.com/ivan-penchev/manga-updates/internal/domain"
+	"github.com/ivan-penchev/manga-updates/internal/feed"
+	"github.com/ivan-penchev/manga-updates/internal/gitstore"
+	"github.com/ivan-penchev/manga-updates/internal/history"
+	"github.com/ivan-penchev/manga-updates/internal/metrics"
+	"github.com/ivan-penchev/manga-updates/internal/notifier"
+	"github.com/ivan-penchev/manga-updates/internal/provider"
+	"github.com/ivan-penchev/manga-updates/internal/store"
+	"github.com/ivan-penchev/manga-updates/internal/tracing"
+	updatechecker "github.com/ivan-penchev/manga-updates/internal/update-checker"
+)
+
+type App struct {
+	Config *config.Config
+	Logger *slog.Logger
+
+	metrics *metrics.Metrics
+	store   store.Store
+
+	routerOnce sync.Once
+	router     domain.ProviderRouter
+	routerErr  error
+
+	tracer *tracing.Provider
+}
+
+type Option func(*App)
+
+// WithLogger sets the logger handed to every component, slog.Default() by default
+func WithLogger(logger *slog.Logger) Option {
+	return func(a *App) {
+		a.Logger = logger
+	}
+}
+
+// WithMetrics records the provider calls, the notifications and the update runs
+func WithMetrics(m *metrics.Metrics) Option {
+	return func(a *App) {
+		a.metrics = m
+	}
+}
+
+func New(cfg *config.Config, opts ...Option) (*App, error) {
+	a := &App{
+		Config: cfg,
+		Logger: slog.Default(),
+	}
+	for _, opt := range opts {
+		opt(a)
+	}
+
+	layout, err := store.ParseLayout(cfg.SeriesDataLayout)
+	if err != nil {
+		return nil, err
+	}
+	a.store = store.NewStore(cfg.SeriesDataFolder, store.WithLayout(layout), store.WithLogger(a.Logger))
+	return a, nil
+}
+
+// Load reads the configuration file, or the environment when configFile is empty, and wires the App
+func Load(configFile string, opts ...Option) (*App, error) {
+	cfg, err := config.Load(configFile)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse configuration: %w", err)
+	}
+	return New(cfg, opts...)
+}
+
+// Store returns the store of the series data folder
+func (a *App) Store() store.Store {
+	return a.store
+}
+
+// ProviderFactories returns the factories of every provider enabled in the configuration
+func (a *App) ProviderFactories() []provider.ProviderFactory {
+	cfg := a.Config
+	factories := []provider.ProviderFactory{
+		provider.NewMangaNelProviderFactory(provider.MangaNelProviderConfig{
+			GraphQLEndpoint:   cfg.MangaNelGraphQLEndpoint,
+			RemoteChromeURL:   cfg.RemoteChromeURL,
+			TokenCacheFile:    cfg.MangaNelTokenCacheFile,
+			RequestsPerSecond: cfg.RateLimits.MangaNel,
+			CacheDir:          cfg.HTTPCacheDirFor(string(domain.MangaSourceMangaNel)),
+			Logger:            a.Logger,
+		}),
+		provider.NewMangaDexProviderFactory(provider.MangaDexProviderConfig{
+			RequestsPerSecond: cfg.RateLimits.MangaDex,
+			CacheDir:          cfg.HTTPCacheDirFor(string(domain.MangaSourceMangaDex)),
+			Logger:            a.Logger,
+		}),
+		provider.NewFeedProviderFactory(provider.FeedProviderConfig{
+			URLPatterns:     cfg.FeedProvider.URLPatterns,
+			ChapterPatterns: cfg.FeedProvider.ChapterPatterns,
+			CacheDir:        cfg.HTTPCacheDirFor(string(domain.MangaSourceFeed)),
+			Logger:          a.Logger,
+		}),
+	}
+	for _, external := range cfg.ExternalProviders {
+		factories = append(factories, provider.NewExternalProviderFactory(provider.ExternalProviderConfig{
+			Command:     external.Command,
+			Args:        external.Args,
+			Kind:        domain.MangaSource(external.Kind),
+			URLPatterns: external.URLPatterns,
+			Timeout:     external.Timeout,
+			Logger:      a.Logger,
+		}))
+	}
+	for _, scraper := range cfg.Scrapers {
+		factories = append(factories, provider.NewScraperProviderFactory(provider.ScraperProviderConfig{
+			Kind:       domain.MangaSource(scraper.Kind),
+			URLPattern: scraper.URLPattern,
+			Selectors: provider.ScraperSelectors{
+				Title:         scraper.Selectors.Title,
+				Status:        scraper.Selectors.Status,
+				ChapterList:   scraper.Selectors.ChapterList,
+				ChapterNumber: scraper.Selectors.ChapterNumber,
+				ChapterLink:   scraper.Selectors.ChapterLink,
+				ChapterDate:   scraper.Selectors.ChapterDate,
+			},
+			ChapterNumberPattern: scraper.ChapterNumberPattern,
+			DateFormat:           scraper.DateFormat,
+			DateAttribute:        scraper.DateAttribute,
+			CacheDir:             cfg.HTTPCacheDirFor(scraper.Kind),
+			Logger:               a.Logger,
+		}))
+	}
+
+	if a.metrics != nil {
+		factories = a.metrics.InstrumentProviders(factories)
+	}
+	return factories
+}
+
+// ProviderRouter returns the router of the configured providers, created once and
+// shared so the providers keep their caches and rate limits across commands.
+func (a *App) ProviderRouter() (domain.ProviderRouter, error) {
+	a.routerOnce.Do(func() {
+		a.router, a.routerErr = provider.NewProviderRouter(a.ProviderFactories()...)
+	})
+	return a.router, a.routerErr
+}
+
+// SearchProviders returns the providers of the given kinds, the unavailable ones are logged and left out
+func (a *App) SearchProviders(kinds ...string) ([]domain.Provider, error) {
+	router, err := a.ProviderRouter()
+	if err != nil {
+		return nil, err
+	}
+
+	var providers []domain.Provider
+	seen := make(map[string]bool)
+	for _, kind := range kinds {
+		if seen[kind] {
+			continue
+		}
+		seen[kind] = true
+		p, err := router.GetProvider(domain.MangaEntity{Source: domain.MangaSource(kind)})
+		if err != nil {
+			a.Logger.Warn("search provider is unavailable", "provider", kind, "error", err)
+			continue
+		}
+		providers = append(providers, p)
+	}
+	return providers, nil
+}
+
+// Notifier returns the notifier of the configured email service, standard output when none is configured.
+// It receives the series nobody is subscribed to, which are only logged when users are configured
+// without a recipient_email.
+func (a *App) Notifier() (updatechecker.Notifier, error) {
+	if a.Config.Notifier.RecipientEmail == "" && len(a.Config.Users) > 0 {
+		return a.newNotifier("stdout", notifier.WithLogger(a.Logger))
+	}
+	return a.emailNotifier(a.Config.Notifier.RecipientEmail)
+}
+
+// Subscribers returns the configured users, each notified through the email service at their own addresses
+func (a *App) Subscribers() ([]updatechecker.Subscriber, error) {
+	subscribers := make([]updatechecker.Subscriber, 0, len(a.Config.Users))
+	seen := make(map[string]bool)
+	for _, user := range a.Config.Users {
+		if user.Name == "" {
+			return nil, errors.New("every user needs a name")
+		}
+		if seen[user.Name] {
+			return nil, fmt.Errorf("user %s is configured more than once", user.Name)
+		}
+		seen[user.Name] = true
+
+		n, err := a.emailNotifier(user.Emails...)
+		if err != nil {
+			return nil, fmt.Errorf("user %s: %w", user.Name, err)
+		}
+		series := make([]domain.SourceRef, 0, len(user.Series))
+		for _, entry := range user.Series {
+			// the slug of a feed is its url, so only the first colon separates the source
+			source, slug, _ := strings.Cut(entry, ":")
+			if source == "" || slug == "" {
+				return nil, fmt.Errorf("user %s: series %q is not <source>:<slug>", user.Name, entry)
+			}
+			series = append(series, domain.SourceRef{Source: domain.MangaSource(source), Slug: slug})
+		}
+		subscribers = append(subscribers, updatechecker.Subscriber{Name: user.Name, Notifier: n, Series: series})
+	}
+	return subscribers, nil
+}
+
+// emailNotifier returns a notifier of the configured email service sending to recipients
+func (a *App) emailNotifier(recipients ...string) (updatechecker.Notifier, error) {
+	cfg := a.Config
+	notifierOptions := []notifier.NotifierOption{
+		notifier.WithRecipients(recipients...),
+		notifier.WithSenderEmail(cfg.Notifier.SenderEmail),
+		notifier.WithLogger(a.Logger),
+	}
+
+	channel := "stdout"
+	if cfg.Notifier.SendGrid.APIKey != "" {
+		channel = "sendgrid"
+		notifierOptions = append(notifierOptions, notifier.WithTemplateID(cfg.Notifier.SendGrid.TemplateID))
+		notifierOptions = append(notifierOptions, notifier.WithSendGridAPIKey(cfg.Notifier.SendGrid.APIKey))
+	} else if cfg.Notifier.SMTP2GO.APIKey != "" {
+		channel = "smtp2go"
+		notifierOptions = append(notifierOptions, notifier.WithTemplateID(cfg.Notifier.SMTP2GO.TemplateID))
+		notifierOptions = append(notifierOptions, notifier.WithSMTP2GOAPIKey(cfg.Notifier.SMTP2GO.APIKey))
+	}
+	return a.newNotifier(channel, notifierOptions...)
+}
+
+// newNotifier creates a notifier, counting its notifications under channel when metrics are enabled
+func (a *App) newNotifier(channel string, opts ...notifier.NotifierOption) (updatechecker.Notifier, error) {
+	n, err := notifier.NewNotifier(opts...)
+	if err != nil {
+		return nil, err
+	}
+	if a.metrics != nil {
+		return a.metrics.InstrumentNotifier(channel, n), nil
+	}
+	return n, nil
+}
+
+// FeedConfig returns the settings of the published feeds
+func (a *App) FeedConfig() feed.Config {
+	return feed.Config{
+		Title:      a.Config.Feed.Title,
+		BaseURL:    a.Config.Feed.BaseURL,
+		MaxEntries: a.Config.Feed.MaxEntries,
+	}
+}
+
+// Tracer returns the tracer provider of the update runs, exporting to the configured
+// OTLP endpoint or discarding the spans when none is configured.
+func (a *App) Tracer(ctx context.Context) (*tracing.Provider, error) {
+	if a.tracer != nil {
+		return a.tracer, nil
+	}
+	tp, err := tracing.New(ctx, tracing.Config{
+		Endpoint:    a.Config.Tracing.Endpoint,
+		Insecure:    a.Config.Tracing.Insecure,
+		Headers:     a.Config.Tracing.Headers,
+		ServiceName: a.Config.Tracing.ServiceName,
+		SampleRatio: a.Config.Tracing.SampleRatio,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to set up tracing: %w", err)
+	}
+	a.tracer = tp
+	return tp, nil
+}
+
+// UpdateChecker returns an update checker with the run observers enabled in the configuration
+func (a *App) UpdateChecker(ctx context.Context) (*updatechecker.UpdateCheckerService, error) {
+	cfg := a.Config
+	notif, err := a.Notifier()
+	if err != nil {
+		return nil, fmt.Errorf("failed to create notifier: %w", err)
+	}
+	router, err := a.ProviderRouter()
+	if err != nil {
+		return nil, fmt.Errorf("failed to create provider router: %w", err)
+	}
+	subscribers, err := a.Subscribers()
+	if err != nil {
+		return nil, fmt.Errorf("failed to create subscribers: %w", err)
+	}
+	tp, err := a.Tracer(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	store := a.store
+	checkerOptions := []updatechecker.Option{
+		updatechecker.WithProviderTimeout(cfg.ProviderTimeout),
+		updatechecker.WithTracerProvider(tp),
+		updatechecker.WithSubscribers(subscribers...),
+	}
+	if cfg.Feed.OutputDir != "" {
+		checkerOptions = append(checkerOptions, updatechecker.WithRunObserver(feed.NewFileWriter(store, cfg.Feed.OutputDir, a.FeedConfig())))
+	}
+
+	checkerOptions = append(checkerOptions, updatechecker.WithRunObserver(history.NewRecorder(cfg.SeriesHistoryDir())))
+	if a.metrics != nil {
+		checkerOptions = append(checkerOptions, updatechecker.WithRunObserver(a.metrics))
+	}
+
+	// registered last so that the history written by the recorder is committed with the series
+	if cfg.Git.Commit {
+		gitOptions := []gitstore.Option{gitstore.WithAuthor(cfg.Git.AuthorName, cfg.Git.AuthorEmail)}
+		if rel, err := filepath.Rel(cfg.SeriesDataFolder, cfg.SeriesHistoryDir()); err == nil && !strings.HasPrefix(rel, "..") {
+			gitOptions = append(gitOptions, gitstore.WithHistoryDir(cfg.SeriesHistoryDir()))
+		}
+		if cfg.Git.Push {
+			gitOptions = append(gitOptions, gitstore.WithPush(cfg.Git.Remote))
+		}
+		gitStore := gitstore.New(store, cfg.SeriesDataFolder, gitOptions...)
+		store = gitStore
+		checkerOptions = append(checkerOptions, updatechecker.WithRunObserver(gitStore))
+	}
+
+	return updatechecker.NewUpdateCheckerService(notif, store, router, a.Logger, checkerOptions...)
+}
+
+// RunUpdate checks every tracked series once, bounded by the configured run timeout
+func (a *App) RunUpdate(ctx context.Context) error {
+	if a.Config.RunTimeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, a.Config.RunTimeout)
+		defer cancel()
+	}
+
+	// the run observers still publish an empty run, e.g. the feeds and the time of the last successful run
+	if len(a.store.GetMangaSeries(ctx)) == 0 {
+		a.Logger.Info("No series to monitor")
+	}
+
+	checker, err := a.UpdateChecker(ctx)
+	if err != nil {
+		return err
+	}
+	return checker.CheckForUpdates(ctx)
+}
+
+// Close exports the spans still buffered, it is bounded by ctx
+func (a *App) Close(ctx context.Context) error {
+	if a.tracer == nil {
+		return nil
+	}
+	return a.tracer.Shutdown(ctx)
+}
@@ -0,0 +1,121 @@
+package app
+
+import (
+	"context"
+	"io"
+	"log/slog"
+	"path/filepath"
+	"testing"
+
+	"github.com/ivan-penchev/manga-updates/internal/config"
+	"github.com/ivan-penchev/manga-updates/internal/domain"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func newTestApp(t *testing.T, cfg *config.Config) *App {
+	t.Helper()
+	if cfg.SeriesDataFolder == "" {
+		cfg.SeriesDataFolder = t.TempDir()
+	}
+	a, err := New(cfg, WithLogger(slog.New(slog.NewTextHandler(io.Discard, nil))))
+	require.NoError(t, err)
+	return a
+}
+
+func TestNew_RejectsUnknownLayout(t *testing.T) {
+	_, err := New(&config.Config{SeriesDataFolder: t.TempDir(), SeriesDataLayout: "nested"})
+	assert.Error(t, err)
+}
+
+func TestProviderFactories_IncludesConfiguredProviders(t *testing.T) {
+	a := newTestApp(t, &config.Config{
+		ExternalProviders: []config.ExternalProviderConfig{{Command: "my-provider", Kind: "custom"}},
+		Scrapers:          []config.ScraperConfig{{Kind: "scans", URLPattern: `scans\.example\.com`}},
+	})
+
+	var kinds []domain.MangaSource
+	for _, factory := range a.ProviderFactories() {
+		kinds = append(kinds, factory.Kind)
+	}
+	assert.Equal(t, []domain.MangaSource{domain.MangaSourceMangaNel, domain.MangaSourceMangaDex, domain.MangaSourceFeed, "custom", "scans"}, kinds)
+}
+
+func TestProviderRouter_IsShared(t *testing.T) {
+	a := newTestApp(t, &config.Config{})
+
+	first, err := a.ProviderRouter()
+	require.NoError(t, err)
+	second, err := a.ProviderRouter()
+	require.NoError(t, err)
+	assert.Same(t, first, second)
+}
+
+func TestSearchProviders_SkipsUnavailableProviders(t *testing.T) {
+	a := newTestApp(t, &config.Config{
+		ExternalProviders: []config.ExternalProviderConfig{{Command: "missing-manga-provider", Kind: "custom"}},
+	})
+
+	providers, err := a.SearchProviders("feed", "custom", "feed", "unknown")
+	require.NoError(t, err)
+	require.Len(t, providers, 1)
+	assert.Equal(t, domain.MangaSourceFeed, providers[0].Kind())
+}
+
+func TestRunUpdate_WithoutSeries(t *testing.T) {
+	a := newTestApp(t, &config.Config{})
+
+	a.Config.Feed.OutputDir = t.TempDir()
+
+	require.NoError(t, a.RunUpdate(context.Background()))
+	assert.FileExists(t, filepath.Join(a.Config.Feed.OutputDir, "feed.atom"), "observers are notified of empty runs")
+	assert.NoError(t, a.Close(context.Background()))
+}
+
+func TestUpdateChecker_Wiring(t *testing.T) {
+	a := newTestApp(t, &config.Config{})
+
+	checker, err := a.UpdateChecker(context.Background())
+	require.NoError(t, err)
+	assert.NotNil(t, checker)
+	assert.NotNil(t, a.tracer, "update runs are traced, with a no-op provider by default")
+	assert.NoError(t, a.Close(context.Background()))
+}
+
+func TestSubscribers(t *testing.T) {
+	emailService := config.NotifierConfig{
+		SenderEmail: "manga@example.com",
+		SMTP2GO:     config.SMTP2GOConfig{APIKey: "key"},
+	}
+
+	a := newTestApp(t, &config.Config{
+		Notifier: emailService,
+		Users: []config.UserConfig{
+			{Name: "alice", Emails: []string{"alice@example.com"}},
+			{Name: "bob", Emails: []string{"bob@example.com"}, Series: []string{"mangadex:solo-leveling", "feed:https://example.com/series"}},
+		},
+	})
+	subscribers, err := a.Subscribers()
+	require.NoError(t, err)
+	require.Len(t, subscribers, 2)
+	assert.Equal(t, "bob", subscribers[1].Name)
+	assert.Equal(t, []domain.SourceRef{
+		{Source: domain.MangaSourceMangaDex, Slug: "solo-leveling"},
+		{Source: domain.MangaSourceFeed, Slug: "https://example.com/series"},
+	}, subscribers[1].Series)
+
+	_, err = a.Notifier()
+	assert.NoError(t, err, "without recipient_email the series nobody is subscribed to are logged")
+
+	for name, users := range map[string][]config.UserConfig{
+		"invalid email": {{Name: "alice", Emails: []string{"not-an-email"}}},
+		"no email":      {{Name: "alice"}},
+		"bare slug":     {{Name: "alice", Emails: []string{"alice@example.com"}, Series: []string{"solo-leveling"}}},
+		"duplicate":     {{Name: "alice", Emails: []string{"alice@example.com"}}, {Name: "alice", Emails: []string{"alice@example.com"}}},
+		"no name":       {{Emails: []string{"alice@example.com"}}},
+	} {
+		a := newTestApp(t, &config.Config{Notifier: emailService, Users: users})
+		_, err := a.Subscribers()
+		assert.Error(t, err, name)
+	}
+}
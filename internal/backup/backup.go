@@ -0,0 +1,254 @@
+// Package backup exports the tracking library of a store to a single versioned
+// archive and restores such archives into any store.
+package backup
+
+import (
+	"archive/tar"
+	"bufio"
+	"bytes"
+	"compress/gzip"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"sort"
+	"time"
+
+	"github.com/ivan-penchev/manga-updates/internal/domain"
+)
+
+// CurrentVersion of the archive format, archives of newer versions are rejected
+const CurrentVersion = 1
+
+// archiveEntryName is the name of the library document inside tar.gz archives
+const archiveEntryName = "library.json"
+
+// Format is the encoding of an archive
+type Format string
+
+const (
+	FormatJSON  Format = "json"
+	FormatTarGz Format = "tar.gz"
+)
+
+// ConflictPolicy decides what happens to an archived series already present in the store
+type ConflictPolicy string
+
+const (
+	// ConflictSkip keeps the series of the store untouched
+	ConflictSkip ConflictPolicy = "skip"
+	// ConflictOverwrite replaces the series of the store with the archived one
+	ConflictOverwrite ConflictPolicy = "overwrite"
+	// ConflictMerge merges the chapters of both and keeps the furthest read progress
+	ConflictMerge ConflictPolicy = "merge"
+)
+
+type Store interface {
+	GetMangaSeries(ctx context.Context) map[string]domain.MangaEntity
+	PersistMangaTitle(ctx context.Context, location string, mangaTitle domain.MangaEntity) error
+	AddManga(ctx context.Context, manga domain.MangaEntity) error
+}
+
+// Archive is the whole library: every series with its chapters, its settings
+// (notifications, linked sources) and its read progress.
+type Archive struct {
+	Version   int                  `json:"version"`
+	CreatedAt time.Time            `json:"createdAt"`
+	Series    []domain.MangaEntity `json:"series"`
+}
+
+// RestoreReport lists the titles of the restored series by outcome
+type RestoreReport struct {
+	Added       []string
+	Overwritten []string
+	Merged      []string
+	Skipped     []string
+}
+
+// Export collects every series of the store, ordered by source and slug.
+func Export(ctx context.Context, store Store) Archive {
+	archive := Archive{Version: CurrentVersion, CreatedAt: time.Now().UTC()}
+	for _, manga := range store.GetMangaSeries(ctx) {
+		archive.Series = append(archive.Series, manga)
+	}
+	sort.Slice(archive.Series, func(i, j int) bool {
+		a, b := archive.Series[i], archive.Series[j]
+		if a.Source != b.Source {
+			return a.Source < b.Source
+		}
+		return a.Slug < b.Slug
+	})
+	return archive
+}
+
+// Write encodes the archive in the given format
+func Write(w io.Writer, archive Archive, format Format) error {
+	document, err := json.MarshalIndent(archive, "", " ")
+	if err != nil {
+		return err
+	}
+
+	switch format {
+	case FormatJSON:
+		_, err := w.Write(document)
+		return err
+	case FormatTarGz:
+		gz := gzip.NewWriter(w)
+		tw := tar.NewWriter(gz)
+		header := &tar.Header{
+			Name:    archiveEntryName,
+			Mode:    0644,
+			Size:    int64(len(document)),
+			ModTime: archive.CreatedAt,
+		}
+		if err := tw.WriteHeader(header); err != nil {
+			return err
+		}
+		if _, err := tw.Write(document); err != nil {
+			return err
+		}
+		if err := tw.Close(); err != nil {
+			return err
+		}
+		return gz.Close()
+	default:
+		return fmt.Errorf("unsupported archive format %q", format)
+	}
+}
+
+// Read decodes and validates an archive, the format is detected from its content.
+func Read(r io.Reader) (Archive, error) {
+	br := bufio.NewReader(r)
+	magic, _ := br.Peek(2)
+
+	var document io.Reader = br
+	if bytes.Equal(magic, []byte{0x1f, 0x8b}) {
+		gz, err := gzip.NewReader(br)
+		if err != nil {
+			return Archive{}, fmt.Errorf("failed to open archive: %w", err)
+		}
+		defer gz.Close()
+
+		tr := tar.NewReader(gz)
+		for {
+			header, err := tr.Next()
+			if errors.Is(err, io.EOF) {
+				return Archive{}, fmt.Errorf("archive has no %s", archiveEntryName)
+			}
+			if err != nil {
+				return Archive{}, fmt.Errorf("failed to read archive: %w", err)
+			}
+			if header.Name == archiveEntryName {
+				document = tr
+				break
+			}
+		}
+	}
+
+	var archive Archive
+	if err := json.NewDecoder(document).Decode(&archive); err != nil {
+		return Archive{}, fmt.Errorf("failed to parse archive: %w", err)
+	}
+	return archive, archive.Validate()
+}
+
+// Validate checks the archive can be restored by this version
+func (a Archive) Validate() error {
+	if a.Version < 1 || a.Version > CurrentVersion {
+		return fmt.Errorf("unsupported archive version %d, expected at most %d", a.Version, CurrentVersion)
+	}
+	seen := make(map[domain.SourceRef]bool, len(a.Series))
+	for i, manga := range a.Series {
+		if manga.Slug == "" || manga.Source == "" {
+			return fmt.Errorf("series %d (%q) has no source or slug", i, manga.Name)
+		}
+		ref := domain.SourceRef{Source: manga.Source, Slug: manga.Slug}
+		if seen[ref] {
+			return fmt.Errorf("series %s/%s is archived twice", ref.Source, ref.Slug)
+		}
+		seen[ref] = true
+	}
+	return nil
+}
+
+// Restore adds the archived series to the store, series already tracked on the
+// same source and slug are handled according to the conflict policy.
+func Restore(ctx context.Context, store Store, archive Archive, policy ConflictPolicy) (RestoreReport, error) {
+	var report RestoreReport
+	if err := archive.Validate(); err != nil {
+		return report, err
+	}
+	switch policy {
+	case ConflictSkip, ConflictOverwrite, ConflictMerge:
+	default:
+		return report, fmt.Errorf("unsupported conflict policy %q", policy)
+	}
+
+	existing := make(map[domain.SourceRef]string)
+	series := store.GetMangaSeries(ctx)
+	for location, manga := range series {
+		existing[domain.SourceRef{Source: manga.Source, Slug: manga.Slug}] = location
+	}
+
+	for _, manga := range archive.Series {
+		if err := ctx.Err(); err != nil {
+			return report, err
+		}
+
+		location, conflict := existing[domain.SourceRef{Source: manga.Source, Slug: manga.Slug}]
+		if !conflict {
+			if err := store.AddManga(ctx, manga); err != nil {
+				return report, fmt.Errorf("failed to add %s: %w", manga.Name, err)
+			}
+			report.Added = append(report.Added, manga.Name)
+			continue
+		}
+
+		switch policy {
+		case ConflictSkip:
+			report.Skipped = append(report.Skipped, manga.Name)
+			continue
+		case ConflictOverwrite:
+			report.Overwritten = append(report.Overwritten, manga.Name)
+		case ConflictMerge:
+			manga = merge(series[location], manga)
+			report.Merged = append(report.Merged, manga.Name)
+		}
+		if err := store.PersistMangaTitle(ctx, location, manga); err != nil {
+			return report, fmt.Errorf("failed to restore %s: %w", manga.Name, err)
+		}
+	}
+	return report, nil
+}
+
+// merge combines the chapters, sources and subscribers of both versions of a series,
+// the current settings are kept and the furthest read progress wins.
+func merge(current, archived domain.MangaEntity) domain.MangaEntity {
+	merged := current
+	merged.Sources = append([]domain.SourceRef(nil), current.Sources...)
+	merged.Subscribers = append([]string(nil), current.Subscribers...)
+	merged.Subscribe(archived.Subscribers...)
+	merged.Chapters = domain.MergeChapters(current.Chapters, archived.Chapters)
+	if archived.LastUpdate.After(merged.LastUpdate) {
+		merged.LastUpdate = archived.LastUpdate
+	}
+	for _, ref := range archived.Sources {
+		if !containsSource(merged.AllSources(), ref) {
+			merged.Sources = append(merged.Sources, ref)
+		}
+	}
+	if archived.Read != nil && (merged.Read == nil || archived.Read.Chapter > merged.Read.Chapter) {
+		merged.Read = archived.Read
+	}
+	return merged
+}
+
+func containsSource(refs []domain.SourceRef, ref domain.SourceRef) bool {
+	for _, r := range refs {
+		if r == ref {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,129 @@
+package backup
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/ivan-penchev/manga-updates/internal/domain"
+	"github.com/ivan-penchev/manga-updates/internal/store"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// chapters of a version of a series, released on date and linked as <version>/<number>
+func chapters(version string, date time.Time, numbers ...float64) []domain.ChapterEntity {
+	result := make([]domain.ChapterEntity, 0, len(numbers))
+	for _, n := range numbers {
+		result = append(result, domain.ChapterEntity{Number: &n, Date: &date, URI: fmt.Sprintf("%s/%g", version, n)})
+	}
+	return result
+}
+
+func TestWriteRead_RoundTrip(t *testing.T) {
+	day := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	ctx := context.Background()
+	s := store.NewMemoryStore()
+	require.NoError(t, s.AddManga(ctx, domain.MangaEntity{
+		Name: "Solo Leveling", Slug: "solo-leveling", Source: domain.MangaSourceMangaNel, ShouldNotify: true,
+		Chapters: chapters("nel", day, 1),
+		Read:     &domain.ReadMarker{Chapter: 1, ReadAt: day},
+	}))
+	require.NoError(t, s.AddManga(ctx, domain.MangaEntity{Name: "Berserk", Slug: "berserk", Source: domain.MangaSourceMangaDex}))
+
+	archive := Export(ctx, s)
+	assert.Equal(t, CurrentVersion, archive.Version)
+	require.Len(t, archive.Series, 2)
+	assert.Equal(t, "Berserk", archive.Series[0].Name, "series are ordered by source and slug")
+
+	for _, format := range []Format{FormatJSON, FormatTarGz} {
+		t.Run(string(format), func(t *testing.T) {
+			var buf bytes.Buffer
+			require.NoError(t, Write(&buf, archive, format))
+
+			read, err := Read(&buf)
+			require.NoError(t, err)
+			assert.Equal(t, archive.Series, read.Series)
+			assert.True(t, archive.CreatedAt.Equal(read.CreatedAt))
+		})
+	}
+}
+
+func TestRead_RejectsInvalidArchives(t *testing.T) {
+	_, err := Read(bytes.NewBufferString(`{"version": 2, "series": []}`))
+	assert.ErrorContains(t, err, "unsupported archive version")
+
+	_, err = Read(bytes.NewBufferString(`{"version": 1, "series": [{"name": "Berserk"}]}`))
+	assert.ErrorContains(t, err, "no source or slug")
+
+	_, err = Read(bytes.NewBufferString(`{"version": 1, "series": [
+		{"name": "Berserk", "slug": "berserk", "source": "mangadex"},
+		{"name": "Berserk", "slug": "berserk", "source": "mangadex"}]}`))
+	assert.ErrorContains(t, err, "archived twice")
+
+	_, err = Read(bytes.NewBufferString(`not json`))
+	assert.Error(t, err)
+}
+
+func TestRestore_ConflictPolicies(t *testing.T) {
+	day := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	current := domain.MangaEntity{
+		Name: "Solo Leveling", Slug: "solo-leveling", Source: domain.MangaSourceMangaNel, ShouldNotify: true, LastUpdate: day,
+		Chapters:    chapters("current", day, 2, 1),
+		Read:        &domain.ReadMarker{Chapter: 1, ReadAt: day},
+		Subscribers: []string{"alice"},
+	}
+	archived := current
+	archived.Subscribers = []string{"bob", "alice"}
+	archived.ShouldNotify = false
+	archived.LastUpdate = day.Add(24 * time.Hour)
+	archived.Chapters = chapters("archived", day.Add(24*time.Hour), 3, 2)
+	archived.Read = &domain.ReadMarker{Chapter: 2, ReadAt: day}
+	added := domain.MangaEntity{Name: "Berserk", Slug: "berserk", Source: domain.MangaSourceMangaDex}
+	archive := Archive{Version: CurrentVersion, Series: []domain.MangaEntity{archived, added}}
+
+	restore := func(t *testing.T, policy ConflictPolicy) (domain.MangaEntity, RestoreReport) {
+		ctx := context.Background()
+		s := store.NewMemoryStore()
+		require.NoError(t, s.PersistMangaTitle(ctx, "solo.json", current))
+
+		report, err := Restore(ctx, s, archive, policy)
+		require.NoError(t, err)
+		series := s.GetMangaSeries(ctx)
+		assert.Len(t, series, 2)
+		assert.Equal(t, []string{"Berserk"}, report.Added)
+		return series["solo.json"], report
+	}
+
+	t.Run("skip", func(t *testing.T) {
+		restored, report := restore(t, ConflictSkip)
+		assert.Equal(t, current, restored)
+		assert.Equal(t, []string{"Solo Leveling"}, report.Skipped)
+	})
+
+	t.Run("overwrite", func(t *testing.T) {
+		restored, report := restore(t, ConflictOverwrite)
+		assert.Equal(t, archived, restored)
+		assert.Equal(t, []string{"Solo Leveling"}, report.Overwritten)
+	})
+
+	t.Run("merge", func(t *testing.T) {
+		restored, report := restore(t, ConflictMerge)
+		assert.Equal(t, []string{"Solo Leveling"}, report.Merged)
+		assert.True(t, restored.ShouldNotify, "the settings of the store are kept")
+		assert.Equal(t, archived.LastUpdate, restored.LastUpdate)
+		assert.Equal(t, 2.0, restored.Read.Chapter, "the furthest read progress wins")
+		assert.Equal(t, []string{"alice", "bob"}, restored.Subscribers, "the subscribers of both versions are kept")
+
+		var uris []string
+		for _, c := range restored.Chapters {
+			uris = append(uris, c.URI)
+		}
+		assert.Equal(t, []string{"archived/3", "current/2", "current/1"}, uris)
+	})
+
+	_, err := Restore(context.Background(), store.NewMemoryStore(), archive, "replace")
+	assert.Error(t, err)
+}
@@ -0,0 +1,134 @@
+// Package cache provides an in-memory cache whose entries expire after a TTL,
+// bounded in size by evicting the least recently used entries.
+package cache
+
+import (
+	"container/list"
+	"sync"
+	"time"
+)
+
+type entry[K comparable, V any] struct {
+	key       K
+	value     V
+	expiresAt time.Time
+}
+
+// Cache is safe for concurrent use.
+type Cache[K comparable, V any] struct {
+	mutex      sync.Mutex
+	ttl        time.Duration
+	maxEntries int
+	entries    map[K]*list.Element
+	// recency holds the entries from the most to the least recently used
+	recency *list.List
+	now     func() time.Time
+	// copy is applied to the values stored and returned, nil shares them with the callers
+	copy func(V) V
+}
+
+type Option[K comparable, V any] func(*Cache[K, V])
+
+// WithCopy copies the values on Set and Get with copy, so that callers modifying
+// a value in place, e.g. the slices of a struct, never corrupt the cached one.
+func WithCopy[K comparable, V any](copy func(V) V) Option[K, V] {
+	return func(c *Cache[K, V]) {
+		c.copy = copy
+	}
+}
+
+// New returns a cache keeping entries for ttl, and at most maxEntries of them.
+// A maxEntries of zero or less does not bound the size.
+func New[K comparable, V any](ttl time.Duration, maxEntries int, opts ...Option[K, V]) *Cache[K, V] {
+	c := &Cache[K, V]{
+		ttl:        ttl,
+		maxEntries: maxEntries,
+		entries:    make(map[K]*list.Element),
+		recency:    list.New(),
+		now:        time.Now,
+	}
+	for _, opt := range opts {
+		opt(c)
+	}
+	return c
+}
+
+// Get returns the value cached for key, expired entries are never returned.
+func (c *Cache[K, V]) Get(key K) (V, bool) {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	var zero V
+	element, ok := c.entries[key]
+	if !ok {
+		return zero, false
+	}
+	e := element.Value.(*entry[K, V])
+	if !c.now().Before(e.expiresAt) {
+		c.remove(element)
+		return zero, false
+	}
+	c.recency.MoveToFront(element)
+	return c.copyOf(e.value), true
+}
+
+// Set caches value for key, evicting the least recently used entry when the cache is full.
+func (c *Cache[K, V]) Set(key K, value V) {
+	value = c.copyOf(value)
+
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	expiresAt := c.now().Add(c.ttl)
+	if element, ok := c.entries[key]; ok {
+		e := element.Value.(*entry[K, V])
+		e.value = value
+		e.expiresAt = expiresAt
+		c.recency.MoveToFront(element)
+		return
+	}
+
+	c.entries[key] = c.recency.PushFront(&entry[K, V]{key: key, value: value, expiresAt: expiresAt})
+	if c.maxEntries > 0 && c.recency.Len() > c.maxEntries {
+		c.remove(c.recency.Back())
+	}
+}
+
+// Invalidate removes the entry of key, if any.
+func (c *Cache[K, V]) Invalidate(key K) {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	if element, ok := c.entries[key]; ok {
+		c.remove(element)
+	}
+}
+
+// InvalidateAll removes every entry.
+func (c *Cache[K, V]) InvalidateAll() {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	c.entries = make(map[K]*list.Element)
+	c.recency.Init()
+}
+
+// Len returns the number of entries, including expired ones not evicted yet.
+func (c *Cache[K, V]) Len() int {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	return c.recency.Len()
+}
+
+func (c *Cache[K, V]) copyOf(value V) V {
+	if c.copy == nil {
+		return value
+	}
+	return c.copy(value)
+}
+
+func (c *Cache[K, V]) remove(element *list.Element) {
+	c.recency.Remove(element)
+	delete(c.entries, element.Value.(*entry[K, V]).key)
+}
@@ -0,0 +1,109 @@
+package cache
+
+import (
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func newTestCache(ttl time.Duration, maxEntries int) (*Cache[string, int], *time.Time) {
+	now := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	c := New[string, int](ttl, maxEntries)
+	c.now = func() time.Time { return now }
+	return c, &now
+}
+
+func TestCache_Expires(t *testing.T) {
+	c, now := newTestCache(time.Minute, 0)
+	c.Set("a", 1)
+
+	value, ok := c.Get("a")
+	assert.True(t, ok)
+	assert.Equal(t, 1, value)
+
+	*now = now.Add(time.Minute)
+	_, ok = c.Get("a")
+	assert.False(t, ok, "entries expire after the ttl")
+	assert.Equal(t, 0, c.Len(), "expired entries are evicted when read")
+}
+
+func TestCache_SetRefreshesExpiry(t *testing.T) {
+	c, now := newTestCache(time.Minute, 0)
+	c.Set("a", 1)
+	*now = now.Add(45 * time.Second)
+	c.Set("a", 2)
+	*now = now.Add(45 * time.Second)
+
+	value, ok := c.Get("a")
+	assert.True(t, ok)
+	assert.Equal(t, 2, value)
+}
+
+func TestCache_EvictsLeastRecentlyUsed(t *testing.T) {
+	c, _ := newTestCache(time.Minute, 2)
+	c.Set("a", 1)
+	c.Set("b", 2)
+	c.Get("a")
+	c.Set("c", 3)
+
+	_, ok := c.Get("b")
+	assert.False(t, ok, "b was the least recently used entry")
+	_, ok = c.Get("a")
+	assert.True(t, ok)
+	_, ok = c.Get("c")
+	assert.True(t, ok)
+	assert.Equal(t, 2, c.Len())
+}
+
+func TestCache_Invalidate(t *testing.T) {
+	c, _ := newTestCache(time.Minute, 0)
+	c.Set("a", 1)
+	c.Set("b", 2)
+
+	c.Invalidate("a")
+	_, ok := c.Get("a")
+	assert.False(t, ok)
+	_, ok = c.Get("b")
+	assert.True(t, ok)
+
+	c.InvalidateAll()
+	assert.Equal(t, 0, c.Len())
+}
+
+func TestCache_ConcurrentUse(t *testing.T) {
+	c := New[int, int](time.Minute, 10)
+
+	var wg sync.WaitGroup
+	for i := 0; i < 8; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for j := 0; j < 100; j++ {
+				c.Set(j, j)
+				c.Get(j - 1)
+				c.Invalidate(j - 2)
+			}
+		}()
+	}
+	wg.Wait()
+	assert.LessOrEqual(t, c.Len(), 10)
+}
+
+func TestCache_WithCopy(t *testing.T) {
+	c := New(time.Minute, 0, WithCopy[string](func(v []int) []int {
+		return append([]int(nil), v...)
+	}))
+
+	value := []int{1, 2}
+	c.Set("a", value)
+	value[0] = 10
+
+	cached, _ := c.Get("a")
+	assert.Equal(t, []int{1, 2}, cached, "the value set is copied")
+	cached[1] = 20
+
+	cached, _ = c.Get("a")
+	assert.Equal(t, []int{1, 2}, cached, "the value returned is a copy")
+}
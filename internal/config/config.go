@@ -2,16 +2,104 @@ package config
 
 import (
 	"os"
+	"path/filepath"
+	"time"
 
 	"github.com/caarlos0/env/v10"
 	"gopkg.in/yaml.v3"
 )
 
 type Config struct {
-	MangaNelGraphQLEndpoint string         `env:"API_ENDPOINT" yaml:"api_endpoint"`
-	RemoteChromeURL         string         `env:"REMOTE_CHROME_URL" yaml:"remote_chrome_url"`
-	SeriesDataFolder        string         `env:"SERIES_DATAFOLDER" yaml:"series_data_folder"`
-	Notifier                NotifierConfig `yaml:"notifier"`
+	MangaNelGraphQLEndpoint string                   `env:"API_ENDPOINT" yaml:"api_endpoint"`
+	RemoteChromeURL         string                   `env:"REMOTE_CHROME_URL" yaml:"remote_chrome_url"`
+	MangaNelTokenCacheFile  string                   `env:"MANGANEL_TOKEN_CACHE_FILE" yaml:"manganel_token_cache_file"`
+	HTTPCacheDir            string                   `env:"HTTP_CACHE_DIR" yaml:"http_cache_dir"`
+	SeriesDataFolder        string                   `env:"SERIES_DATAFOLDER" yaml:"series_data_folder"`
+	SeriesDataLayout        string                   `env:"SERIES_DATA_LAYOUT" yaml:"series_data_layout"`
+	HistoryDir              string                   `env:"HISTORY_DIR" yaml:"history_dir"`
+	Notifier                NotifierConfig           `yaml:"notifier"`
+	Users                   []UserConfig             `yaml:"users"`
+	Feed                    FeedConfig               `yaml:"feed"`
+	FeedProvider            FeedProviderConfig       `yaml:"feed_provider"`
+	ExternalProviders       []ExternalProviderConfig `yaml:"external_providers"`
+	Scrapers                []ScraperConfig          `yaml:"scrapers"`
+	RateLimits              RateLimitConfig          `yaml:"rate_limits"`
+	Git                     GitConfig                `yaml:"git"`
+	Tracing                 TracingConfig            `yaml:"tracing"`
+
+	// RunTimeout is the deadline of a whole update run, no deadline when zero
+	RunTimeout time.Duration `env:"RUN_TIMEOUT" yaml:"run_timeout"`
+	// ProviderTimeout bounds every provider call of an update run, no bound when zero
+	ProviderTimeout time.Duration `env:"PROVIDER_TIMEOUT" yaml:"provider_timeout"`
+}
+
+// GitConfig commits the data folder after every update run, it has to be inside a git work tree
+type GitConfig struct {
+	Commit      bool   `env:"GIT_COMMIT_CHANGES" yaml:"commit"`
+	Push        bool   `env:"GIT_PUSH_CHANGES" yaml:"push"`
+	Remote      string `env:"GIT_PUSH_REMOTE" yaml:"remote"`
+	AuthorName  string `env:"GIT_COMMIT_AUTHOR_NAME" yaml:"author_name"`
+	AuthorEmail string `env:"GIT_COMMIT_AUTHOR_EMAIL" yaml:"author_email"`
+}
+
+// TracingConfig exports OpenTelemetry traces of the update runs, tracing is disabled without an endpoint
+type TracingConfig struct {
+	Endpoint    string            `env:"OTEL_EXPORTER_OTLP_ENDPOINT" yaml:"endpoint"`
+	Insecure    bool              `env:"OTEL_EXPORTER_OTLP_INSECURE" yaml:"insecure"`
+	Headers     map[string]string `env:"OTEL_EXPORTER_OTLP_HEADERS" envSeparator:"," envKeyValSeparator:"=" yaml:"headers"`
+	ServiceName string            `env:"OTEL_SERVICE_NAME" yaml:"service_name"`
+	SampleRatio float64           `env:"TRACING_SAMPLE_RATIO" yaml:"sample_ratio"`
+}
+
+// RateLimitConfig is the number of requests per second sent to each provider, zero disables the limit
+type RateLimitConfig struct {
+	MangaDex float64 `env:"MANGADEX_REQUESTS_PER_SECOND" yaml:"mangadex"`
+	MangaNel float64 `env:"MANGANEL_REQUESTS_PER_SECOND" yaml:"manganel"`
+}
+
+// ScraperConfig declares a provider scraping the HTML of a site with CSS selectors,
+// scrapers can only be configured through the config file.
+type ScraperConfig struct {
+	Kind                 string                 `yaml:"kind"`
+	URLPattern           string                 `yaml:"url_pattern"`
+	Selectors            ScraperSelectorsConfig `yaml:"selectors"`
+	ChapterNumberPattern string                 `yaml:"chapter_number_pattern"`
+	DateFormat           string                 `yaml:"date_format"`
+	DateAttribute        string                 `yaml:"date_attribute"`
+}
+
+type ScraperSelectorsConfig struct {
+	Title         string `yaml:"title"`
+	Status        string `yaml:"status"`
+	ChapterList   string `yaml:"chapter_list"`
+	ChapterNumber string `yaml:"chapter_number"`
+	ChapterLink   string `yaml:"chapter_link"`
+	ChapterDate   string `yaml:"chapter_date"`
+}
+
+// ExternalProviderConfig registers an executable implementing the external provider protocol,
+// external providers can only be configured through the config file.
+type ExternalProviderConfig struct {
+	Command     string        `yaml:"command"`
+	Args        []string      `yaml:"args"`
+	Kind        string        `yaml:"kind"`
+	URLPatterns []string      `yaml:"url_patterns"`
+	Timeout     time.Duration `yaml:"timeout"`
+}
+
+type FeedProviderConfig struct {
+	// Regular expressions, a url matching any of them is tracked through its RSS/Atom feed
+	URLPatterns []string `env:"FEED_PROVIDER_URL_PATTERNS" envSeparator:";" yaml:"url_patterns"`
+	// Regular expressions with one capture group extracting the chapter number from an item title
+	ChapterPatterns []string `env:"FEED_PROVIDER_CHAPTER_PATTERNS" envSeparator:";" yaml:"chapter_patterns"`
+}
+
+type FeedConfig struct {
+	// OutputDir enables writing Atom/RSS feeds after each update run when set
+	OutputDir  string `env:"FEED_OUTPUT_DIR" yaml:"output_dir"`
+	Title      string `env:"FEED_TITLE" yaml:"title"`
+	BaseURL    string `env:"FEED_BASE_URL" yaml:"base_url"`
+	MaxEntries int    `env:"FEED_MAX_ENTRIES" yaml:"max_entries"`
 }
 
 type NotifierConfig struct {
@@ -21,6 +109,27 @@ type NotifierConfig struct {
 	SMTP2GO        SMTP2GOConfig  `yaml:"smtp2go"`
 }
 
+// UserConfig declares a user notified about the series they are subscribed to, through the
+// configured email service. Users can only be configured through the config file.
+type UserConfig struct {
+	Name string `yaml:"name"`
+	// Emails receive the notifications of the user
+	Emails []string `yaml:"emails"`
+	// Series lists the series the user is subscribed to as "<source>:<slug>", on top
+	// of the series added with `manga-cli add --user`
+	Series []string `yaml:"series"`
+}
+
+// User returns the user with the given name, ok is false when no such user is configured
+func (c *Config) User(name string) (user UserConfig, ok bool) {
+	for _, u := range c.Users {
+		if u.Name == name {
+			return u, true
+		}
+	}
+	return UserConfig{}, false
+}
+
 type SendGridConfig struct {
 	APIKey     string `env:"SENDGRID_API_KEY" yaml:"api_key"`
 	TemplateID string `env:"SENDGRID_TEMPLATE_ID" yaml:"template_id"`
@@ -31,10 +140,38 @@ type SMTP2GOConfig struct {
 	TemplateID string `env:"SMTP2GO_TEMPLATE_ID" yaml:"template_id"`
 }
 
+// HTTPCacheDirFor returns the HTTP cache directory of a provider, empty when caching is disabled
+func (c *Config) HTTPCacheDirFor(provider string) string {
+	if c.HTTPCacheDir == "" {
+		return ""
+	}
+	return filepath.Join(c.HTTPCacheDir, filepath.Base(provider))
+}
+
+// SeriesHistoryDir returns the directory of the series histories, .history in the
+// data folder by default so that it is versioned along with the series.
+func (c *Config) SeriesHistoryDir() string {
+	if c.HistoryDir != "" {
+		return c.HistoryDir
+	}
+	return filepath.Join(c.SeriesDataFolder, ".history")
+}
+
 func Load(configFile string) (*Config, error) {
 	cfg := Config{
 		MangaNelGraphQLEndpoint: "https://api.mghcdn.com/graphql",
 		SeriesDataFolder:        os.ExpandEnv("$HOME/repos/manga-updates/data"),
+		ProviderTimeout:         2 * time.Minute,
+		// MangaDex allows about 5 requests per second per client, manganel publishes nothing and blocks aggressive clients
+		RateLimits: RateLimitConfig{
+			MangaDex: 4,
+			MangaNel: 1,
+		},
+	}
+
+	if cacheDir, err := os.UserCacheDir(); err == nil {
+		cfg.MangaNelTokenCacheFile = filepath.Join(cacheDir, "manga-updates", "manganel-token.json")
+		cfg.HTTPCacheDir = filepath.Join(cacheDir, "manga-updates", "http")
 	}
 
 	// If configFile argument is empty, try to load from ENV
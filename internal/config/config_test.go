@@ -3,6 +3,7 @@ package config
 import (
 	"os"
 	"testing"
+	"time"
 
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
@@ -68,3 +69,80 @@ remote_chrome_url: "ws://from-file:3000"
 	// Verify Env overrides File
 	assert.Equal(t, "ws://from-file:3000", cfg.RemoteChromeURL, "ENV should not override File")
 }
+
+func TestLoad_ExternalProvidersFromFile(t *testing.T) {
+	configFileContent := `
+external_providers:
+  - command: /usr/local/bin/my-site-provider
+    args: ["--verbose"]
+    kind: my-site
+    url_patterns: ['^https://my-site\.example\.com/']
+    timeout: 45s
+`
+	tmpFile, err := os.CreateTemp("", "config_*.yaml")
+	require.NoError(t, err)
+	t.Cleanup(func() {
+		_ = os.Remove(tmpFile.Name())
+	})
+
+	_, err = tmpFile.WriteString(configFileContent)
+	require.NoError(t, err)
+	require.NoError(t, tmpFile.Close())
+
+	cfg, err := Load(tmpFile.Name())
+	require.NoError(t, err)
+
+	require.Len(t, cfg.ExternalProviders, 1)
+	assert.Equal(t, "/usr/local/bin/my-site-provider", cfg.ExternalProviders[0].Command)
+	assert.Equal(t, []string{"--verbose"}, cfg.ExternalProviders[0].Args)
+	assert.Equal(t, "my-site", cfg.ExternalProviders[0].Kind)
+	assert.Equal(t, 45*time.Second, cfg.ExternalProviders[0].Timeout)
+}
+
+func TestLoad_TracingFromEnv(t *testing.T) {
+	t.Setenv("CONFIG_FILE", "")
+	t.Setenv("OTEL_EXPORTER_OTLP_ENDPOINT", "http://localhost:4318")
+	t.Setenv("OTEL_EXPORTER_OTLP_HEADERS", "x-api-key=secret,x-team=manga")
+	t.Setenv("TRACING_SAMPLE_RATIO", "0.5")
+
+	cfg, err := Load("")
+	require.NoError(t, err)
+
+	assert.Equal(t, "http://localhost:4318", cfg.Tracing.Endpoint)
+	assert.Equal(t, map[string]string{"x-api-key": "secret", "x-team": "manga"}, cfg.Tracing.Headers)
+	assert.Equal(t, 0.5, cfg.Tracing.SampleRatio)
+}
+
+func TestLoad_UsersFromFile(t *testing.T) {
+	configFileContent := `
+users:
+  - name: alice
+    emails: ["alice@example.com"]
+    series: ["solo-leveling"]
+  - name: bob
+    emails: ["bob@example.com", "bob@work.example.com"]
+`
+	tmpFile, err := os.CreateTemp("", "config_*.yaml")
+	require.NoError(t, err)
+	t.Cleanup(func() {
+		_ = os.Remove(tmpFile.Name())
+	})
+
+	_, err = tmpFile.WriteString(configFileContent)
+	require.NoError(t, err)
+	require.NoError(t, tmpFile.Close())
+
+	cfg, err := Load(tmpFile.Name())
+	require.NoError(t, err)
+
+	require.Len(t, cfg.Users, 2)
+	alice, ok := cfg.User("alice")
+	require.True(t, ok)
+	assert.Equal(t, []string{"alice@example.com"}, alice.Emails)
+	assert.Equal(t, []string{"solo-leveling"}, alice.Series)
+	bob, ok := cfg.User("bob")
+	require.True(t, ok)
+	assert.Len(t, bob.Emails, 2)
+	_, ok = cfg.User("carol")
+	assert.False(t, ok)
+}
@@ -2,6 +2,10 @@ package domain
 
 import (
 	"context"
+	"encoding/json"
+	"fmt"
+	"slices"
+	"sort"
 	"time"
 )
 
@@ -11,6 +15,7 @@ type MangaStatus string
 const (
 	MangaSourceMangaNel MangaSource = "manganel"
 	MangaSourceMangaDex MangaSource = "mangadex"
+	MangaSourceFeed     MangaSource = "feed"
 
 	MangaStatusOngoing  MangaStatus = "ongoing"
 	MangaStatusComplete MangaStatus = "complete"
@@ -24,15 +29,52 @@ type MangaEntity struct {
 	Status       MangaStatus     `json:"status"`
 	Source       MangaSource     `json:"source"`
 	Chapters     []ChapterEntity `json:"chapters"`
+	// Sources lists other sources publishing the same series, checked after
+	// Source in the order they are listed.
+	Sources []SourceRef `json:"sources,omitempty"`
+	// Read is the reading progress, nil until a chapter is marked as read
+	Read *ReadMarker `json:"read,omitempty"`
+	// Subscribers lists the users notified about the new chapters of the series
+	Subscribers []string `json:"subscribers,omitempty"`
+}
+
+// ReadMarker records the last chapter read of a series
+type ReadMarker struct {
+	Chapter float64   `json:"chapter"`
+	ReadAt  time.Time `json:"readAt"`
+}
+
+// SourceRef identifies a series on a source
+type SourceRef struct {
+	Source MangaSource `json:"source"`
+	Slug   string      `json:"slug"`
 }
 
 type ChapterEntity struct {
-	Number *float64   `json:"name"`
+	Number *float64   `json:"number"`
 	Slug   *string    `json:"slug"`
 	Date   *time.Time `json:"date"`
 	URI    string     `json:"uri"`
 }
 
+// UnmarshalJSON also accepts the chapter number under the legacy "name" key,
+// still written by external providers and archives predating schema version 1.
+func (c *ChapterEntity) UnmarshalJSON(data []byte) error {
+	type chapter ChapterEntity
+	var decoded struct {
+		chapter
+		LegacyNumber *float64 `json:"name"`
+	}
+	if err := json.Unmarshal(data, &decoded); err != nil {
+		return err
+	}
+	*c = ChapterEntity(decoded.chapter)
+	if c.Number == nil {
+		c.Number = decoded.LegacyNumber
+	}
+	return nil
+}
+
 // examine if default values are present on a MangaEntity,
 // if they are, it means the entity has never been synced before.
 // and should be considered new for the purpose of syncing
@@ -70,6 +112,225 @@ type SearchResult struct {
 type ProviderRouter interface {
 	GetProvider(manga MangaEntity) (Provider, error)
 	GetProviderForURL(url string) (Provider, error)
+	// Providers lists the registered providers, providers registered
+	// without a kind are initialized to find out their kind.
+	Providers() []ProviderInfo
+}
+
+// ProviderInfo describes a provider registered with a ProviderRouter
+type ProviderInfo struct {
+	Kind        MangaSource
+	URLPatterns []string
+	// InitError is set when the provider failed to initialize while resolving its kind
+	InitError error
+}
+
+// HealthChecker is optionally implemented by providers able to diagnose their source
+type HealthChecker interface {
+	HealthCheck(ctx context.Context) HealthReport
+}
+
+// HealthReport is the result of a provider health check
+type HealthReport struct {
+	// Reachable is true when the source endpoint answered
+	Reachable bool `json:"reachable"`
+	// AuthValid is nil for sources that need no authentication
+	AuthValid *bool `json:"authValid,omitempty"`
+	// SchemaValid is true when the response had the expected structure
+	SchemaValid bool `json:"schemaValid"`
+	// Latency is encoded in milliseconds as latencyMs
+	Latency time.Duration `json:"-"`
+	Error   string        `json:"error,omitempty"`
+}
+
+// MarshalJSON encodes the latency as a number of milliseconds rather than nanoseconds
+func (r HealthReport) MarshalJSON() ([]byte, error) {
+	type report HealthReport
+	return json.Marshal(struct {
+		report
+		LatencyMs int64 `json:"latencyMs"`
+	}{report: report(r), LatencyMs: r.Latency.Milliseconds()})
+}
+
+// Healthy reports whether every check of the report passed
+func (r HealthReport) Healthy() bool {
+	return r.Reachable && r.SchemaValid && (r.AuthValid == nil || *r.AuthValid) && r.Error == ""
+}
+
+// ProviderUnavailableError is returned by a ProviderRouter for a registered
+// provider that failed to initialize, the other providers keep working.
+type ProviderUnavailableError struct {
+	Kind  MangaSource
+	Cause error
+}
+
+func (e *ProviderUnavailableError) Error() string {
+	return fmt.Sprintf("provider %s is unavailable: %v", e.Kind, e.Cause)
+}
+
+func (e *ProviderUnavailableError) Unwrap() error {
+	return e.Cause
+}
+
+// UnreadCount returns the number of chapters newer than the read marker,
+// ok is false when no chapter was marked as read.
+func (m *MangaEntity) UnreadCount() (count int, ok bool) {
+	if m.Read == nil {
+		return 0, false
+	}
+	seen := make(map[float64]bool)
+	for _, c := range m.Chapters {
+		if c.Number != nil && *c.Number > m.Read.Chapter && !seen[*c.Number] {
+			seen[*c.Number] = true
+			count++
+		}
+	}
+	return count, true
+}
+
+// LatestChapterNumber returns the highest chapter number of the series,
+// ok is false when no chapter has a number.
+func (m *MangaEntity) LatestChapterNumber() (latest float64, ok bool) {
+	for _, c := range m.Chapters {
+		if c.Number != nil && (!ok || *c.Number > latest) {
+			latest, ok = *c.Number, true
+		}
+	}
+	return latest, ok
+}
+
+// KeepUserFields copies the fields owned by the user from the persisted version of
+// a series, providers only know about what the source publishes.
+func (m *MangaEntity) KeepUserFields(persisted MangaEntity) {
+	m.ShouldNotify = persisted.ShouldNotify
+	m.Sources = persisted.Sources
+	m.Read = persisted.Read
+	m.Subscribers = persisted.Subscribers
+}
+
+// Clone returns a deep copy of the series, sharing no slice or pointer with m
+func (m *MangaEntity) Clone() MangaEntity {
+	clone := *m
+	if m.Chapters != nil {
+		clone.Chapters = make([]ChapterEntity, len(m.Chapters))
+		for i, c := range m.Chapters {
+			clone.Chapters[i] = c.Clone()
+		}
+	}
+	clone.Sources = slices.Clone(m.Sources)
+	clone.Subscribers = slices.Clone(m.Subscribers)
+	if m.Read != nil {
+		read := *m.Read
+		clone.Read = &read
+	}
+	return clone
+}
+
+// Clone returns a copy of the chapter with its own number, slug and date
+func (c ChapterEntity) Clone() ChapterEntity {
+	if c.Number != nil {
+		number := *c.Number
+		c.Number = &number
+	}
+	if c.Slug != nil {
+		slug := *c.Slug
+		c.Slug = &slug
+	}
+	if c.Date != nil {
+		date := *c.Date
+		c.Date = &date
+	}
+	return c
+}
+
+// IsSubscribed reports whether user is subscribed to the series
+func (m *MangaEntity) IsSubscribed(user string) bool {
+	return slices.Contains(m.Subscribers, user)
+}
+
+// Subscribe adds the users not yet subscribed to the series, it reports whether any was added
+func (m *MangaEntity) Subscribe(users ...string) bool {
+	added := false
+	for _, user := range users {
+		if user != "" && !m.IsSubscribed(user) {
+			m.Subscribers = append(m.Subscribers, user)
+			added = true
+		}
+	}
+	return added
+}
+
+// AllSources returns the primary source of the series followed by its linked sources
+func (m *MangaEntity) AllSources() []SourceRef {
+	refs := []SourceRef{{Source: m.Source, Slug: m.Slug}}
+	for _, ref := range m.Sources {
+		if ref != refs[0] {
+			refs = append(refs, ref)
+		}
+	}
+	return refs
+}
+
+// OnSource returns the series as seen by the provider of another source
+func (m *MangaEntity) OnSource(ref SourceRef) MangaEntity {
+	other := *m
+	other.Source = ref.Source
+	other.Slug = ref.Slug
+	other.Sources = nil
+	return other
+}
+
+// returns the chapters of n whose number is missing from the current manga
+func (m *MangaEntity) GetMissingChaptersByNumber(n MangaEntity) []ChapterEntity {
+	known := make(map[float64]bool, len(m.Chapters))
+	for _, c := range m.Chapters {
+		if c.Number != nil {
+			known[*c.Number] = true
+		}
+	}
+	var missing []ChapterEntity
+	for _, c := range n.Chapters {
+		if c.Number != nil && !known[*c.Number] {
+			missing = append(missing, c)
+		}
+	}
+	return missing
+}
+
+// MergeChapters merges chapter lists of the same series published by different sources,
+// chapters are matched by number and the one published first is kept, current wins ties.
+// The result is sorted in descending order, chapters without a number come last.
+func MergeChapters(current []ChapterEntity, other []ChapterEntity) []ChapterEntity {
+	merged := make([]ChapterEntity, 0, len(current)+len(other))
+	byNumber := make(map[float64]int)
+	for source, chapters := range [][]ChapterEntity{current, other} {
+		for _, c := range chapters {
+			if c.Number == nil {
+				// they cannot be matched across sources, only the current ones are kept
+				if source == 0 {
+					merged = append(merged, c)
+				}
+				continue
+			}
+			i, ok := byNumber[*c.Number]
+			if !ok {
+				byNumber[*c.Number] = len(merged)
+				merged = append(merged, c)
+				continue
+			}
+			if c.Date != nil && merged[i].Date != nil && c.Date.Before(*merged[i].Date) {
+				merged[i] = c
+			}
+		}
+	}
+
+	sort.SliceStable(merged, func(i, j int) bool {
+		if merged[i].Number == nil || merged[j].Number == nil {
+			return merged[j].Number == nil && merged[i].Number != nil
+		}
+		return *merged[i].Number > *merged[j].Number
+	})
+	return merged
 }
 
 // returns the missing chapters between the current manga and the new one
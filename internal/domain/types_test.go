@@ -0,0 +1,135 @@
+package domain
+
+import (
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func chapter(number float64, date time.Time, uri string) ChapterEntity {
+	return ChapterEntity{Number: &number, Date: &date, URI: uri}
+}
+
+func TestMergeChapters(t *testing.T) {
+	day := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	current := []ChapterEntity{
+		chapter(2, day.Add(48*time.Hour), "a/2"),
+		chapter(1, day, "a/1"),
+		{URI: "a/extra"},
+	}
+	other := []ChapterEntity{
+		chapter(3, day.Add(72*time.Hour), "b/3"),
+		chapter(2, day.Add(24*time.Hour), "b/2"),
+		chapter(1, day, "b/1"),
+		{URI: "b/extra"},
+	}
+
+	merged := MergeChapters(current, other)
+
+	var uris []string
+	for _, c := range merged {
+		uris = append(uris, c.URI)
+	}
+	assert.Equal(t, []string{"b/3", "b/2", "a/1", "a/extra"}, uris,
+		"the chapter published first is kept, current wins ties and unnumbered chapters of other sources are dropped")
+}
+
+func TestGetMissingChaptersByNumber(t *testing.T) {
+	day := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	current := MangaEntity{Chapters: []ChapterEntity{chapter(1, day, "a/1")}}
+	latest := MangaEntity{Chapters: []ChapterEntity{chapter(3, day, "b/3"), chapter(2, day, "b/2"), chapter(1, day, "b/1")}}
+
+	missing := current.GetMissingChaptersByNumber(latest)
+	assert.Len(t, missing, 2)
+	assert.Equal(t, "b/3", missing[0].URI)
+}
+
+func TestAllSources(t *testing.T) {
+	m := MangaEntity{Source: MangaSourceMangaNel, Slug: "solo", Sources: []SourceRef{
+		{Source: MangaSourceMangaNel, Slug: "solo"},
+		{Source: MangaSourceMangaDex, Slug: "dex-id"},
+	}}
+	assert.Equal(t, []SourceRef{{MangaSourceMangaNel, "solo"}, {MangaSourceMangaDex, "dex-id"}}, m.AllSources())
+}
+
+func TestUnreadCount(t *testing.T) {
+	day := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	m := MangaEntity{Chapters: []ChapterEntity{
+		chapter(3, day, "a/3"),
+		chapter(2.5, day, "a/2.5"),
+		chapter(2.5, day, "b/2.5"),
+		chapter(2, day, "a/2"),
+		{URI: "a/extra"},
+	}}
+
+	_, ok := m.UnreadCount()
+	assert.False(t, ok, "no marker means no progress is tracked")
+
+	m.Read = &ReadMarker{Chapter: 2, ReadAt: day}
+	count, ok := m.UnreadCount()
+	assert.True(t, ok)
+	assert.Equal(t, 2, count, "duplicate numbers are counted once")
+
+	latest, ok := m.LatestChapterNumber()
+	assert.True(t, ok)
+	assert.Equal(t, 3.0, latest)
+}
+
+func TestSubscribe(t *testing.T) {
+	m := MangaEntity{Subscribers: []string{"alice"}}
+
+	assert.True(t, m.Subscribe("alice", "bob", ""))
+	assert.False(t, m.Subscribe("bob"), "users already subscribed are not added twice")
+	assert.Equal(t, []string{"alice", "bob"}, m.Subscribers)
+	assert.True(t, m.IsSubscribed("bob"))
+	assert.False(t, m.IsSubscribed("carol"))
+
+	latest := MangaEntity{}
+	latest.KeepUserFields(m)
+	assert.Equal(t, m.Subscribers, latest.Subscribers, "subscriptions survive the updates of the series")
+}
+
+func TestClone(t *testing.T) {
+	day := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	m := MangaEntity{
+		Chapters:    []ChapterEntity{chapter(1, day, "a/1")},
+		Sources:     []SourceRef{{Source: MangaSourceMangaDex, Slug: "dex-id"}},
+		Read:        &ReadMarker{Chapter: 1},
+		Subscribers: []string{"alice"},
+	}
+
+	clone := m.Clone()
+	*clone.Chapters[0].Number = 2
+	clone.Chapters[0].URI = "b/2"
+	clone.Sources[0].Slug = "other"
+	clone.Read.Chapter = 2
+	clone.Subscribers[0] = "bob"
+
+	assert.Equal(t, 1.0, *m.Chapters[0].Number)
+	assert.Equal(t, "a/1", m.Chapters[0].URI)
+	assert.Equal(t, "dex-id", m.Sources[0].Slug)
+	assert.Equal(t, 1.0, m.Read.Chapter)
+	assert.Equal(t, []string{"alice"}, m.Subscribers)
+}
+
+func TestHealthReport_MarshalLatencyInMilliseconds(t *testing.T) {
+	data, err := json.Marshal(HealthReport{Reachable: true, SchemaValid: true, Latency: 183004211})
+	require.NoError(t, err)
+	assert.JSONEq(t, `{"reachable":true,"schemaValid":true,"latencyMs":183}`, string(data))
+}
+
+func TestChapterEntity_UnmarshalLegacyNumber(t *testing.T) {
+	var chapters []ChapterEntity
+	err := json.Unmarshal([]byte(`[{"number": 2, "uri": "a/2"}, {"name": 1, "uri": "a/1"}, {"uri": "a/extra"}]`), &chapters)
+	assert.NoError(t, err)
+	assert.Equal(t, 2.0, *chapters[0].Number)
+	assert.Equal(t, 1.0, *chapters[1].Number, "the legacy name key is still read")
+	assert.Nil(t, chapters[2].Number)
+
+	encoded, err := json.Marshal(chapters[1])
+	assert.NoError(t, err)
+	assert.Contains(t, string(encoded), `"number":1`)
+}
@@ -0,0 +1,76 @@
+package feed
+
+import (
+	"encoding/xml"
+	"io"
+	"time"
+)
+
+const atomNamespace = "http://www.w3.org/2005/Atom"
+
+type atomFeed struct {
+	XMLName xml.Name    `xml:"feed"`
+	XMLNS   string      `xml:"xmlns,attr"`
+	ID      string      `xml:"id"`
+	Title   string      `xml:"title"`
+	Updated string      `xml:"updated"`
+	Links   []atomLink  `xml:"link"`
+	Entries []atomEntry `xml:"entry"`
+}
+
+type atomLink struct {
+	Href string `xml:"href,attr"`
+	Rel  string `xml:"rel,attr,omitempty"`
+}
+
+type atomEntry struct {
+	ID        string     `xml:"id"`
+	Title     string     `xml:"title"`
+	Updated   string     `xml:"updated"`
+	Published string     `xml:"published"`
+	Links     []atomLink `xml:"link"`
+	Category  *atomTerm  `xml:"category,omitempty"`
+}
+
+type atomTerm struct {
+	Term string `xml:"term,attr"`
+}
+
+// WriteAtom renders the feed as an Atom 1.0 document
+func WriteAtom(w io.Writer, f Feed) error {
+	doc := atomFeed{
+		XMLNS:   atomNamespace,
+		ID:      f.ID,
+		Title:   f.Title,
+		Updated: f.Updated.Format(time.RFC3339),
+		Links:   []atomLink{{Href: f.Link, Rel: "self"}},
+	}
+
+	for _, e := range f.Entries {
+		entry := atomEntry{
+			ID:        e.ID,
+			Title:     e.Title,
+			Updated:   e.Published.Format(time.RFC3339),
+			Published: e.Published.Format(time.RFC3339),
+			Category:  &atomTerm{Term: e.Series},
+		}
+		if e.Link != "" {
+			entry.Links = []atomLink{{Href: e.Link, Rel: "alternate"}}
+		}
+		doc.Entries = append(doc.Entries, entry)
+	}
+
+	return encode(w, doc)
+}
+
+func encode(w io.Writer, doc any) error {
+	if _, err := io.WriteString(w, xml.Header); err != nil {
+		return err
+	}
+	enc := xml.NewEncoder(w)
+	enc.Indent("", "  ")
+	if err := enc.Encode(doc); err != nil {
+		return err
+	}
+	return enc.Close()
+}
@@ -0,0 +1,161 @@
+package feed
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strconv"
+	"time"
+
+	"github.com/ivan-penchev/manga-updates/internal/domain"
+)
+
+const defaultMaxEntries = 50
+
+// Store is the subset of the series store needed to build feeds
+type Store interface {
+	GetMangaSeries(ctx context.Context) map[string]domain.MangaEntity
+}
+
+// Config controls how feeds are titled and linked
+type Config struct {
+	// Title of the global feed
+	Title string
+	// BaseURL is where the feeds are published, used for self links and ids.
+	// Optional, relative links are used when empty.
+	BaseURL string
+	// MaxEntries limits the number of chapters in each feed
+	MaxEntries int
+}
+
+// Feed is a format agnostic list of chapter entries
+type Feed struct {
+	ID      string
+	Title   string
+	Link    string
+	Updated time.Time
+	Entries []Entry
+}
+
+// Entry represents a single chapter of a series
+type Entry struct {
+	ID        string
+	Title     string
+	Link      string
+	Series    string
+	Published time.Time
+}
+
+func (c Config) withDefaults() Config {
+	if c.Title == "" {
+		c.Title = "Manga Updates"
+	}
+	if c.MaxEntries <= 0 {
+		c.MaxEntries = defaultMaxEntries
+	}
+	return c
+}
+
+// SeriesKey returns the identifier used for per-series feed files and routes
+func SeriesKey(manga domain.MangaEntity) string {
+	return fmt.Sprintf("%s-%s", manga.Source, manga.Slug)
+}
+
+// BuildGlobal creates a feed with the most recent chapters across all series
+func BuildGlobal(cfg Config, series map[string]domain.MangaEntity) Feed {
+	var entries []Entry
+	for _, manga := range series {
+		entries = append(entries, entriesFor(manga)...)
+	}
+	return globalFeed(cfg, entries)
+}
+
+// BuildSeries creates a feed with the most recent chapters of a single series
+func BuildSeries(cfg Config, manga domain.MangaEntity) Feed {
+	return seriesFeed(cfg, manga, entriesFor(manga))
+}
+
+func globalFeed(cfg Config, entries []Entry) Feed {
+	cfg = cfg.withDefaults()
+	return newFeed(cfg, cfg.Title, "urn:manga-updates:feed", cfg.BaseURL+"/feed.atom", entries)
+}
+
+func seriesFeed(cfg Config, manga domain.MangaEntity, entries []Entry) Feed {
+	cfg = cfg.withDefaults()
+	key := SeriesKey(manga)
+
+	return newFeed(cfg,
+		fmt.Sprintf("%s - %s", cfg.Title, manga.Name),
+		"urn:manga-updates:series:"+key,
+		fmt.Sprintf("%s/series/%s.atom", cfg.BaseURL, key),
+		entries,
+	)
+}
+
+// withPrevious adds the entries of a previously written version of the feed, the entries
+// already in f win and only the most recent MaxEntries are kept.
+func withPrevious(cfg Config, f Feed, previous []Entry) Feed {
+	seen := make(map[string]bool, len(f.Entries))
+	entries := append([]Entry(nil), f.Entries...)
+	for _, e := range entries {
+		seen[e.ID] = true
+	}
+	for _, e := range previous {
+		if !seen[e.ID] {
+			seen[e.ID] = true
+			entries = append(entries, e)
+		}
+	}
+	return newFeed(cfg.withDefaults(), f.Title, f.ID, f.Link, entries)
+}
+
+func newFeed(cfg Config, title, id, link string, entries []Entry) Feed {
+	sort.SliceStable(entries, func(i, j int) bool {
+		return entries[i].Published.After(entries[j].Published)
+	})
+	if len(entries) > cfg.MaxEntries {
+		entries = entries[:cfg.MaxEntries]
+	}
+
+	updated := time.Unix(0, 0).UTC()
+	if len(entries) > 0 {
+		updated = entries[0].Published
+	}
+
+	return Feed{
+		ID:      id,
+		Title:   title,
+		Link:    link,
+		Updated: updated,
+		Entries: entries,
+	}
+}
+
+func entriesFor(manga domain.MangaEntity) []Entry {
+	entries := make([]Entry, 0, len(manga.Chapters))
+	for _, chapter := range manga.Chapters {
+		if chapter.Number == nil {
+			continue
+		}
+		number := strconv.FormatFloat(*chapter.Number, 'f', -1, 64)
+
+		published := manga.LastUpdate
+		if chapter.Date != nil && !chapter.Date.IsZero() {
+			published = *chapter.Date
+		}
+
+		id := chapter.URI
+		if id == "" {
+			id = fmt.Sprintf("urn:manga-updates:chapter:%s:%s", SeriesKey(manga), number)
+		}
+
+		entries = append(entries, Entry{
+			ID:        id,
+			Title:     fmt.Sprintf("%s - Chapter %s", manga.Name, number),
+			Link:      chapter.URI,
+			Series:    manga.Name,
+			Published: published.UTC(),
+		})
+	}
+	return entries
+}
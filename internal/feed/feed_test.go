@@ -0,0 +1,154 @@
+package feed
+
+import (
+	"bytes"
+	"context"
+	"encoding/xml"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/ivan-penchev/manga-updates/internal/domain"
+	updatechecker "github.com/ivan-penchev/manga-updates/internal/update-checker"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type staticStore map[string]domain.MangaEntity
+
+func (s staticStore) GetMangaSeries(ctx context.Context) map[string]domain.MangaEntity {
+	return s
+}
+
+// soloLevelingChapter is a chapter of the Solo Leveling series of testSeries
+func soloLevelingChapter(number float64, date time.Time) domain.ChapterEntity {
+	return domain.ChapterEntity{Number: &number, Date: &date, URI: fmt.Sprintf("https://manganel.me/chapter/solo-leveling/chapter-%g", number)}
+}
+
+func testSeries() staticStore {
+	day := time.Date(2025, time.March, 1, 12, 0, 0, 0, time.UTC)
+	swordmasterNumber, swordmasterDate := 10.5, day.Add(24*time.Hour)
+	return staticStore{
+		"a.json": {
+			Name:   "Solo Leveling",
+			Slug:   "solo-leveling",
+			Source: domain.MangaSourceMangaNel,
+			Chapters: []domain.ChapterEntity{
+				soloLevelingChapter(2, day.Add(48*time.Hour)),
+				soloLevelingChapter(1, day),
+			},
+		},
+		"b.json": {
+			Name:   "Academy's Genius Swordmaster",
+			Slug:   "633d470a",
+			Source: domain.MangaSourceMangaDex,
+			Chapters: []domain.ChapterEntity{
+				{Number: &swordmasterNumber, Date: &swordmasterDate, URI: "https://mangadex.org/chapter/abc"},
+			},
+		},
+	}
+}
+
+func TestBuildGlobal_SortsAndLimitsEntries(t *testing.T) {
+	f := BuildGlobal(Config{MaxEntries: 2}, testSeries())
+
+	require.Len(t, f.Entries, 2)
+	assert.Equal(t, "Solo Leveling - Chapter 2", f.Entries[0].Title)
+	assert.Equal(t, "Academy's Genius Swordmaster - Chapter 10.5", f.Entries[1].Title)
+	assert.Equal(t, f.Entries[0].Published, f.Updated)
+	assert.Equal(t, "Manga Updates", f.Title)
+}
+
+func TestWriteAtomAndRSS_ProduceValidXML(t *testing.T) {
+	f := BuildGlobal(Config{BaseURL: "https://example.com"}, testSeries())
+
+	var atom bytes.Buffer
+	require.NoError(t, WriteAtom(&atom, f))
+	var parsedAtom atomFeed
+	require.NoError(t, xml.Unmarshal(atom.Bytes(), &parsedAtom))
+	assert.Len(t, parsedAtom.Entries, 3)
+	assert.Equal(t, "https://example.com/feed.atom", parsedAtom.Links[0].Href)
+
+	var rss bytes.Buffer
+	require.NoError(t, WriteRSS(&rss, f))
+	var parsedRSS rssDocument
+	require.NoError(t, xml.Unmarshal(rss.Bytes(), &parsedRSS))
+	assert.Len(t, parsedRSS.Channel.Items, 3)
+	assert.True(t, parsedRSS.Channel.Items[0].GUID.IsPermaLink)
+}
+
+func TestFileWriter_WritesGlobalAndSeriesFeeds(t *testing.T) {
+	dir := t.TempDir()
+
+	require.NoError(t, NewFileWriter(testSeries(), dir, Config{}).Write(context.Background()))
+
+	for _, name := range []string{
+		"feed.atom",
+		"feed.rss",
+		"series/manganel-solo-leveling.atom",
+		"series/manganel-solo-leveling.rss",
+		"series/mangadex-633d470a.atom",
+		"series/mangadex-633d470a.rss",
+	} {
+		_, err := os.Stat(filepath.Join(dir, name))
+		assert.NoError(t, err, name)
+	}
+}
+
+func TestFileWriter_RunCompletedAddsNewChaptersToPreviousFeeds(t *testing.T) {
+	dir := t.TempDir()
+	day := time.Date(2025, time.March, 1, 12, 0, 0, 0, time.UTC)
+	series := testSeries()
+	solo := series["a.json"]
+	writer := NewFileWriter(series, dir, Config{MaxEntries: 2})
+
+	require.NoError(t, writer.RunCompleted(context.Background(), updatechecker.RunSummary{}))
+	assert.Empty(t, readAtom(t, filepath.Join(dir, "feed.atom")).Entries, "chapters are only published once detected")
+	assert.NoFileExists(t, filepath.Join(dir, "series", "manganel-solo-leveling.atom"))
+
+	require.NoError(t, writer.RunCompleted(context.Background(), updatechecker.RunSummary{Updated: []updatechecker.SeriesUpdate{
+		{Manga: solo, NewChapters: solo.Chapters[:1]},
+	}}))
+	require.NoError(t, writer.RunCompleted(context.Background(), updatechecker.RunSummary{Updated: []updatechecker.SeriesUpdate{
+		{Manga: solo, NewChapters: []domain.ChapterEntity{soloLevelingChapter(3, day.Add(72*time.Hour))}},
+		{Manga: series["b.json"], NewChapters: series["b.json"].Chapters},
+	}}))
+
+	global := readAtom(t, filepath.Join(dir, "feed.atom"))
+	require.Len(t, global.Entries, 2, "the previous entries are kept up to MaxEntries")
+	assert.Equal(t, "Solo Leveling - Chapter 3", global.Entries[0].Title)
+	assert.Equal(t, "Solo Leveling - Chapter 2", global.Entries[1].Title)
+	assert.Equal(t, "Solo Leveling", global.Entries[1].Series)
+
+	seriesFeed := readAtom(t, filepath.Join(dir, "series", "manganel-solo-leveling.atom"))
+	assert.Len(t, seriesFeed.Entries, 2)
+	assert.FileExists(t, filepath.Join(dir, "series", "mangadex-633d470a.rss"))
+}
+
+func readAtom(t *testing.T, path string) Feed {
+	t.Helper()
+	file, err := os.Open(path)
+	require.NoError(t, err)
+	defer file.Close()
+	f, err := Parse(file)
+	require.NoError(t, err)
+	return f
+}
+
+func TestHandler_ServesSeriesFeed(t *testing.T) {
+	handler := NewHandler(testSeries(), Config{})
+
+	rec := httptest.NewRecorder()
+	handler.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/series/manganel-solo-leveling.rss", nil))
+	assert.Equal(t, http.StatusOK, rec.Code)
+	assert.Contains(t, rec.Header().Get("Content-Type"), "application/rss+xml")
+	assert.Contains(t, rec.Body.String(), "Solo Leveling - Chapter 2")
+
+	rec = httptest.NewRecorder()
+	handler.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/series/unknown.atom", nil))
+	assert.Equal(t, http.StatusNotFound, rec.Code)
+}
@@ -0,0 +1,51 @@
+package feed
+
+import (
+	"io"
+	"net/http"
+	"strings"
+)
+
+// NewHandler serves the feeds straight from the store, using the same
+// paths as the FileWriter output directory.
+func NewHandler(store Store, cfg Config) http.Handler {
+	mux := http.NewServeMux()
+
+	mux.HandleFunc("GET /feed.atom", func(w http.ResponseWriter, r *http.Request) {
+		serve(w, "application/atom+xml", BuildGlobal(cfg, store.GetMangaSeries(r.Context())), WriteAtom)
+	})
+	mux.HandleFunc("GET /feed.rss", func(w http.ResponseWriter, r *http.Request) {
+		serve(w, "application/rss+xml", BuildGlobal(cfg, store.GetMangaSeries(r.Context())), WriteRSS)
+	})
+	mux.HandleFunc("GET /series/{file}", func(w http.ResponseWriter, r *http.Request) {
+		file := r.PathValue("file")
+
+		render, contentType := WriteAtom, "application/atom+xml"
+		key, ok := strings.CutSuffix(file, ".atom")
+		if !ok {
+			render, contentType = WriteRSS, "application/rss+xml"
+			key, ok = strings.CutSuffix(file, ".rss")
+		}
+		if !ok {
+			http.NotFound(w, r)
+			return
+		}
+
+		for _, manga := range store.GetMangaSeries(r.Context()) {
+			if SeriesKey(manga) == key {
+				serve(w, contentType, BuildSeries(cfg, manga), render)
+				return
+			}
+		}
+		http.NotFound(w, r)
+	})
+
+	return mux
+}
+
+func serve(w http.ResponseWriter, contentType string, f Feed, render func(io.Writer, Feed) error) {
+	w.Header().Set("Content-Type", contentType+"; charset=utf-8")
+	if err := render(w, f); err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+	}
+}
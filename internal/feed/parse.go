@@ -0,0 +1,147 @@
+package feed
+
+import (
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+	"time"
+)
+
+// rssDateLayouts lists the date formats commonly found in the wild,
+// RSS 2.0 mandates RFC 822 but many publishers deviate from it.
+var rssDateLayouts = []string{
+	time.RFC1123Z,
+	time.RFC1123,
+	time.RFC822Z,
+	time.RFC822,
+	"Mon, 2 Jan 2006 15:04:05 -0700",
+	"Mon, 2 Jan 2006 15:04:05 MST",
+	time.RFC3339,
+}
+
+type parsedDocument struct {
+	XMLName xml.Name
+	// RSS 2.0
+	Channel struct {
+		Title string `xml:"title"`
+		Link  string `xml:"link"`
+		Items []struct {
+			Title   string `xml:"title"`
+			Link    string `xml:"link"`
+			GUID    string `xml:"guid"`
+			PubDate string `xml:"pubDate"`
+		} `xml:"item"`
+	} `xml:"channel"`
+	// Atom 1.0
+	ID      string     `xml:"id"`
+	Title   string     `xml:"title"`
+	Updated string     `xml:"updated"`
+	Links   []atomLink `xml:"link"`
+	Entries []struct {
+		ID        string     `xml:"id"`
+		Title     string     `xml:"title"`
+		Updated   string     `xml:"updated"`
+		Published string     `xml:"published"`
+		Links     []atomLink `xml:"link"`
+		Category  *atomTerm  `xml:"category"`
+	} `xml:"entry"`
+}
+
+// Parse reads an RSS 2.0 or Atom 1.0 document
+func Parse(r io.Reader) (Feed, error) {
+	var doc parsedDocument
+	if err := xml.NewDecoder(r).Decode(&doc); err != nil {
+		return Feed{}, fmt.Errorf("failed to decode feed: %w", err)
+	}
+
+	switch doc.XMLName.Local {
+	case "rss":
+		return parseRSS(doc), nil
+	case "feed":
+		return parseAtom(doc), nil
+	default:
+		return Feed{}, fmt.Errorf("unsupported feed format: <%s>", doc.XMLName.Local)
+	}
+}
+
+func parseRSS(doc parsedDocument) Feed {
+	f := Feed{
+		ID:    doc.Channel.Link,
+		Title: strings.TrimSpace(doc.Channel.Title),
+		Link:  doc.Channel.Link,
+	}
+
+	for _, item := range doc.Channel.Items {
+		published, _ := parseDate(item.PubDate, rssDateLayouts...)
+		id := strings.TrimSpace(item.GUID)
+		if id == "" {
+			id = strings.TrimSpace(item.Link)
+		}
+		f.Entries = append(f.Entries, Entry{
+			ID:        id,
+			Title:     strings.TrimSpace(item.Title),
+			Link:      strings.TrimSpace(item.Link),
+			Published: published,
+		})
+		if published.After(f.Updated) {
+			f.Updated = published
+		}
+	}
+
+	return f
+}
+
+func parseAtom(doc parsedDocument) Feed {
+	f := Feed{
+		ID:    doc.ID,
+		Title: strings.TrimSpace(doc.Title),
+		Link:  alternateLink(doc.Links),
+	}
+	f.Updated, _ = parseDate(doc.Updated, time.RFC3339)
+
+	for _, entry := range doc.Entries {
+		published, err := parseDate(entry.Published, time.RFC3339)
+		if err != nil {
+			published, _ = parseDate(entry.Updated, time.RFC3339)
+		}
+		e := Entry{
+			ID:        strings.TrimSpace(entry.ID),
+			Title:     strings.TrimSpace(entry.Title),
+			Link:      alternateLink(entry.Links),
+			Published: published,
+		}
+		if entry.Category != nil {
+			e.Series = entry.Category.Term
+		}
+		f.Entries = append(f.Entries, e)
+		if published.After(f.Updated) {
+			f.Updated = published
+		}
+	}
+
+	return f
+}
+
+func alternateLink(links []atomLink) string {
+	for _, l := range links {
+		if l.Rel == "" || l.Rel == "alternate" {
+			return l.Href
+		}
+	}
+	return ""
+}
+
+func parseDate(value string, layouts ...string) (time.Time, error) {
+	value = strings.TrimSpace(value)
+	if value == "" {
+		return time.Time{}, errors.New("empty date")
+	}
+	for _, layout := range layouts {
+		if t, err := time.Parse(layout, value); err == nil {
+			return t.UTC(), nil
+		}
+	}
+	return time.Time{}, fmt.Errorf("unrecognized date format: %s", value)
+}
@@ -0,0 +1,59 @@
+package feed
+
+import (
+	"encoding/xml"
+	"io"
+	"time"
+)
+
+type rssDocument struct {
+	XMLName xml.Name   `xml:"rss"`
+	Version string     `xml:"version,attr"`
+	Channel rssChannel `xml:"channel"`
+}
+
+type rssChannel struct {
+	Title         string    `xml:"title"`
+	Link          string    `xml:"link"`
+	Description   string    `xml:"description"`
+	LastBuildDate string    `xml:"lastBuildDate"`
+	Items         []rssItem `xml:"item"`
+}
+
+type rssItem struct {
+	Title    string  `xml:"title"`
+	Link     string  `xml:"link,omitempty"`
+	GUID     rssGUID `xml:"guid"`
+	PubDate  string  `xml:"pubDate"`
+	Category string  `xml:"category,omitempty"`
+}
+
+type rssGUID struct {
+	Value       string `xml:",chardata"`
+	IsPermaLink bool   `xml:"isPermaLink,attr"`
+}
+
+// WriteRSS renders the feed as an RSS 2.0 document
+func WriteRSS(w io.Writer, f Feed) error {
+	doc := rssDocument{
+		Version: "2.0",
+		Channel: rssChannel{
+			Title:         f.Title,
+			Link:          f.Link,
+			Description:   f.Title,
+			LastBuildDate: f.Updated.Format(time.RFC1123Z),
+		},
+	}
+
+	for _, e := range f.Entries {
+		doc.Channel.Items = append(doc.Channel.Items, rssItem{
+			Title:    e.Title,
+			Link:     e.Link,
+			GUID:     rssGUID{Value: e.ID, IsPermaLink: e.ID == e.Link},
+			PubDate:  e.Published.Format(time.RFC1123Z),
+			Category: e.Series,
+		})
+	}
+
+	return encode(w, doc)
+}
@@ -0,0 +1,110 @@
+package feed
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+
+	updatechecker "github.com/ivan-penchev/manga-updates/internal/update-checker"
+)
+
+// FileWriter writes the global and per-series feeds into a directory.
+//
+// Layout of the output directory:
+//
+//	feed.atom
+//	feed.rss
+//	series/<source>-<slug>.atom
+//	series/<source>-<slug>.rss
+type FileWriter struct {
+	store Store
+	dir   string
+	cfg   Config
+}
+
+func NewFileWriter(store Store, dir string, cfg Config) *FileWriter {
+	return &FileWriter{
+		store: store,
+		dir:   dir,
+		cfg:   cfg,
+	}
+}
+
+// RunCompleted implements updatechecker.RunObserver, it adds the chapters detected during
+// the run to the feeds written by the previous runs. The global feed is written on every
+// run, a series feed once the series has new chapters.
+func (fw *FileWriter) RunCompleted(ctx context.Context, summary updatechecker.RunSummary) error {
+	if err := os.MkdirAll(filepath.Join(fw.dir, "series"), 0755); err != nil {
+		return fmt.Errorf("failed to create feed directory: %w", err)
+	}
+
+	var detected []Entry
+	for _, update := range summary.Updated {
+		manga := update.Manga
+		manga.Chapters = update.NewChapters
+		entries := entriesFor(manga)
+		detected = append(detected, entries...)
+
+		if err := fw.update(filepath.Join(fw.dir, "series", SeriesKey(manga)), seriesFeed(fw.cfg, manga, entries)); err != nil {
+			return err
+		}
+	}
+	return fw.update(filepath.Join(fw.dir, "feed"), globalFeed(fw.cfg, detected))
+}
+
+// Write renders all feeds from the current contents of the store, replacing the feeds
+// written by the update runs.
+func (fw *FileWriter) Write(ctx context.Context) error {
+	series := fw.store.GetMangaSeries(ctx)
+
+	if err := os.MkdirAll(filepath.Join(fw.dir, "series"), 0755); err != nil {
+		return fmt.Errorf("failed to create feed directory: %w", err)
+	}
+
+	if err := writeFeed(filepath.Join(fw.dir, "feed"), BuildGlobal(fw.cfg, series)); err != nil {
+		return err
+	}
+	for _, manga := range series {
+		if err := writeFeed(filepath.Join(fw.dir, "series", SeriesKey(manga)), BuildSeries(fw.cfg, manga)); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// update merges f with the entries of the Atom feed previously written at base
+func (fw *FileWriter) update(base string, f Feed) error {
+	file, err := os.Open(base + ".atom")
+	if err == nil {
+		previous, parseErr := Parse(file)
+		file.Close()
+		if parseErr != nil {
+			return fmt.Errorf("failed to read previous feed %s.atom: %w", base, parseErr)
+		}
+		f = withPrevious(fw.cfg, f, previous.Entries)
+	} else if !errors.Is(err, fs.ErrNotExist) {
+		return fmt.Errorf("failed to read previous feed: %w", err)
+	}
+	return writeFeed(base, f)
+}
+
+// writeFeed writes f as base.atom and base.rss
+func writeFeed(base string, f Feed) error {
+	if err := writeFile(base+".atom", f, WriteAtom); err != nil {
+		return err
+	}
+	return writeFile(base+".rss", f, WriteRSS)
+}
+
+func writeFile(path string, f Feed, render func(io.Writer, Feed) error) error {
+	var buf bytes.Buffer
+	if err := render(&buf, f); err != nil {
+		return fmt.Errorf("failed to render feed %s: %w", path, err)
+	}
+	return os.WriteFile(path, buf.Bytes(), 0644)
+}
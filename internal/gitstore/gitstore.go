@@ -0,0 +1,262 @@
+// Package gitstore decorates a store so that the series data files changed by an
+// update run are committed, and optionally pushed, with the local git binary.
+package gitstore
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/ivan-penchev/manga-updates/internal/domain"
+	updatechecker "github.com/ivan-penchev/manga-updates/internal/update-checker"
+)
+
+type Store interface {
+	GetMangaSeries(ctx context.Context) map[string]domain.MangaEntity
+	PersistMangaTitle(ctx context.Context, location string, mangaTitle domain.MangaEntity) error
+	AddManga(ctx context.Context, manga domain.MangaEntity) error
+}
+
+// GitStore is a Store committing the series files it wrote after every update run in
+// which a series changed, along with the history of the series when enabled. It
+// implements updatechecker.RunObserver.
+type GitStore struct {
+	Store
+	dir string
+	git string
+
+	push        bool
+	remote      string
+	authorName  string
+	authorEmail string
+	historyDir  string
+
+	mutex sync.Mutex
+	// written holds the absolute paths of the series files written since the last commit
+	written map[string]bool
+}
+
+type Option func(*GitStore)
+
+// WithPush pushes the commits to remote, "origin" when empty
+func WithPush(remote string) Option {
+	return func(g *GitStore) {
+		g.push = true
+		g.remote = remote
+	}
+}
+
+// WithAuthor sets the identity of the commits, the git configuration is used by default
+func WithAuthor(name, email string) Option {
+	return func(g *GitStore) {
+		g.authorName = name
+		g.authorEmail = email
+	}
+}
+
+// WithHistoryDir commits the history folder dir, which has to be inside the git work tree,
+// along with the series changed by a run
+func WithHistoryDir(dir string) Option {
+	return func(g *GitStore) {
+		g.historyDir = dir
+	}
+}
+
+// WithGitBinary sets the git executable, "git" from the PATH by default
+func WithGitBinary(path string) Option {
+	return func(g *GitStore) {
+		g.git = path
+	}
+}
+
+// New decorates the store of the data folder dir, which has to be inside a git work tree.
+func New(store Store, dir string, opts ...Option) *GitStore {
+	g := &GitStore{
+		Store:   store,
+		dir:     dir,
+		git:     "git",
+		remote:  "origin",
+		written: make(map[string]bool),
+	}
+	for _, opt := range opts {
+		opt(g)
+	}
+	if g.remote == "" {
+		g.remote = "origin"
+	}
+	return g
+}
+
+func (g *GitStore) PersistMangaTitle(ctx context.Context, location string, mangaTitle domain.MangaEntity) error {
+	err := g.Store.PersistMangaTitle(ctx, location, mangaTitle)
+	g.markWritten(location)
+	return err
+}
+
+func (g *GitStore) AddManga(ctx context.Context, manga domain.MangaEntity) error {
+	if err := g.Store.AddManga(ctx, manga); err != nil {
+		return err
+	}
+	// the store picks the file of a new series
+	for location, m := range g.Store.GetMangaSeries(ctx) {
+		if m.Source == manga.Source && m.Slug == manga.Slug {
+			g.markWritten(location)
+		}
+	}
+	return nil
+}
+
+func (g *GitStore) markWritten(location string) {
+	if abs, err := filepath.Abs(location); err == nil {
+		location = abs
+	}
+	g.mutex.Lock()
+	defer g.mutex.Unlock()
+	g.written[location] = true
+}
+
+// takeWritten returns the series files written since the last call
+func (g *GitStore) takeWritten() []string {
+	g.mutex.Lock()
+	defer g.mutex.Unlock()
+	paths := make([]string, 0, len(g.written))
+	for path := range g.written {
+		paths = append(paths, path)
+	}
+	g.written = make(map[string]bool)
+	sort.Strings(paths)
+	return paths
+}
+
+// RunCompleted implements updatechecker.RunObserver
+func (g *GitStore) RunCompleted(ctx context.Context, summary updatechecker.RunSummary) error {
+	paths := g.takeWritten()
+	changed, err := g.stage(ctx, paths...)
+	if err != nil || !changed {
+		return err
+	}
+
+	// the history of the runs that changed no series is committed with the next change
+	if g.historyDir != "" {
+		if _, err := os.Stat(g.historyDir); err == nil {
+			if _, err := g.stage(ctx, g.historyDir); err != nil {
+				return err
+			}
+			paths = append(paths, g.historyDir)
+		}
+	}
+	return g.commit(ctx, CommitMessage(summary), paths...)
+}
+
+// Commit stages the changes of paths and commits them, then pushes when enabled, the other
+// files of the work tree are left as they are. It returns false without committing when
+// none of the paths changed.
+func (g *GitStore) Commit(ctx context.Context, message string, paths ...string) (bool, error) {
+	changed, err := g.stage(ctx, paths...)
+	if err != nil || !changed {
+		return false, err
+	}
+	return true, g.commit(ctx, message, paths...)
+}
+
+// stage adds the changes of paths to the index and reports whether any of them changed
+func (g *GitStore) stage(ctx context.Context, paths ...string) (bool, error) {
+	if len(paths) == 0 {
+		return false, nil
+	}
+	if _, err := g.run(ctx, append([]string{"add", "--all", "--"}, paths...)...); err != nil {
+		return false, err
+	}
+
+	changed, err := g.run(ctx, append([]string{"diff", "--cached", "--name-only", "--"}, paths...)...)
+	if err != nil {
+		return false, err
+	}
+	return strings.TrimSpace(changed) != "", nil
+}
+
+func (g *GitStore) commit(ctx context.Context, message string, paths ...string) error {
+	// the pathspec keeps the changes staged outside of paths out of the commit
+	if _, err := g.run(ctx, append([]string{"commit", "--quiet", "--message", message, "--"}, paths...)...); err != nil {
+		return err
+	}
+
+	if g.push {
+		if _, err := g.run(ctx, "push", "--quiet", g.remote, "HEAD"); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (g *GitStore) run(ctx context.Context, args ...string) (string, error) {
+	var stdout, stderr bytes.Buffer
+	cmd := exec.CommandContext(ctx, g.git, args...)
+	cmd.Dir = g.dir
+	cmd.Stdout = &stdout
+	cmd.Stderr = &stderr
+	// an empty variable would override the identity configured in git and fail the commit
+	var identity []string
+	if g.authorName != "" {
+		identity = append(identity, "GIT_AUTHOR_NAME="+g.authorName, "GIT_COMMITTER_NAME="+g.authorName)
+	}
+	if g.authorEmail != "" {
+		identity = append(identity, "GIT_AUTHOR_EMAIL="+g.authorEmail, "GIT_COMMITTER_EMAIL="+g.authorEmail)
+	}
+	if len(identity) > 0 {
+		cmd.Env = append(os.Environ(), identity...)
+	}
+	if err := cmd.Run(); err != nil {
+		var exitErr *exec.ExitError
+		if errors.As(err, &exitErr) {
+			return "", fmt.Errorf("git %s failed: %s", args[0], strings.TrimSpace(stderr.String()))
+		}
+		return "", fmt.Errorf("git %s failed: %w", args[0], err)
+	}
+	return stdout.String(), nil
+}
+
+// CommitMessage describes the series updated during a run and their new chapters
+func CommitMessage(summary updatechecker.RunSummary) string {
+	if len(summary.Updated) == 0 {
+		return "Update series data"
+	}
+
+	updated := append([]updatechecker.SeriesUpdate(nil), summary.Updated...)
+	sort.Slice(updated, func(i, j int) bool {
+		return updated[i].Manga.Name < updated[j].Manga.Name
+	})
+
+	var b strings.Builder
+	fmt.Fprintf(&b, "Update %d series\n\n", len(updated))
+	for _, u := range updated {
+		var values []float64
+		for _, c := range u.NewChapters {
+			if c.Number != nil {
+				values = append(values, *c.Number)
+			}
+		}
+		sort.Float64s(values)
+		numbers := make([]string, len(values))
+		for i, v := range values {
+			numbers[i] = strconv.FormatFloat(v, 'f', -1, 64)
+		}
+		switch len(numbers) {
+		case 0:
+			fmt.Fprintf(&b, "- %s\n", u.Manga.Name)
+		case 1:
+			fmt.Fprintf(&b, "- %s: chapter %s\n", u.Manga.Name, numbers[0])
+		default:
+			fmt.Fprintf(&b, "- %s: chapters %s\n", u.Manga.Name, strings.Join(numbers, ", "))
+		}
+	}
+	return b.String()
+}
@@ -0,0 +1,129 @@
+package gitstore
+
+import (
+	"context"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/ivan-penchev/manga-updates/internal/domain"
+	"github.com/ivan-penchev/manga-updates/internal/history"
+	"github.com/ivan-penchev/manga-updates/internal/store"
+	updatechecker "github.com/ivan-penchev/manga-updates/internal/update-checker"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func git(t *testing.T, dir string, args ...string) string {
+	t.Helper()
+	cmd := exec.Command("git", args...)
+	cmd.Dir = dir
+	out, err := cmd.CombinedOutput()
+	require.NoError(t, err, string(out))
+	return strings.TrimSpace(string(out))
+}
+
+// newRepo creates a work tree with a data folder, pushing to a bare remote
+func newRepo(t *testing.T) (repo, data, remote string) {
+	t.Helper()
+	if _, err := exec.LookPath("git"); err != nil {
+		t.Skip("git is not installed")
+	}
+	remote = t.TempDir()
+	git(t, remote, "init", "--quiet", "--bare")
+
+	repo = t.TempDir()
+	git(t, repo, "init", "--quiet")
+	git(t, repo, "remote", "add", "origin", remote)
+	data = filepath.Join(repo, "data")
+	require.NoError(t, os.MkdirAll(data, 0755))
+	require.NoError(t, os.WriteFile(filepath.Join(repo, "README.md"), []byte("library"), 0644))
+	git(t, repo, "add", "README.md")
+	git(t, repo, "-c", "user.name=test", "-c", "user.email=test@example.com", "commit", "--quiet", "-m", "init")
+	return repo, data, remote
+}
+
+func TestGitStore_CommitsAfterRun(t *testing.T) {
+	ctx := context.Background()
+	repo, data, remote := newRepo(t)
+	require.NoError(t, os.WriteFile(filepath.Join(repo, "notes.txt"), []byte("unrelated"), 0644))
+
+	s := New(store.NewStore(data), data, WithAuthor("MangaUpdates (Automation)", "bot@example.com"), WithPush(""))
+	number := 12.0
+	manga := domain.MangaEntity{Name: "Solo Leveling", Slug: "solo-leveling", Source: domain.MangaSourceMangaNel}
+	require.NoError(t, s.AddManga(ctx, manga))
+
+	summary := updatechecker.RunSummary{Updated: []updatechecker.SeriesUpdate{{
+		Manga:       manga,
+		NewChapters: []domain.ChapterEntity{{Number: &number}},
+	}}}
+	require.NoError(t, s.RunCompleted(ctx, summary))
+
+	assert.Equal(t, "Update 1 series\n\n- Solo Leveling: chapter 12", git(t, repo, "log", "-1", "--format=%B"))
+	assert.Equal(t, "MangaUpdates (Automation) <bot@example.com>", git(t, repo, "log", "-1", "--format=%an <%ae>"))
+	assert.Equal(t, "data/solo-leveling.json", git(t, repo, "show", "--name-only", "--format=", "HEAD"))
+	assert.Equal(t, "?? notes.txt", git(t, repo, "status", "--porcelain"), "files outside of the data folder are not committed")
+	assert.Equal(t, git(t, repo, "rev-parse", "HEAD"), git(t, remote, "rev-parse", "HEAD"), "the commit is pushed")
+
+	head := git(t, repo, "rev-parse", "HEAD")
+	require.NoError(t, s.RunCompleted(ctx, updatechecker.RunSummary{}))
+	assert.Equal(t, head, git(t, repo, "rev-parse", "HEAD"), "runs that persisted nothing do not commit")
+
+	series := s.GetMangaSeries(ctx)
+	for path, m := range series {
+		require.NoError(t, s.PersistMangaTitle(ctx, path, m))
+	}
+	require.NoError(t, s.RunCompleted(ctx, updatechecker.RunSummary{}))
+	assert.Equal(t, head, git(t, repo, "rev-parse", "HEAD"), "unchanged files do not create empty commits")
+}
+
+func TestGitStore_CommitsOnlyTheWrittenSeries(t *testing.T) {
+	ctx := context.Background()
+	repo, data, _ := newRepo(t)
+	git(t, repo, "config", "user.email", "library@example.com")
+	require.NoError(t, os.WriteFile(filepath.Join(data, "notes.txt"), []byte("draft"), 0644))
+
+	s := New(store.NewStore(data), data, WithAuthor("MangaUpdates (Automation)", ""))
+	require.NoError(t, s.AddManga(ctx, domain.MangaEntity{Name: "Berserk", Slug: "berserk", Source: domain.MangaSourceMangaDex}))
+	require.NoError(t, s.RunCompleted(ctx, updatechecker.RunSummary{}))
+
+	assert.Equal(t, "MangaUpdates (Automation) <library@example.com>", git(t, repo, "log", "-1", "--format=%an <%ae>"), "the email configured in git is kept")
+	assert.Equal(t, "data/berserk.json", git(t, repo, "show", "--name-only", "--format=", "HEAD"))
+	assert.Equal(t, "?? data/notes.txt", git(t, repo, "status", "--porcelain"), "files the store did not write are not committed")
+}
+
+func TestGitStore_CommitsTheHistoryWithChangedSeries(t *testing.T) {
+	ctx := context.Background()
+	repo, data, _ := newRepo(t)
+	historyDir := filepath.Join(data, ".history")
+	recorder := history.NewRecorder(historyDir)
+
+	s := New(store.NewStore(data), data, WithAuthor("test", "test@example.com"), WithHistoryDir(historyDir))
+	manga := domain.MangaEntity{Name: "Berserk", Slug: "berserk", Source: domain.MangaSourceMangaDex}
+	require.NoError(t, s.AddManga(ctx, manga))
+	unchanged := updatechecker.RunSummary{Unchanged: []updatechecker.SeriesUpdate{{Manga: manga}}}
+	require.NoError(t, recorder.RunCompleted(ctx, unchanged))
+	require.NoError(t, s.RunCompleted(ctx, unchanged))
+
+	assert.Equal(t, "data/.history/mangadex-berserk.jsonl\ndata/berserk.json", git(t, repo, "show", "--name-only", "--format=", "HEAD"))
+
+	head := git(t, repo, "rev-parse", "HEAD")
+	for path, m := range s.GetMangaSeries(ctx) {
+		require.NoError(t, s.PersistMangaTitle(ctx, path, m))
+	}
+	require.NoError(t, recorder.RunCompleted(ctx, unchanged))
+	require.NoError(t, s.RunCompleted(ctx, unchanged))
+	assert.Equal(t, head, git(t, repo, "rev-parse", "HEAD"), "the history of runs that changed no series is not committed")
+	assert.Equal(t, "M data/.history/mangadex-berserk.jsonl", git(t, repo, "status", "--porcelain"))
+}
+
+func TestCommitMessage(t *testing.T) {
+	n := func(f float64) *float64 { return &f }
+	message := CommitMessage(updatechecker.RunSummary{Updated: []updatechecker.SeriesUpdate{
+		{Manga: domain.MangaEntity{Name: "Tower of God"}, NewChapters: []domain.ChapterEntity{{Number: n(601)}, {Number: n(600.5)}}},
+		{Manga: domain.MangaEntity{Name: "Berserk"}, NewChapters: []domain.ChapterEntity{{URI: "unnumbered"}}},
+	}})
+	assert.Equal(t, "Update 2 series\n\n- Berserk\n- Tower of God: chapters 600.5, 601\n", message)
+}
@@ -0,0 +1,176 @@
+// Package history keeps an append-only log per series of every check attempt
+// and of when each chapter was first seen.
+//
+// Every series has its own JSON Lines file in the history directory:
+//
+//	<source>-<slug>.jsonl
+//
+// Slugs that are not safe file names, such as the urls of feeds, are made safe and
+// suffixed with a hash of the source and slug so that two series never share a file.
+package history
+
+import (
+	"bufio"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/ivan-penchev/manga-updates/internal/domain"
+	updatechecker "github.com/ivan-penchev/manga-updates/internal/update-checker"
+)
+
+// EventType tells check events and chapter events apart
+type EventType string
+
+const (
+	// EventCheck records the outcome of a check attempt
+	EventCheck EventType = "check"
+	// EventChapter records the first time a chapter was seen
+	EventChapter EventType = "chapter"
+)
+
+// Outcome is the result of a check attempt
+type Outcome string
+
+const (
+	OutcomeUpdated   Outcome = "updated"
+	OutcomeUnchanged Outcome = "unchanged"
+	OutcomeSkipped   Outcome = "skipped"
+	OutcomeFailed    Outcome = "failed"
+)
+
+// Event is a single line of a series history
+type Event struct {
+	Time time.Time `json:"time"`
+	Type EventType `json:"type"`
+
+	// Outcome, Error and NewChapters describe check events
+	Outcome     Outcome `json:"outcome,omitempty"`
+	Error       string  `json:"error,omitempty"`
+	NewChapters int     `json:"newChapters,omitempty"`
+
+	// Chapter and URI describe chapter events
+	Chapter *float64 `json:"chapter,omitempty"`
+	URI     string   `json:"uri,omitempty"`
+}
+
+// Recorder appends the outcome of every update run to the history of the series.
+// It implements updatechecker.RunObserver.
+type Recorder struct {
+	dir   string
+	mutex sync.Mutex
+}
+
+func NewRecorder(dir string) *Recorder {
+	return &Recorder{dir: dir}
+}
+
+// RunCompleted implements updatechecker.RunObserver
+func (r *Recorder) RunCompleted(ctx context.Context, summary updatechecker.RunSummary) error {
+	at := summary.FinishedAt
+	if at.IsZero() {
+		at = time.Now()
+	}
+
+	var errs []error
+	for _, u := range summary.Updated {
+		events := []Event{{Time: at, Type: EventCheck, Outcome: OutcomeUpdated, NewChapters: len(u.NewChapters)}}
+		// new chapters are ordered newest first, they are logged in publication order
+		for i := len(u.NewChapters) - 1; i >= 0; i-- {
+			c := u.NewChapters[i]
+			events = append(events, Event{Time: at, Type: EventChapter, Chapter: c.Number, URI: c.URI})
+		}
+		errs = append(errs, r.Append(u.Manga, events...))
+	}
+	for _, u := range summary.Unchanged {
+		errs = append(errs, r.Append(u.Manga, Event{Time: at, Type: EventCheck, Outcome: OutcomeUnchanged}))
+	}
+	for _, s := range summary.Skipped {
+		errs = append(errs, r.Append(s.Manga, Event{Time: at, Type: EventCheck, Outcome: OutcomeSkipped, Error: errorString(s.Err)}))
+	}
+	for _, f := range summary.Failed {
+		errs = append(errs, r.Append(f.Manga, Event{Time: at, Type: EventCheck, Outcome: OutcomeFailed, Error: errorString(f.Err)}))
+	}
+	return errors.Join(errs...)
+}
+
+// Append adds events to the history of a series
+func (r *Recorder) Append(manga domain.MangaEntity, events ...Event) error {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	if err := os.MkdirAll(r.dir, 0755); err != nil {
+		return fmt.Errorf("failed to create history directory: %w", err)
+	}
+	file, err := os.OpenFile(Path(r.dir, manga), os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0644)
+	if err != nil {
+		return fmt.Errorf("failed to open history of %s: %w", manga.Name, err)
+	}
+	defer file.Close()
+
+	encoder := json.NewEncoder(file)
+	for _, event := range events {
+		if err := encoder.Encode(event); err != nil {
+			return fmt.Errorf("failed to append history of %s: %w", manga.Name, err)
+		}
+	}
+	return nil
+}
+
+// Read returns the history of a series from the oldest event, it is empty when
+// the series was never checked.
+func Read(dir string, manga domain.MangaEntity) ([]Event, error) {
+	file, err := os.Open(Path(dir, manga))
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	var events []Event
+	scanner := bufio.NewScanner(file)
+	for line := 1; scanner.Scan(); line++ {
+		if len(strings.TrimSpace(scanner.Text())) == 0 {
+			continue
+		}
+		var event Event
+		if err := json.Unmarshal(scanner.Bytes(), &event); err != nil {
+			return events, fmt.Errorf("invalid history line %d: %w", line, err)
+		}
+		events = append(events, event)
+	}
+	return events, scanner.Err()
+}
+
+var unsafeKeyChars = regexp.MustCompile(`[^A-Za-z0-9._-]+`)
+
+// Path returns the history file of a series
+func Path(dir string, manga domain.MangaEntity) string {
+	exact := fmt.Sprintf("%s-%s", manga.Source, manga.Slug)
+	slug := strings.TrimPrefix(strings.TrimPrefix(manga.Slug, "https://"), "http://")
+	key := strings.Trim(unsafeKeyChars.ReplaceAllString(fmt.Sprintf("%s-%s", manga.Source, slug), "-"), "-.")
+	if key != exact {
+		// the key lost characters, the hash keeps series whose slugs only differ by them apart
+		sum := sha256.Sum256([]byte(string(manga.Source) + "\x00" + manga.Slug))
+		key += "-" + hex.EncodeToString(sum[:6])
+	}
+	return filepath.Join(dir, key+".jsonl")
+}
+
+func errorString(err error) string {
+	if err == nil {
+		return ""
+	}
+	return err.Error()
+}
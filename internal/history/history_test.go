@@ -0,0 +1,66 @@
+package history
+
+import (
+	"context"
+	"errors"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/ivan-penchev/manga-updates/internal/domain"
+	updatechecker "github.com/ivan-penchev/manga-updates/internal/update-checker"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRecorder_AppendsRunOutcomes(t *testing.T) {
+	ctx := context.Background()
+	dir := t.TempDir()
+	recorder := NewRecorder(dir)
+
+	solo := domain.MangaEntity{Name: "Solo Leveling", Slug: "solo-leveling", Source: domain.MangaSourceMangaNel}
+	feed := domain.MangaEntity{Name: "Feed", Slug: "https://scans.example.com/feed", Source: domain.MangaSourceFeed}
+	first := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	second := first.Add(24 * time.Hour)
+	n := func(f float64) *float64 { return &f }
+
+	require.NoError(t, recorder.RunCompleted(ctx, updatechecker.RunSummary{
+		FinishedAt: first,
+		Updated: []updatechecker.SeriesUpdate{{Manga: solo, NewChapters: []domain.ChapterEntity{
+			{Number: n(121), URI: "nel/121"},
+			{Number: n(120), URI: "nel/120"},
+		}}},
+		Failed: []updatechecker.SeriesFailure{{Manga: feed, Err: errors.New("feed returned 500")}},
+	}))
+	require.NoError(t, recorder.RunCompleted(ctx, updatechecker.RunSummary{
+		FinishedAt: second,
+		Unchanged:  []updatechecker.SeriesUpdate{{Manga: solo}},
+		Skipped:    []updatechecker.SeriesFailure{{Manga: feed, Err: errors.New("provider unavailable")}},
+	}))
+
+	events, err := Read(dir, solo)
+	require.NoError(t, err)
+	assert.Equal(t, []Event{
+		{Time: first, Type: EventCheck, Outcome: OutcomeUpdated, NewChapters: 2},
+		{Time: first, Type: EventChapter, Chapter: n(120), URI: "nel/120"},
+		{Time: first, Type: EventChapter, Chapter: n(121), URI: "nel/121"},
+		{Time: second, Type: EventCheck, Outcome: OutcomeUnchanged},
+	}, events)
+
+	events, err = Read(dir, feed)
+	require.NoError(t, err)
+	require.Len(t, events, 2)
+	assert.Equal(t, OutcomeFailed, events[0].Outcome)
+	assert.Equal(t, "feed returned 500", events[0].Error)
+	assert.Equal(t, OutcomeSkipped, events[1].Outcome)
+	assert.Regexp(t, `^feed-scans\.example\.com-feed-[0-9a-f]{12}\.jsonl$`, filepath.Base(Path(dir, feed)))
+	assert.Equal(t, filepath.Join(dir, "mangadex-never-checked.jsonl"), Path(dir, domain.MangaEntity{Slug: "never-checked", Source: domain.MangaSourceMangaDex}))
+
+	query := domain.MangaEntity{Slug: "https://a.com/x?y", Source: domain.MangaSourceFeed}
+	dash := domain.MangaEntity{Slug: "https://a.com/x-y", Source: domain.MangaSourceFeed}
+	assert.NotEqual(t, Path(dir, query), Path(dir, dash), "slugs only differing by unsafe characters have their own file")
+
+	events, err = Read(dir, domain.MangaEntity{Slug: "never-checked", Source: domain.MangaSourceMangaDex})
+	assert.NoError(t, err)
+	assert.Empty(t, events)
+}
@@ -0,0 +1,222 @@
+// Package httpcache provides an http.RoundTripper caching GET responses on disk
+// and revalidating them with conditional requests (ETag and Last-Modified),
+// so unchanged pages are answered with an empty 304 instead of being downloaded again.
+package httpcache
+
+import (
+	"bufio"
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/http/httputil"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// FromCacheHeader is set on responses served from the cache after a successful revalidation
+const FromCacheHeader = "X-From-Cache"
+
+// Transport caches the responses of GET requests carrying an ETag or a Last-Modified header
+// in Dir, and sends the validators of the cached response with the next request for the same url.
+type Transport struct {
+	Next http.RoundTripper
+	Dir  string
+	// POSTQueries also caches the POST requests, keyed by their url and body,
+	// for APIs reading with POST requests such as GraphQL.
+	POSTQueries bool
+	// Logger receives the cache failures, which never fail a request, slog.Default() when nil
+	Logger *slog.Logger
+}
+
+type Option func(*Transport)
+
+// WithPOSTQueries caches the POST requests too, see Transport.POSTQueries
+func WithPOSTQueries() Option {
+	return func(t *Transport) {
+		t.POSTQueries = true
+	}
+}
+
+// WithLogger sets the logger of the transport, see Transport.Logger
+func WithLogger(logger *slog.Logger) Option {
+	return func(t *Transport) {
+		t.Logger = logger
+	}
+}
+
+// NewClient returns an http.Client whose responses are cached in dir,
+// the client is returned unchanged when dir is empty.
+func NewClient(client *http.Client, dir string, opts ...Option) *http.Client {
+	if dir == "" {
+		return client
+	}
+	cached := *client
+	cached.Transport = NewTransport(client.Transport, dir, opts...)
+	return &cached
+}
+
+// NewTransport returns a Transport caching the responses of next in dir
+func NewTransport(next http.RoundTripper, dir string, opts ...Option) *Transport {
+	t := &Transport{Next: next, Dir: dir}
+	for _, opt := range opts {
+		opt(t)
+	}
+	return t
+}
+
+func (t *Transport) RoundTrip(req *http.Request) (*http.Response, error) {
+	next := t.Next
+	if next == nil {
+		next = http.DefaultTransport
+	}
+	if !t.cached(req) || req.Header.Get("Range") != "" {
+		return next.RoundTrip(req)
+	}
+
+	path, req, err := t.path(req)
+	if err != nil {
+		return nil, err
+	}
+	cached, err := t.load(path, req)
+	if err != nil && !os.IsNotExist(err) {
+		t.logger().Debug("ignoring unreadable http cache entry", "url", req.URL.String(), "error", err)
+	}
+
+	if cached != nil {
+		req = req.Clone(req.Context())
+		if etag := cached.Header.Get("ETag"); etag != "" && req.Header.Get("If-None-Match") == "" {
+			req.Header.Set("If-None-Match", etag)
+		}
+		if lastModified := cached.Header.Get("Last-Modified"); lastModified != "" && req.Header.Get("If-Modified-Since") == "" {
+			req.Header.Set("If-Modified-Since", lastModified)
+		}
+	}
+
+	res, err := next.RoundTrip(req)
+	if err != nil {
+		if cached != nil {
+			_ = cached.Body.Close()
+		}
+		return nil, err
+	}
+
+	if res.StatusCode == http.StatusNotModified && cached != nil {
+		_ = res.Body.Close()
+		cached.Header.Set(FromCacheHeader, "1")
+		return cached, nil
+	}
+	if cached != nil {
+		_ = cached.Body.Close()
+	}
+
+	if !cacheable(res) {
+		return res, nil
+	}
+	if err := t.store(path, res); err != nil {
+		t.logger().Debug("failed to cache http response", "url", req.URL.String(), "error", err)
+	}
+	return res, nil
+}
+
+func (t *Transport) logger() *slog.Logger {
+	if t.Logger == nil {
+		return slog.Default()
+	}
+	return t.Logger
+}
+
+func cacheable(res *http.Response) bool {
+	if res.StatusCode != http.StatusOK {
+		return false
+	}
+	if strings.Contains(strings.ToLower(res.Header.Get("Cache-Control")), "no-store") {
+		return false
+	}
+	return res.Header.Get("ETag") != "" || res.Header.Get("Last-Modified") != ""
+}
+
+func (t *Transport) cached(req *http.Request) bool {
+	return req.Method == http.MethodGet || (t.POSTQueries && req.Method == http.MethodPost)
+}
+
+// path returns the cache file of the request, along with the request to send: the body
+// of a POST request is part of the key, so the request is cloned with a body read again.
+func (t *Transport) path(req *http.Request) (string, *http.Request, error) {
+	key := []byte(req.URL.String())
+	if req.Method == http.MethodPost && req.Body != nil {
+		body, err := io.ReadAll(req.Body)
+		_ = req.Body.Close()
+		if err != nil {
+			return "", nil, fmt.Errorf("failed to read request body: %w", err)
+		}
+		req = req.Clone(req.Context())
+		req.Body = io.NopCloser(bytes.NewReader(body))
+		req.GetBody = func() (io.ReadCloser, error) {
+			return io.NopCloser(bytes.NewReader(body)), nil
+		}
+		key = append(append(key, '\n'), body...)
+	}
+	sum := sha256.Sum256(key)
+	return filepath.Join(t.Dir, hex.EncodeToString(sum[:])), req, nil
+}
+
+func (t *Transport) load(path string, req *http.Request) (*http.Response, error) {
+	content, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	return http.ReadResponse(bufio.NewReader(bytes.NewReader(content)), req)
+}
+
+// store writes the response to the cache and replaces its body, which is consumed in the process
+func (t *Transport) store(path string, res *http.Response) error {
+	body, err := io.ReadAll(res.Body)
+	_ = res.Body.Close()
+	if err != nil {
+		// the caller still reads what was received, followed by the error
+		res.Body = io.NopCloser(io.MultiReader(bytes.NewReader(body), errorReader{err}))
+		return fmt.Errorf("failed to read response body: %w", err)
+	}
+	res.Body = io.NopCloser(bytes.NewReader(body))
+
+	stored := *res
+	stored.Body = io.NopCloser(bytes.NewReader(body))
+	stored.ContentLength = int64(len(body))
+	stored.TransferEncoding = nil
+	stored.Header = res.Header.Clone()
+	dump, err := httputil.DumpResponse(&stored, true)
+	if err != nil {
+		return fmt.Errorf("failed to serialize response: %w", err)
+	}
+
+	if err := os.MkdirAll(t.Dir, 0o755); err != nil {
+		return err
+	}
+	tmp, err := os.CreateTemp(t.Dir, ".tmp-*")
+	if err != nil {
+		return err
+	}
+	if _, err := tmp.Write(dump); err != nil {
+		_ = tmp.Close()
+		_ = os.Remove(tmp.Name())
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		_ = os.Remove(tmp.Name())
+		return err
+	}
+	return os.Rename(tmp.Name(), path)
+}
+
+type errorReader struct {
+	err error
+}
+
+func (r errorReader) Read([]byte) (int, error) {
+	return 0, r.err
+}
@@ -0,0 +1,165 @@
+package httpcache
+
+import (
+	"bytes"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type countingServer struct {
+	requests    int
+	notModified int
+}
+
+func newETagServer(t *testing.T, counts *countingServer, body *string) *httptest.Server {
+	t.Helper()
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		counts.requests++
+		etag := `"` + *body + `"`
+		if r.Header.Get("If-None-Match") == etag {
+			counts.notModified++
+			w.WriteHeader(http.StatusNotModified)
+			return
+		}
+		w.Header().Set("ETag", etag)
+		_, _ = io.WriteString(w, *body)
+	}))
+	t.Cleanup(server.Close)
+	return server
+}
+
+func get(t *testing.T, client *http.Client, url string) (string, *http.Response) {
+	t.Helper()
+	res, err := client.Get(url)
+	require.NoError(t, err)
+	defer res.Body.Close()
+	body, err := io.ReadAll(res.Body)
+	require.NoError(t, err)
+	return string(body), res
+}
+
+func TestTransport_RevalidatesWithETag(t *testing.T) {
+	counts := &countingServer{}
+	body := "chapter 1"
+	server := newETagServer(t, counts, &body)
+	client := NewClient(&http.Client{}, t.TempDir())
+
+	content, res := get(t, client, server.URL+"/feed")
+	assert.Equal(t, "chapter 1", content)
+	assert.Empty(t, res.Header.Get(FromCacheHeader))
+
+	content, res = get(t, client, server.URL+"/feed")
+	assert.Equal(t, "chapter 1", content, "the cached body is served on 304")
+	assert.Equal(t, http.StatusOK, res.StatusCode)
+	assert.Equal(t, "1", res.Header.Get(FromCacheHeader))
+	assert.Equal(t, 1, counts.notModified)
+
+	body = "chapter 2"
+	content, _ = get(t, client, server.URL+"/feed")
+	assert.Equal(t, "chapter 2", content, "changed content is downloaded again")
+	assert.Equal(t, 3, counts.requests)
+}
+
+func TestTransport_RevalidatesWithLastModified(t *testing.T) {
+	const lastModified = "Mon, 01 Jan 2024 00:00:00 GMT"
+	var conditional int
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("If-Modified-Since") == lastModified {
+			conditional++
+			w.WriteHeader(http.StatusNotModified)
+			return
+		}
+		w.Header().Set("Last-Modified", lastModified)
+		_, _ = io.WriteString(w, "page")
+	}))
+	defer server.Close()
+
+	dir := t.TempDir()
+	content, _ := get(t, NewClient(&http.Client{}, dir), server.URL)
+	assert.Equal(t, "page", content)
+
+	// a new client sharing the directory, as in the next run
+	content, _ = get(t, NewClient(&http.Client{}, dir), server.URL)
+	assert.Equal(t, "page", content)
+	assert.Equal(t, 1, conditional)
+}
+
+func TestTransport_SkipsUncacheableResponses(t *testing.T) {
+	var conditional int
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("If-None-Match") != "" {
+			conditional++
+		}
+		if r.URL.Path == "/no-store" {
+			w.Header().Set("ETag", `"x"`)
+			w.Header().Set("Cache-Control", "no-store")
+		}
+		_, _ = io.WriteString(w, "body")
+	}))
+	defer server.Close()
+
+	client := NewClient(&http.Client{}, t.TempDir())
+	for i := 0; i < 2; i++ {
+		get(t, client, server.URL+"/no-validators")
+		get(t, client, server.URL+"/no-store")
+	}
+	assert.Zero(t, conditional)
+}
+
+func TestNewClient_WithoutDir(t *testing.T) {
+	client := &http.Client{}
+	assert.Same(t, client, NewClient(client, ""))
+}
+
+func TestTransport_CachesPOSTQueriesWhenEnabled(t *testing.T) {
+	counts := &countingServer{}
+	body := "solo leveling"
+	server := newETagServer(t, counts, &body)
+
+	post := func(client *http.Client, query string) *http.Response {
+		res, err := client.Post(server.URL+"/graphql", "application/json", strings.NewReader(query))
+		require.NoError(t, err)
+		_, _ = io.Copy(io.Discard, res.Body)
+		require.NoError(t, res.Body.Close())
+		return res
+	}
+
+	client := NewClient(&http.Client{}, t.TempDir())
+	post(client, `{"query":"a"}`)
+	post(client, `{"query":"a"}`)
+	assert.Zero(t, counts.notModified, "POST requests are not cached by default")
+
+	client = NewClient(&http.Client{}, t.TempDir(), WithPOSTQueries())
+	post(client, `{"query":"a"}`)
+	post(client, `{"query":"b"}`)
+	assert.Zero(t, counts.notModified, "queries with another body are cached separately")
+	res := post(client, `{"query":"a"}`)
+	assert.Equal(t, 1, counts.notModified)
+	assert.Equal(t, "1", res.Header.Get(FromCacheHeader))
+}
+
+func TestTransport_LogsCacheFailuresToItsLogger(t *testing.T) {
+	counts := &countingServer{}
+	body := "chapter 1"
+	server := newETagServer(t, counts, &body)
+	// a file where the cache folder is expected
+	dir := filepath.Join(t.TempDir(), "cache")
+	require.NoError(t, os.WriteFile(dir, nil, 0644))
+
+	var logs bytes.Buffer
+	logger := slog.New(slog.NewTextHandler(&logs, &slog.HandlerOptions{Level: slog.LevelDebug}))
+	client := NewClient(&http.Client{}, dir, WithLogger(logger))
+
+	content, _ := get(t, client, server.URL+"/feed")
+	assert.Equal(t, "chapter 1", content, "cache failures do not fail the request")
+	assert.Contains(t, logs.String(), "failed to cache http response")
+}
@@ -0,0 +1,229 @@
+package importer
+
+import (
+	"bufio"
+	"encoding/json"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"regexp"
+	"strings"
+)
+
+// Format is the kind of tracking list export being imported
+type Format string
+
+const (
+	FormatMangaDex Format = "mangadex"
+	FormatMAL      Format = "mal"
+	FormatAniList  Format = "anilist"
+	FormatURLs     Format = "urls"
+)
+
+// Formats lists the supported export formats
+var Formats = []Format{FormatMangaDex, FormatMAL, FormatAniList, FormatURLs}
+
+// Entry is a single series of an imported list, identified by a URL when the
+// export contains one and by its titles otherwise.
+type Entry struct {
+	Title string
+	// AltTitles are other titles of the series, tried when Title has no match
+	AltTitles []string
+	URL       string
+	// Skip is why the entry is not imported, such as a dropped series, empty when it is imported
+	Skip string
+}
+
+// Parse reads the entries of an export in the given format
+func Parse(format Format, r io.Reader) ([]Entry, error) {
+	switch format {
+	case FormatMangaDex:
+		return parseMangaDex(r)
+	case FormatMAL:
+		return parseMAL(r)
+	case FormatAniList:
+		return parseAniList(r)
+	case FormatURLs:
+		return parseURLs(r)
+	default:
+		return nil, fmt.Errorf("unsupported import format %q", format)
+	}
+}
+
+// DetectFormat guesses the format of an export from its file name
+func DetectFormat(filename string) (Format, bool) {
+	lower := strings.ToLower(filename)
+	switch {
+	case strings.HasSuffix(lower, ".xml"):
+		return FormatMAL, true
+	case strings.HasSuffix(lower, ".txt"):
+		return FormatURLs, true
+	case strings.Contains(lower, "anilist"):
+		return FormatAniList, true
+	case strings.Contains(lower, "mangadex"):
+		return FormatMangaDex, true
+	}
+	return "", false
+}
+
+var mangaDexIDPattern = regexp.MustCompile(`^[0-9a-f]{8}-[0-9a-f]{4}-[0-9a-f]{4}-[0-9a-f]{4}-[0-9a-f]{12}$`)
+
+type mangaDexManga struct {
+	ID         string `json:"id"`
+	Attributes struct {
+		Title     map[string]string   `json:"title"`
+		AltTitles []map[string]string `json:"altTitles"`
+	} `json:"attributes"`
+}
+
+// parseMangaDex reads a MangaDex follows export, either the response of the
+// /user/follows/manga endpoint or a plain JSON array of manga ids.
+func parseMangaDex(r io.Reader) ([]Entry, error) {
+	raw, err := io.ReadAll(r)
+	if err != nil {
+		return nil, err
+	}
+
+	var ids []string
+	if err := json.Unmarshal(raw, &ids); err == nil {
+		var entries []Entry
+		for _, id := range ids {
+			if !mangaDexIDPattern.MatchString(id) {
+				return nil, fmt.Errorf("invalid MangaDex manga id %q", id)
+			}
+			entries = append(entries, Entry{URL: mangaDexURL(id)})
+		}
+		return entries, nil
+	}
+
+	var response struct {
+		Data []mangaDexManga `json:"data"`
+	}
+	if err := json.Unmarshal(raw, &response); err != nil {
+		return nil, fmt.Errorf("failed to parse MangaDex follows export: %w", err)
+	}
+	var entries []Entry
+	for _, manga := range response.Data {
+		entry := Entry{URL: mangaDexURL(manga.ID), Title: localizedTitle(manga.Attributes.Title)}
+		for _, alt := range manga.Attributes.AltTitles {
+			if title := localizedTitle(alt); title != "" {
+				entry.AltTitles = append(entry.AltTitles, title)
+			}
+		}
+		entries = append(entries, entry)
+	}
+	return entries, nil
+}
+
+func mangaDexURL(id string) string {
+	return "https://mangadex.org/title/" + id
+}
+
+// localizedTitle prefers the english title of a MangaDex localized string
+func localizedTitle(titles map[string]string) string {
+	if title, ok := titles["en"]; ok {
+		return title
+	}
+	for _, lang := range []string{"ja-ro", "ko-ro", "zh-ro"} {
+		if title, ok := titles[lang]; ok {
+			return title
+		}
+	}
+	for _, title := range titles {
+		return title
+	}
+	return ""
+}
+
+type malExport struct {
+	Manga []struct {
+		Title  string `xml:"manga_title"`
+		Status string `xml:"my_status"`
+	} `xml:"manga"`
+}
+
+// parseMAL reads a MyAnimeList XML export, plan to read and dropped entries are skipped
+func parseMAL(r io.Reader) ([]Entry, error) {
+	var export malExport
+	if err := xml.NewDecoder(r).Decode(&export); err != nil {
+		return nil, fmt.Errorf("failed to parse MyAnimeList export: %w", err)
+	}
+	var entries []Entry
+	for _, manga := range export.Manga {
+		entry := Entry{Title: strings.TrimSpace(manga.Title)}
+		if manga.Status == "Dropped" || manga.Status == "Plan to Read" {
+			entry.Skip = fmt.Sprintf("%s on MyAnimeList", strings.ToLower(manga.Status))
+		}
+		entries = append(entries, entry)
+	}
+	return entries, nil
+}
+
+type aniListCollection struct {
+	Lists []struct {
+		Entries []struct {
+			Status string `json:"status"`
+			Media  struct {
+				Title struct {
+					English string `json:"english"`
+					Romaji  string `json:"romaji"`
+					Native  string `json:"native"`
+				} `json:"title"`
+				Synonyms []string `json:"synonyms"`
+			} `json:"media"`
+		} `json:"entries"`
+	} `json:"lists"`
+}
+
+// parseAniList reads an AniList JSON export, the MediaListCollection of the
+// GraphQL API with or without its data envelope. Dropped and planning entries are skipped.
+func parseAniList(r io.Reader) ([]Entry, error) {
+	var export struct {
+		aniListCollection
+		Data struct {
+			MediaListCollection aniListCollection `json:"MediaListCollection"`
+		} `json:"data"`
+	}
+	if err := json.NewDecoder(r).Decode(&export); err != nil {
+		return nil, fmt.Errorf("failed to parse AniList export: %w", err)
+	}
+
+	lists := export.Lists
+	if len(lists) == 0 {
+		lists = export.Data.MediaListCollection.Lists
+	}
+	var entries []Entry
+	for _, list := range lists {
+		for _, e := range list.Entries {
+			var titles []string
+			for _, title := range append([]string{e.Media.Title.English, e.Media.Title.Romaji, e.Media.Title.Native}, e.Media.Synonyms...) {
+				if title != "" {
+					titles = append(titles, title)
+				}
+			}
+			if len(titles) == 0 {
+				continue
+			}
+			entry := Entry{Title: titles[0], AltTitles: titles[1:]}
+			if e.Status == "DROPPED" || e.Status == "PLANNING" {
+				entry.Skip = fmt.Sprintf("%s on AniList", strings.ToLower(e.Status))
+			}
+			entries = append(entries, entry)
+		}
+	}
+	return entries, nil
+}
+
+// parseURLs reads one URL per line, blank lines and lines starting with # are ignored
+func parseURLs(r io.Reader) ([]Entry, error) {
+	var entries []Entry
+	scanner := bufio.NewScanner(r)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		entries = append(entries, Entry{URL: line})
+	}
+	return entries, scanner.Err()
+}
@@ -0,0 +1,138 @@
+// Package importer resolves the entries of tracking lists exported from other
+// services (MangaDex follows, MyAnimeList, AniList or plain URL lists) to series
+// of the configured providers.
+package importer
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"unicode"
+
+	"github.com/ivan-penchev/manga-updates/internal/domain"
+)
+
+// Status is the outcome of resolving an entry
+type Status string
+
+const (
+	StatusMatched   Status = "matched"
+	StatusAmbiguous Status = "ambiguous"
+	StatusUnmatched Status = "unmatched"
+	// StatusSkipped entries are not resolved, see Entry.Skip
+	StatusSkipped Status = "skipped"
+	// StatusFailed entries matched a series that could not be imported
+	StatusFailed Status = "failed"
+)
+
+// Result is the resolution of a single entry. A matched entry has a URL and the
+// provider handling it, an ambiguous one lists the candidates found by search.
+type Result struct {
+	Entry      Entry
+	Status     Status
+	Provider   domain.Provider
+	URL        string
+	Candidates []domain.SearchResult
+	// Reason explains why an entry is ambiguous, unmatched, skipped or failed
+	Reason string
+}
+
+// Resolver matches entries to providers, by URL first and by searching the
+// titles of the entry in the search providers otherwise.
+type Resolver struct {
+	router domain.ProviderRouter
+	search []domain.Provider
+}
+
+// NewResolver returns a resolver searching titles in the given providers, in order
+func NewResolver(router domain.ProviderRouter, search ...domain.Provider) *Resolver {
+	return &Resolver{router: router, search: search}
+}
+
+// Resolve resolves every entry, it only fails when the context is done.
+func (r *Resolver) Resolve(ctx context.Context, entries []Entry) ([]Result, error) {
+	results := make([]Result, 0, len(entries))
+	for _, entry := range entries {
+		if err := ctx.Err(); err != nil {
+			return results, err
+		}
+		results = append(results, r.resolve(ctx, entry))
+	}
+	return results, nil
+}
+
+func (r *Resolver) resolve(ctx context.Context, entry Entry) Result {
+	if entry.Skip != "" {
+		return Result{Entry: entry, Status: StatusSkipped, Reason: entry.Skip}
+	}
+	result := Result{Entry: entry, Status: StatusUnmatched}
+
+	if entry.URL != "" {
+		p, err := r.router.GetProviderForURL(entry.URL)
+		if err == nil {
+			result.Status = StatusMatched
+			result.Provider = p
+			result.URL = entry.URL
+			return result
+		}
+		result.Reason = err.Error()
+	}
+
+	titles := append([]string{entry.Title}, entry.AltTitles...)
+	var searchErrors []string
+	for _, p := range r.search {
+		for _, title := range titles {
+			if title == "" {
+				continue
+			}
+			found, _, err := p.Search(ctx, title, 0)
+			if err != nil {
+				searchErrors = append(searchErrors, fmt.Sprintf("%s: %v", p.Kind(), err))
+				break
+			}
+
+			var exact []domain.SearchResult
+			for _, candidate := range found {
+				if normalizeTitle(candidate.Manga.Name) == normalizeTitle(title) {
+					exact = append(exact, candidate)
+				}
+			}
+			switch {
+			case len(exact) == 1:
+				result.Status = StatusMatched
+				result.Provider = p
+				result.URL = exact[0].URL
+				result.Candidates = nil
+				result.Reason = ""
+				return result
+			case len(exact) > 1:
+				result.Status = StatusAmbiguous
+				result.Candidates = exact
+				result.Reason = fmt.Sprintf("%d series of %s are titled %q", len(exact), p.Kind(), title)
+			case len(found) > 0 && result.Status != StatusAmbiguous:
+				result.Status = StatusAmbiguous
+				result.Candidates = found
+				result.Reason = fmt.Sprintf("no exact title match on %s", p.Kind())
+			}
+		}
+	}
+
+	if result.Status == StatusUnmatched && len(searchErrors) > 0 {
+		result.Reason = "search failed: " + strings.Join(searchErrors, "; ")
+	} else if result.Status == StatusUnmatched && result.Reason == "" {
+		result.Reason = "no search result"
+	}
+	return result
+}
+
+// normalizeTitle folds the case, punctuation and spacing of a title so that
+// "Solo Leveling!" and "solo leveling" compare equal.
+func normalizeTitle(title string) string {
+	var b strings.Builder
+	for _, r := range strings.ToLower(title) {
+		if unicode.IsLetter(r) || unicode.IsDigit(r) {
+			b.WriteRune(r)
+		}
+	}
+	return b.String()
+}
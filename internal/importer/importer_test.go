@@ -0,0 +1,143 @@
+package importer
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/ivan-penchev/manga-updates/internal/domain"
+	"github.com/ivan-penchev/manga-updates/internal/mocks"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParse(t *testing.T) {
+	tests := []struct {
+		name     string
+		format   Format
+		input    string
+		expected []Entry
+	}{
+		{
+			name:   "mangadex follows response",
+			format: FormatMangaDex,
+			input: `{"result":"ok","data":[{"id":"32d76d19-8a05-4db0-9fc2-e0b0648fe9d0","attributes":{
+				"title":{"en":"Solo Leveling"},"altTitles":[{"ko-ro":"Na Honjaman Level Up"}]}}]}`,
+			expected: []Entry{{
+				Title:     "Solo Leveling",
+				AltTitles: []string{"Na Honjaman Level Up"},
+				URL:       "https://mangadex.org/title/32d76d19-8a05-4db0-9fc2-e0b0648fe9d0",
+			}},
+		},
+		{
+			name:     "mangadex id list",
+			format:   FormatMangaDex,
+			input:    `["32d76d19-8a05-4db0-9fc2-e0b0648fe9d0"]`,
+			expected: []Entry{{URL: "https://mangadex.org/title/32d76d19-8a05-4db0-9fc2-e0b0648fe9d0"}},
+		},
+		{
+			name:   "myanimelist",
+			format: FormatMAL,
+			input: `<?xml version="1.0" encoding="UTF-8" ?>
+<myanimelist>
+	<myinfo><user_export_type>2</user_export_type></myinfo>
+	<manga><manga_mangadb_id>121496</manga_mangadb_id><manga_title><![CDATA[Solo Leveling]]></manga_title><my_status>Reading</my_status></manga>
+	<manga><manga_mangadb_id>2</manga_mangadb_id><manga_title><![CDATA[Berserk]]></manga_title><my_status>Plan to Read</my_status></manga>
+</myanimelist>`,
+			expected: []Entry{{Title: "Solo Leveling"}, {Title: "Berserk", Skip: "plan to read on MyAnimeList"}},
+		},
+		{
+			name:   "anilist",
+			format: FormatAniList,
+			input: `{"data":{"MediaListCollection":{"lists":[{"entries":[
+				{"status":"CURRENT","media":{"title":{"english":"Solo Leveling","romaji":"Na Honjaman Level Up","native":null},"synonyms":["I Level Up Alone"]}},
+				{"status":"DROPPED","media":{"title":{"romaji":"Berserk"}}}
+			]}]}}}`,
+			expected: []Entry{
+				{Title: "Solo Leveling", AltTitles: []string{"Na Honjaman Level Up", "I Level Up Alone"}},
+				{Title: "Berserk", AltTitles: []string{}, Skip: "dropped on AniList"},
+			},
+		},
+		{
+			name:   "urls",
+			format: FormatURLs,
+			input:  "# reading\nhttps://manganel.me/manga/solo-leveling\n\n  https://scans.example.com/feed  \n",
+			expected: []Entry{
+				{URL: "https://manganel.me/manga/solo-leveling"},
+				{URL: "https://scans.example.com/feed"},
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			entries, err := Parse(tt.format, strings.NewReader(tt.input))
+			require.NoError(t, err)
+			assert.Equal(t, tt.expected, entries)
+		})
+	}
+
+	_, err := Parse("goodreads", strings.NewReader(""))
+	assert.Error(t, err)
+}
+
+func TestResolver_Resolve(t *testing.T) {
+	router := mocks.NewMockProviderRouter(t)
+	nel := mocks.NewMockProvider(t)
+	dex := mocks.NewMockProvider(t)
+	dex.EXPECT().Kind().Return(domain.MangaSourceMangaDex).Maybe()
+
+	router.EXPECT().GetProviderForURL("https://manganel.me/manga/solo-leveling").Return(nel, nil)
+	router.EXPECT().GetProviderForURL("https://unknown.example.com/series").Return(nil, errors.New("no provider"))
+
+	result := func(name, url string) domain.SearchResult {
+		return domain.SearchResult{Manga: domain.MangaEntity{Name: name}, URL: url}
+	}
+	dex.EXPECT().Search(mock.Anything, "Omniscient Reader", 0).Return([]domain.SearchResult{
+		result("Omniscient Reader's Viewpoint", "dex/orv"),
+		result("Omniscient Reader!", "dex/or"),
+	}, 2, nil)
+	dex.EXPECT().Search(mock.Anything, "Tower of God", 0).Return([]domain.SearchResult{
+		result("Tower of God", "dex/tog-1"),
+		result("tower of god", "dex/tog-2"),
+	}, 2, nil)
+	dex.EXPECT().Search(mock.Anything, "Berserk", 0).Return([]domain.SearchResult{result("Berserk of Gluttony", "dex/bog")}, 1, nil)
+	dex.EXPECT().Search(mock.Anything, "Unknown", 0).Return(nil, 0, nil)
+
+	resolver := NewResolver(router, dex)
+	results, err := resolver.Resolve(context.Background(), []Entry{
+		{URL: "https://manganel.me/manga/solo-leveling"},
+		{URL: "https://unknown.example.com/series", Title: "Omniscient Reader"},
+		{Title: "Tower of God"},
+		{Title: "Berserk"},
+		{Title: "Unknown"},
+		{Title: "Vagabond", Skip: "dropped on AniList"},
+	})
+	require.NoError(t, err)
+	require.Len(t, results, 6)
+
+	assert.Equal(t, StatusMatched, results[0].Status, "urls are resolved by the router")
+	assert.Equal(t, nel, results[0].Provider)
+
+	assert.Equal(t, StatusMatched, results[1].Status, "unsupported urls fall back to title search")
+	assert.Equal(t, "dex/or", results[1].URL)
+
+	assert.Equal(t, StatusAmbiguous, results[2].Status)
+	assert.Len(t, results[2].Candidates, 2)
+
+	assert.Equal(t, StatusAmbiguous, results[3].Status, "inexact matches need a manual choice")
+	assert.Equal(t, StatusUnmatched, results[4].Status)
+	assert.Equal(t, StatusSkipped, results[5].Status, "skipped entries are reported without being searched")
+	assert.Equal(t, "dropped on AniList", results[5].Reason)
+}
+
+func TestResolver_ResolveCancelled(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	results, err := NewResolver(mocks.NewMockProviderRouter(t)).Resolve(ctx, []Entry{{Title: "Solo Leveling"}})
+	assert.ErrorIs(t, err, context.Canceled)
+	assert.Empty(t, results)
+}
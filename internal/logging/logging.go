@@ -0,0 +1,84 @@
+// Package logging builds the slog logger of the binaries from the command line flags.
+package logging
+
+import (
+	"fmt"
+	"io"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// Format is the encoding of the log records
+type Format string
+
+const (
+	// FormatText writes key=value records, easy to read in a terminal
+	FormatText Format = "text"
+	// FormatJSON writes one JSON object per record, for log collectors
+	FormatJSON Format = "json"
+)
+
+type Config struct {
+	// Level is debug, info, warn or error
+	Level string
+	// Format is text or json
+	Format Format
+	// File receives the logs instead of the default output when set, it is appended to
+	File string
+}
+
+// ParseLevel parses debug, info, warn or error, case insensitively
+func ParseLevel(level string) (slog.Level, error) {
+	var l slog.Level
+	if err := l.UnmarshalText([]byte(strings.TrimSpace(level))); err != nil {
+		return l, fmt.Errorf("unknown log level %q, expected debug, info, warn or error", level)
+	}
+	return l, nil
+}
+
+// ParseFormat parses text or json
+func ParseFormat(format string) (Format, error) {
+	switch f := Format(strings.ToLower(strings.TrimSpace(format))); f {
+	case FormatText, FormatJSON:
+		return f, nil
+	default:
+		return "", fmt.Errorf("unknown log format %q, expected text or json", format)
+	}
+}
+
+// New returns a logger writing to the configured file, or to output when no file
+// is configured. The returned closer releases the file.
+func New(cfg Config, output io.Writer) (*slog.Logger, io.Closer, error) {
+	level, err := ParseLevel(cfg.Level)
+	if err != nil {
+		return nil, nil, err
+	}
+	format, err := ParseFormat(string(cfg.Format))
+	if err != nil {
+		return nil, nil, err
+	}
+
+	var closer io.Closer = nopCloser{}
+	if cfg.File != "" {
+		if err := os.MkdirAll(filepath.Dir(cfg.File), 0755); err != nil {
+			return nil, nil, fmt.Errorf("failed to create log directory: %w", err)
+		}
+		file, err := os.OpenFile(cfg.File, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0644)
+		if err != nil {
+			return nil, nil, fmt.Errorf("failed to open log file: %w", err)
+		}
+		output, closer = file, file
+	}
+
+	options := &slog.HandlerOptions{Level: level}
+	if format == FormatJSON {
+		return slog.New(slog.NewJSONHandler(output, options)), closer, nil
+	}
+	return slog.New(slog.NewTextHandler(output, options)), closer, nil
+}
+
+type nopCloser struct{}
+
+func (nopCloser) Close() error { return nil }
@@ -0,0 +1,65 @@
+package logging
+
+import (
+	"bytes"
+	"encoding/json"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseLevel(t *testing.T) {
+	for input, expected := range map[string]slog.Level{
+		"debug": slog.LevelDebug,
+		"INFO":  slog.LevelInfo,
+		"warn":  slog.LevelWarn,
+		"error": slog.LevelError,
+	} {
+		level, err := ParseLevel(input)
+		require.NoError(t, err, input)
+		assert.Equal(t, expected, level, input)
+	}
+
+	_, err := ParseLevel("verbose")
+	assert.Error(t, err)
+}
+
+func TestNew_TextAboveLevel(t *testing.T) {
+	var output bytes.Buffer
+	logger, closer, err := New(Config{Level: "warn", Format: FormatText}, &output)
+	require.NoError(t, err)
+	defer closer.Close()
+
+	logger.Info("hidden")
+	logger.Warn("shown", "series", "Dex")
+
+	assert.NotContains(t, output.String(), "hidden")
+	assert.Contains(t, output.String(), `level=WARN msg=shown series=Dex`)
+}
+
+func TestNew_JSONToFile(t *testing.T) {
+	file := filepath.Join(t.TempDir(), "logs", "manga-updates.log")
+	var output bytes.Buffer
+	logger, closer, err := New(Config{Level: "info", Format: FormatJSON, File: file}, &output)
+	require.NoError(t, err)
+
+	logger.Info("checked", "series", "Dex")
+	require.NoError(t, closer.Close())
+
+	assert.Empty(t, output.String(), "logs go to the file only")
+	content, err := os.ReadFile(file)
+	require.NoError(t, err)
+	var record map[string]any
+	require.NoError(t, json.Unmarshal(content, &record))
+	assert.Equal(t, "checked", record["msg"])
+	assert.Equal(t, "Dex", record["series"])
+}
+
+func TestNew_RejectsUnknownFormat(t *testing.T) {
+	_, _, err := New(Config{Level: "info", Format: "xml"}, &bytes.Buffer{})
+	assert.Error(t, err)
+}
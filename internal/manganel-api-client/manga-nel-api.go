@@ -6,34 +6,121 @@ import (
 	"fmt"
 	"log/slog"
 	"net/http"
+	"net/url"
 	"reflect"
 	"sort"
+	"strings"
 	"time"
 
 	"github.com/ivan-penchev/manga-updates/internal/domain"
+	"github.com/ivan-penchev/manga-updates/internal/httpcache"
+	"github.com/ivan-penchev/manga-updates/internal/ratelimit"
 	"github.com/machinebox/graphql"
 )
 
+// TokenSource provides the access token sent in the X-Mhub-Access header
+type TokenSource interface {
+	// Token returns the current token, acquiring one if needed
+	Token(ctx context.Context) (string, error)
+	// Refresh discards the current token and acquires a new one,
+	// it is called when the API rejects the current token.
+	Refresh(ctx context.Context) (string, error)
+}
+
+type staticToken string
+
+func (t staticToken) Token(ctx context.Context) (string, error) {
+	return string(t), nil
+}
+
+func (t staticToken) Refresh(ctx context.Context) (string, error) {
+	return "", errors.New("static manganel token cannot be refreshed")
+}
+
+// StaticToken returns a TokenSource always providing the same token
+func StaticToken(token string) TokenSource {
+	return staticToken(token)
+}
+
+// UserAgent is sent with every request, manganel rejects requests from unknown clients
+const UserAgent = "Mozilla/5.0 (Linux; Android 6.0; Nexus 5 Build/MRA58N) AppleWebKit/537.36 (KHTML, like Gecko) Chrome/138.0.0.0 Mobile Safari/537.36"
+
 type MangaNelAPIClient struct {
-	addr   string
-	apiKey string
-	client *graphql.Client
+	addr    string
+	tokens  TokenSource
+	client  *graphql.Client
+	limiter *ratelimit.Limiter
+	// retryBaseDelay and retryMaxDelay bound the backoff between attempts of getMangaSeries
+	retryBaseDelay time.Duration
+	retryMaxDelay  time.Duration
+	// attemptTimeout bounds every request sent to the API, the waits for throttled requests excluded
+	attemptTimeout time.Duration
+	// cacheDir caches the responses on disk and revalidates them, no caching when empty
+	cacheDir string
+	logger   *slog.Logger
 }
 
-func NewMangaNelAPIClient(addr string, apiKey string) *MangaNelAPIClient {
+type Option func(*MangaNelAPIClient)
+
+// WithRateLimiter limits the rate of requests sent to the API,
+// throttled (429) responses are retried after their Retry-After delay.
+func WithRateLimiter(limiter *ratelimit.Limiter) Option {
+	return func(m *MangaNelAPIClient) {
+		m.limiter = limiter
+	}
+}
+
+// WithHTTPCacheDir caches the responses of the API in dir and revalidates them with
+// conditional requests, so unchanged series are not downloaded again.
+func WithHTTPCacheDir(dir string) Option {
+	return func(m *MangaNelAPIClient) {
+		m.cacheDir = dir
+	}
+}
+
+// WithLogger sets the logger of the client, slog.Default() by default
+func WithLogger(logger *slog.Logger) Option {
+	return func(m *MangaNelAPIClient) {
+		m.logger = logger
+	}
+}
+
+func NewMangaNelAPIClient(addr string, tokens TokenSource, opts ...Option) *MangaNelAPIClient {
+	m := &MangaNelAPIClient{
+		addr:           addr,
+		tokens:         tokens,
+		retryBaseDelay: time.Second,
+		retryMaxDelay:  30 * time.Second,
+		attemptTimeout: 10 * time.Second,
+		logger:         slog.Default(),
+	}
+	for _, opt := range opts {
+		opt(m)
+	}
+
+	next := http.DefaultTransport
+	if m.cacheDir != "" {
+		// the queries are POST requests
+		next = httpcache.NewTransport(next, m.cacheDir, httpcache.WithPOSTQueries(), httpcache.WithLogger(m.logger))
+	}
+
+	// no client timeout, it would include the waits of the throttled requests
 	client := &http.Client{
-		Timeout: time.Second * 10,
+		Transport: authStatusTransport{next: &ratelimit.Transport{
+			Next:           next,
+			Limiter:        m.limiter,
+			MaxRetries:     3,
+			BaseDelay:      m.retryBaseDelay,
+			MaxDelay:       m.retryMaxDelay,
+			AttemptTimeout: m.attemptTimeout,
+		}},
 	}
 
 	graphqlClientWithOptions := graphql.WithHTTPClient(client)
-	graphqlClient := graphql.NewClient(addr, graphqlClientWithOptions)
-	//graphqlClient.Log = func(s string) { slog.Error(s) }
+	m.client = graphql.NewClient(addr, graphqlClientWithOptions)
+	//m.client.Log = func(s string) { slog.Error(s) }
 
-	return &MangaNelAPIClient{
-		addr:   addr,
-		client: graphqlClient,
-		apiKey: apiKey,
-	}
+	return m
 }
 
 func (m *MangaNelAPIClient) GetMangaSeriesFull(ctx context.Context, slug string) (*domain.MangaEntity, error) {
@@ -48,18 +135,19 @@ func (m *MangaNelAPIClient) getMangaSeries(ctx context.Context, slug string, sho
 	const maxAttempts = 3
 	var graphqlResponse any
 	var err error
-	graphqlRequest := graphql.NewRequest(getQueryForSlug(slug, shouldIncludeChapters))
-	graphqlRequest.Header.Add("Origin", "https://manganel.me")
-	graphqlRequest.Header.Add("Referer", "https://manganel.me/")
-	graphqlRequest.Header.Add("X-Mhub-Access", m.apiKey)
-	graphqlRequest.Header.Add("user-agent", "Mozilla/5.0 (Linux; Android 6.0; Nexus 5 Build/MRA58N) AppleWebKit/537.36 (KHTML, like Gecko) Chrome/138.0.0.0 Mobile Safari/537.36")
-	graphqlRequest.Header.Add("Sec-Ch-Ua", `"Not)A;Brand";v="8", "Chromium";v="138", "Google Chrome";v="138""`)
+	query := getQueryForSlug(slug, shouldIncludeChapters)
 
+	// throttled responses are retried by the transport, only the requests that got no response are retried here
 	for attempt := 1; attempt <= maxAttempts; attempt++ {
-		err = m.client.Run(ctx, graphqlRequest, &graphqlResponse)
-		if err == nil {
+		err = m.run(ctx, query, &graphqlResponse)
+		if err == nil || !isTransportError(err) || attempt == maxAttempts || ctx.Err() != nil {
 			break
 		}
+		delay := ratelimit.Backoff(attempt, m.retryBaseDelay, m.retryMaxDelay)
+		m.logger.Debug("manganel request failed, retrying", "slug", slug, "attempt", attempt, "delay", delay, "error", err)
+		if sleepErr := ratelimit.Sleep(ctx, delay); sleepErr != nil {
+			return nil, errors.Join(err, sleepErr)
+		}
 	}
 
 	if err != nil {
@@ -102,13 +190,13 @@ func (m *MangaNelAPIClient) getMangaSeries(ctx context.Context, slug string, sho
 
 		number, ok := ss["number"].(float64)
 		if !ok {
-			slog.Error("cant find chapter number of type float64", "value", ss)
+			m.logger.Error("cant find chapter number of type float64", "value", ss)
 		}
 		chapterUpdateTime, ok := ss["date"].(string)
 		timeUpdate, _ := time.Parse(time.RFC3339, chapterUpdateTime)
 
 		if !ok {
-			slog.Error("cant find chapter slug of type string", "value", ss)
+			m.logger.Error("cant find chapter slug of type string", "value", ss)
 		}
 		chapter := domain.ChapterEntity{
 			Number: &number,
@@ -186,18 +274,85 @@ func getQueryForSlug(slug string, includeChapters bool) string {
 }
 
 func (c *MangaNelAPIClient) Search(ctx context.Context, query string, offset int) (*SearchResponse, error) {
-	req := graphql.NewRequest(buildSearchQuery(query, offset))
+	var res SearchResponse
+	if err := c.run(ctx, buildSearchQuery(query, offset), &res); err != nil {
+		return nil, err
+	}
+	return &res, nil
+}
+
+// run executes a query, refreshing the access token and retrying once
+// if the API rejects the current one.
+func (c *MangaNelAPIClient) run(ctx context.Context, query string, response any) error {
+	token, err := c.tokens.Token(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to get manganel access token: %w", err)
+	}
+
+	err = c.client.Run(ctx, newRequest(query, token), response)
+	if err == nil || !isAuthError(err) {
+		return err
+	}
+
+	c.logger.Info("manganel access token was rejected, refreshing it", "error", err)
+	token, refreshErr := c.tokens.Refresh(ctx)
+	if refreshErr != nil {
+		return errors.Join(err, fmt.Errorf("failed to refresh manganel access token: %w", refreshErr))
+	}
+
+	return c.client.Run(ctx, newRequest(query, token), response)
+}
+
+func newRequest(query string, token string) *graphql.Request {
+	req := graphql.NewRequest(query)
 	req.Header.Add("Origin", "https://manganel.me")
 	req.Header.Add("Referer", "https://manganel.me/")
-	req.Header.Add("X-Mhub-Access", c.apiKey)
-	req.Header.Add("user-agent", "Mozilla/5.0 (Linux; Android 6.0; Nexus 5 Build/MRA58N) AppleWebKit/537.36 (KHTML, like Gecko) Chrome/138.0.0.0 Mobile Safari/537.36")
+	req.Header.Add("X-Mhub-Access", token)
+	req.Header.Add("user-agent", UserAgent)
 	req.Header.Add("Sec-Ch-Ua", `"Not)A;Brand";v="8", "Chromium";v="138", "Google Chrome";v="138""`)
+	return req
+}
 
-	var res SearchResponse
-	if err := c.client.Run(ctx, req, &res); err != nil {
+// ErrUnauthorized is returned when the API responds with 401 or 403
+var ErrUnauthorized = errors.New("manganel api rejected the access token")
+
+// authStatusTransport turns 401 and 403 responses into ErrUnauthorized,
+// the graphql client does not look at status codes and would fail decoding the body instead.
+type authStatusTransport struct {
+	next http.RoundTripper
+}
+
+func (t authStatusTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	res, err := t.next.RoundTrip(req)
+	if err != nil {
 		return nil, err
 	}
-	return &res, nil
+	if res.StatusCode == http.StatusUnauthorized || res.StatusCode == http.StatusForbidden {
+		_ = res.Body.Close()
+		return nil, fmt.Errorf("%w: status code %d", ErrUnauthorized, res.StatusCode)
+	}
+	return res, nil
+}
+
+// isAuthError reports whether the API rejected the request because of the access token
+func isAuthError(err error) bool {
+	if errors.Is(err, ErrUnauthorized) {
+		return true
+	}
+	msg := strings.ToLower(err.Error())
+	for _, hint := range []string{"unauthorized", "forbidden", "access token", "mhub_access", "mhub-access"} {
+		if strings.Contains(msg, hint) {
+			return true
+		}
+	}
+	return false
+}
+
+// isTransportError reports whether a request failed without a response from the API,
+// e.g. a connection reset or an attempt timing out
+func isTransportError(err error) bool {
+	var urlErr *url.Error
+	return errors.As(err, &urlErr) && !errors.Is(err, ErrUnauthorized)
 }
 
 func buildSearchQuery(q string, offset int) string {
@@ -0,0 +1,191 @@
+package manganelapiclient
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/ivan-penchev/manga-updates/internal/ratelimit"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type refreshingTokens struct {
+	current   string
+	refreshed int
+}
+
+func (r *refreshingTokens) Token(ctx context.Context) (string, error) {
+	return r.current, nil
+}
+
+func (r *refreshingTokens) Refresh(ctx context.Context) (string, error) {
+	r.refreshed++
+	r.current = "fresh-token"
+	return r.current, nil
+}
+
+func newTestServer(t *testing.T) *httptest.Server {
+	t.Helper()
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("X-Mhub-Access") != "fresh-token" {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		_ = json.NewEncoder(w).Encode(map[string]any{
+			"data": map[string]any{
+				"search": map[string]any{"count": 1, "rows": []map[string]any{{"title": "Solo Leveling", "slug": "solo-leveling"}}},
+			},
+		})
+	}))
+	t.Cleanup(server.Close)
+	return server
+}
+
+func TestMangaNelAPIClient_RefreshesRejectedToken(t *testing.T) {
+	server := newTestServer(t)
+	tokens := &refreshingTokens{current: "expired-token"}
+	client := NewMangaNelAPIClient(server.URL, tokens)
+
+	res, err := client.Search(context.Background(), "solo", 0)
+	require.NoError(t, err)
+	assert.Equal(t, 1, res.Search.Count)
+	assert.Equal(t, 1, tokens.refreshed)
+
+	_, err = client.Search(context.Background(), "solo", 0)
+	require.NoError(t, err)
+	assert.Equal(t, 1, tokens.refreshed, "a valid token is not refreshed")
+}
+
+func TestMangaNelAPIClient_StaticTokenIsNotRefreshed(t *testing.T) {
+	server := newTestServer(t)
+	client := NewMangaNelAPIClient(server.URL, StaticToken("expired-token"))
+
+	_, err := client.Search(context.Background(), "solo", 0)
+	assert.ErrorContains(t, err, "cannot be refreshed")
+}
+
+func TestMangaNelAPIClient_RetriesThrottledRequests(t *testing.T) {
+	var calls int
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls++
+		if calls == 1 {
+			w.Header().Set("Retry-After", "0")
+			w.WriteHeader(http.StatusTooManyRequests)
+			return
+		}
+		_ = json.NewEncoder(w).Encode(map[string]any{
+			"data": map[string]any{"search": map[string]any{"count": 1}},
+		})
+	}))
+	defer server.Close()
+
+	client := NewMangaNelAPIClient(server.URL, StaticToken("token"), WithRateLimiter(ratelimit.NewLimiter(100, 1)))
+
+	res, err := client.Search(context.Background(), "solo", 0)
+	require.NoError(t, err)
+	assert.Equal(t, 1, res.Search.Count)
+	assert.Equal(t, 2, calls)
+}
+
+func TestMangaNelAPIClient_GetMangaSeriesBacksOffBetweenAttempts(t *testing.T) {
+	var calls int
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls++
+		if calls < 3 {
+			dropConnection(t, w)
+			return
+		}
+		_ = json.NewEncoder(w).Encode(map[string]any{
+			"data": map[string]any{"manga": map[string]any{
+				"title": "Solo Leveling", "slug": "solo-leveling", "status": "completed", "updatedDate": "2024-01-01T00:00:00Z",
+			}},
+		})
+	}))
+	defer server.Close()
+
+	client := NewMangaNelAPIClient(server.URL, StaticToken("token"))
+	client.retryBaseDelay = time.Millisecond
+	client.retryMaxDelay = 5 * time.Millisecond
+
+	manga, err := client.GetMangaSeriesShort(context.Background(), "solo-leveling")
+	require.NoError(t, err)
+	assert.Equal(t, "Solo Leveling", manga.Name)
+	assert.Equal(t, 3, calls)
+}
+
+func TestMangaNelAPIClient_GetMangaSeriesStopsWhenCancelled(t *testing.T) {
+	var calls int
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls++
+		dropConnection(t, w)
+	}))
+	defer server.Close()
+
+	client := NewMangaNelAPIClient(server.URL, StaticToken("token"))
+	client.retryBaseDelay = time.Hour
+	client.retryMaxDelay = time.Hour
+
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+
+	_, err := client.GetMangaSeriesShort(ctx, "solo-leveling")
+	assert.ErrorIs(t, err, context.DeadlineExceeded)
+	assert.Equal(t, 1, calls, "no retry is sent after the context is done")
+}
+
+func TestMangaNelAPIClient_GetMangaSeriesDoesNotRetryResponses(t *testing.T) {
+	var calls int
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls++
+		_, _ = w.Write([]byte("not json"))
+	}))
+	defer server.Close()
+
+	client := NewMangaNelAPIClient(server.URL, StaticToken("token"))
+	client.retryBaseDelay = time.Millisecond
+	client.retryMaxDelay = 5 * time.Millisecond
+
+	_, err := client.GetMangaSeriesShort(context.Background(), "solo-leveling")
+	assert.Error(t, err)
+	assert.Equal(t, 1, calls, "the API answered, sending the same query again would not help")
+}
+
+// dropConnection closes the connection without answering the request
+func dropConnection(t *testing.T, w http.ResponseWriter) {
+	conn, _, err := http.NewResponseController(w).Hijack()
+	require.NoError(t, err)
+	_ = conn.Close()
+}
+
+func TestMangaNelAPIClient_ServesUnchangedSeriesFromCache(t *testing.T) {
+	var calls, notModified int
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls++
+		if r.Header.Get("If-None-Match") == `"v1"` {
+			notModified++
+			w.WriteHeader(http.StatusNotModified)
+			return
+		}
+		w.Header().Set("ETag", `"v1"`)
+		_ = json.NewEncoder(w).Encode(map[string]any{
+			"data": map[string]any{"manga": map[string]any{
+				"title": "Solo Leveling", "slug": "solo-leveling", "status": "completed", "updatedDate": "2024-01-01T00:00:00Z",
+			}},
+		})
+	}))
+	defer server.Close()
+
+	client := NewMangaNelAPIClient(server.URL, StaticToken("token"), WithHTTPCacheDir(t.TempDir()))
+
+	for range 2 {
+		manga, err := client.GetMangaSeriesShort(context.Background(), "solo-leveling")
+		require.NoError(t, err)
+		assert.Equal(t, "Solo Leveling", manga.Name)
+	}
+	assert.Equal(t, 2, calls)
+	assert.Equal(t, 1, notModified, "the second query is answered with a 304 served from the cache")
+}
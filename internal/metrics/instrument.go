@@ -0,0 +1,102 @@
+package metrics
+
+import (
+	"context"
+	"time"
+
+	"github.com/ivan-penchev/manga-updates/internal/domain"
+	"github.com/ivan-penchev/manga-updates/internal/provider"
+)
+
+type Notifier interface {
+	NotifyForNewChapter(ctx context.Context, chapter domain.ChapterEntity, fromManga domain.MangaEntity) error
+}
+
+// InstrumentProviders wraps the providers created by the factories so that the
+// latency and the errors of their calls are recorded.
+func (m *Metrics) InstrumentProviders(factories []provider.ProviderFactory) []provider.ProviderFactory {
+	instrumented := make([]provider.ProviderFactory, len(factories))
+	for i, factory := range factories {
+		newProvider := factory.New
+		factory.New = func() (domain.Provider, error) {
+			p, err := newProvider()
+			if err != nil {
+				return nil, err
+			}
+			instrumented := &instrumentedProvider{Provider: p, metrics: m}
+			// keep the health check of the providers implementing it reachable
+			if checker, ok := p.(domain.HealthChecker); ok {
+				return &healthCheckedProvider{instrumentedProvider: instrumented, checker: checker}, nil
+			}
+			return instrumented, nil
+		}
+		instrumented[i] = factory
+	}
+	return instrumented
+}
+
+type instrumentedProvider struct {
+	domain.Provider
+	metrics *Metrics
+}
+
+func (p *instrumentedProvider) GetLatestVersionMangaEntity(ctx context.Context, manga domain.MangaEntity) (*domain.MangaEntity, error) {
+	started := time.Now()
+	latest, err := p.Provider.GetLatestVersionMangaEntity(ctx, manga)
+	p.metrics.observeProviderCall(p.Kind(), "get_latest_version", started, err)
+	return latest, err
+}
+
+func (p *instrumentedProvider) GetMangaFromURL(ctx context.Context, url string) (domain.MangaEntity, error) {
+	started := time.Now()
+	manga, err := p.Provider.GetMangaFromURL(ctx, url)
+	p.metrics.observeProviderCall(p.Kind(), "get_manga_from_url", started, err)
+	return manga, err
+}
+
+func (p *instrumentedProvider) IsNewerVersionAvailable(ctx context.Context, manga domain.MangaEntity) (bool, error) {
+	started := time.Now()
+	newer, err := p.Provider.IsNewerVersionAvailable(ctx, manga)
+	p.metrics.observeProviderCall(p.Kind(), "is_newer_version_available", started, err)
+	return newer, err
+}
+
+func (p *instrumentedProvider) Search(ctx context.Context, query string, offset int) ([]domain.SearchResult, int, error) {
+	started := time.Now()
+	results, total, err := p.Provider.Search(ctx, query, offset)
+	p.metrics.observeProviderCall(p.Kind(), "search", started, err)
+	return results, total, err
+}
+
+// healthCheckedProvider is an instrumentedProvider forwarding the health check
+// of a provider implementing domain.HealthChecker
+type healthCheckedProvider struct {
+	*instrumentedProvider
+	checker domain.HealthChecker
+}
+
+// HealthCheck implements domain.HealthChecker.
+func (p *healthCheckedProvider) HealthCheck(ctx context.Context) domain.HealthReport {
+	return p.checker.HealthCheck(ctx)
+}
+
+// InstrumentNotifier counts the notifications sent and failed through a channel
+func (m *Metrics) InstrumentNotifier(channel string, next Notifier) Notifier {
+	return &instrumentedNotifier{next: next, channel: channel, metrics: m}
+}
+
+type instrumentedNotifier struct {
+	next    Notifier
+	channel string
+	metrics *Metrics
+}
+
+func (n *instrumentedNotifier) NotifyForNewChapter(ctx context.Context, chapter domain.ChapterEntity, fromManga domain.MangaEntity) error {
+	err := n.next.NotifyForNewChapter(ctx, chapter, fromManga)
+	result := "sent"
+	if err != nil {
+		result = "failed"
+	}
+	n.metrics.notifications.WithLabelValues(n.channel, result).Inc()
+	return err
+}
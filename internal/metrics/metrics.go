@@ -0,0 +1,154 @@
+// Package metrics exposes Prometheus metrics of the update runs, the providers
+// and the notifiers of a long-running process.
+package metrics
+
+import (
+	"context"
+	"net/http"
+	"time"
+
+	"github.com/ivan-penchev/manga-updates/internal/domain"
+	updatechecker "github.com/ivan-penchev/manga-updates/internal/update-checker"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/collectors"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+)
+
+const namespace = "manga_updates"
+
+// Check outcomes of the checks_total counter
+const (
+	OutcomeUpdated   = "updated"
+	OutcomeUnchanged = "unchanged"
+	OutcomeSkipped   = "skipped"
+	OutcomeFailed    = "failed"
+)
+
+// Metrics holds the collectors of the application, registered on their own registry.
+type Metrics struct {
+	registry *prometheus.Registry
+
+	checks            *prometheus.CounterVec
+	providerDuration  *prometheus.HistogramVec
+	providerErrors    *prometheus.CounterVec
+	notifications     *prometheus.CounterVec
+	series            *prometheus.GaugeVec
+	runDuration       prometheus.Histogram
+	lastSuccessfulRun prometheus.Gauge
+}
+
+func New() *Metrics {
+	m := &Metrics{
+		registry: prometheus.NewRegistry(),
+		checks: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: namespace,
+			Name:      "checks_total",
+			Help:      "Series checks by provider and outcome (updated, unchanged, skipped, failed).",
+		}, []string{"provider", "outcome"}),
+		providerDuration: prometheus.NewHistogramVec(prometheus.HistogramOpts{
+			Namespace: namespace,
+			Name:      "provider_request_duration_seconds",
+			Help:      "Latency of provider calls by provider and operation.",
+			Buckets:   []float64{0.1, 0.25, 0.5, 1, 2.5, 5, 10, 30, 60, 120},
+		}, []string{"provider", "operation"}),
+		providerErrors: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: namespace,
+			Name:      "provider_errors_total",
+			Help:      "Failed provider calls by provider and operation.",
+		}, []string{"provider", "operation"}),
+		notifications: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: namespace,
+			Name:      "notifications_total",
+			Help:      "Notifications by channel and result (sent, failed).",
+		}, []string{"channel", "result"}),
+		series: prometheus.NewGaugeVec(prometheus.GaugeOpts{
+			Namespace: namespace,
+			Name:      "series",
+			Help:      "Tracked series by publication status, as of the last run.",
+		}, []string{"status"}),
+		runDuration: prometheus.NewHistogram(prometheus.HistogramOpts{
+			Namespace: namespace,
+			Name:      "run_duration_seconds",
+			Help:      "Duration of the update runs.",
+			Buckets:   prometheus.ExponentialBuckets(1, 2, 12),
+		}),
+		lastSuccessfulRun: prometheus.NewGauge(prometheus.GaugeOpts{
+			Namespace: namespace,
+			Name:      "last_successful_run_timestamp_seconds",
+			Help:      "Unix time of the end of the last run in which no series failed.",
+		}),
+	}
+
+	m.registry.MustRegister(
+		m.checks,
+		m.providerDuration,
+		m.providerErrors,
+		m.notifications,
+		m.series,
+		m.runDuration,
+		m.lastSuccessfulRun,
+		collectors.NewGoCollector(),
+		collectors.NewProcessCollector(collectors.ProcessCollectorOpts{}),
+	)
+	return m
+}
+
+// Handler serves the metrics in the Prometheus exposition format
+func (m *Metrics) Handler() http.Handler {
+	return promhttp.HandlerFor(m.registry, promhttp.HandlerOpts{Registry: m.registry})
+}
+
+// Registry returns the registry of the collectors
+func (m *Metrics) Registry() *prometheus.Registry {
+	return m.registry
+}
+
+// RunCompleted implements updatechecker.RunObserver
+func (m *Metrics) RunCompleted(ctx context.Context, summary updatechecker.RunSummary) error {
+	statuses := make(map[string]float64)
+	count := func(manga domain.MangaEntity, outcome string) {
+		m.checks.WithLabelValues(string(manga.Source), outcome).Inc()
+		status := string(manga.Status)
+		if status == "" {
+			status = "unknown"
+		}
+		statuses[status]++
+	}
+	for _, u := range summary.Updated {
+		count(u.Manga, OutcomeUpdated)
+	}
+	for _, u := range summary.Unchanged {
+		count(u.Manga, OutcomeUnchanged)
+	}
+	for _, s := range summary.Skipped {
+		count(s.Manga, OutcomeSkipped)
+	}
+	for _, f := range summary.Failed {
+		count(f.Manga, OutcomeFailed)
+	}
+
+	m.series.Reset()
+	for status, n := range statuses {
+		m.series.WithLabelValues(status).Set(n)
+	}
+
+	if !summary.StartedAt.IsZero() && !summary.FinishedAt.IsZero() {
+		m.runDuration.Observe(summary.FinishedAt.Sub(summary.StartedAt).Seconds())
+	}
+	if len(summary.Failed) == 0 {
+		finished := summary.FinishedAt
+		if finished.IsZero() {
+			finished = time.Now()
+		}
+		m.lastSuccessfulRun.Set(float64(finished.Unix()))
+	}
+	return nil
+}
+
+// observeProviderCall records the latency and the error of a provider call
+func (m *Metrics) observeProviderCall(provider domain.MangaSource, operation string, started time.Time, err error) {
+	m.providerDuration.WithLabelValues(string(provider), operation).Observe(time.Since(started).Seconds())
+	if err != nil {
+		m.providerErrors.WithLabelValues(string(provider), operation).Inc()
+	}
+}
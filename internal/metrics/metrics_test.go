@@ -0,0 +1,118 @@
+package metrics
+
+import (
+	"context"
+	"errors"
+	"io"
+	"log/slog"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/ivan-penchev/manga-updates/internal/domain"
+	"github.com/ivan-penchev/manga-updates/internal/mocks"
+	"github.com/ivan-penchev/manga-updates/internal/provider"
+	updatechecker "github.com/ivan-penchev/manga-updates/internal/update-checker"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+func TestMetrics_RunCompleted(t *testing.T) {
+	m := New()
+	ongoing := domain.MangaEntity{Source: domain.MangaSourceMangaDex, Status: domain.MangaStatusOngoing}
+	complete := domain.MangaEntity{Source: domain.MangaSourceMangaNel, Status: domain.MangaStatusComplete}
+	finished := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+
+	require.NoError(t, m.RunCompleted(context.Background(), updatechecker.RunSummary{
+		StartedAt:  finished.Add(-time.Minute),
+		FinishedAt: finished,
+		Updated:    []updatechecker.SeriesUpdate{{Manga: ongoing}},
+		Unchanged:  []updatechecker.SeriesUpdate{{Manga: ongoing}, {Manga: complete}},
+	}))
+
+	assert.Equal(t, 1.0, testutil.ToFloat64(m.checks.WithLabelValues("mangadex", OutcomeUpdated)))
+	assert.Equal(t, 1.0, testutil.ToFloat64(m.checks.WithLabelValues("manganel", OutcomeUnchanged)))
+	assert.Equal(t, 2.0, testutil.ToFloat64(m.series.WithLabelValues("ongoing")))
+	assert.Equal(t, float64(finished.Unix()), testutil.ToFloat64(m.lastSuccessfulRun))
+
+	require.NoError(t, m.RunCompleted(context.Background(), updatechecker.RunSummary{
+		FinishedAt: finished.Add(time.Hour),
+		Failed:     []updatechecker.SeriesFailure{{Manga: complete, Err: errors.New("timeout")}},
+	}))
+	assert.Equal(t, 1.0, testutil.ToFloat64(m.checks.WithLabelValues("manganel", OutcomeFailed)))
+	assert.Equal(t, float64(finished.Unix()), testutil.ToFloat64(m.lastSuccessfulRun), "runs with failures are not successful")
+	assert.Equal(t, 1, testutil.CollectAndCount(m.series), "series counts are replaced every run")
+}
+
+func TestMetrics_RecordsRunsWithoutSeries(t *testing.T) {
+	m := New()
+	mockStore := mocks.NewMockStore(t)
+	mockStore.EXPECT().GetMangaSeries(mock.Anything).Return(map[string]domain.MangaEntity{})
+
+	service, err := updatechecker.NewUpdateCheckerService(mocks.NewMockNotifier(t), mockStore, mocks.NewMockProviderRouter(t), slog.New(slog.NewTextHandler(io.Discard, nil)), updatechecker.WithRunObserver(m))
+	require.NoError(t, err)
+	require.NoError(t, service.CheckForUpdates(context.Background()))
+
+	assert.NotZero(t, testutil.ToFloat64(m.lastSuccessfulRun), "a run without series is successful")
+}
+
+// healthCheckedMockProvider is a provider implementing domain.HealthChecker
+type healthCheckedMockProvider struct {
+	*mocks.MockProvider
+}
+
+func (p healthCheckedMockProvider) HealthCheck(context.Context) domain.HealthReport {
+	return domain.HealthReport{Reachable: true, SchemaValid: true}
+}
+
+func TestMetrics_InstrumentProvidersForwardsHealthCheck(t *testing.T) {
+	m := New()
+	factories := m.InstrumentProviders([]provider.ProviderFactory{
+		{Kind: domain.MangaSourceMangaDex, New: func() (domain.Provider, error) { return healthCheckedMockProvider{mocks.NewMockProvider(t)}, nil }},
+		{Kind: domain.MangaSourceMangaNel, New: func() (domain.Provider, error) { return mocks.NewMockProvider(t), nil }},
+	})
+
+	p, err := factories[0].New()
+	require.NoError(t, err)
+	checker, ok := p.(domain.HealthChecker)
+	require.True(t, ok, "the health check of the provider is forwarded")
+	assert.True(t, checker.HealthCheck(context.Background()).Healthy())
+
+	p, err = factories[1].New()
+	require.NoError(t, err)
+	_, ok = p.(domain.HealthChecker)
+	assert.False(t, ok, "providers without health check do not get one")
+}
+
+func TestMetrics_InstrumentProvidersAndNotifiers(t *testing.T) {
+	m := New()
+	mockProvider := mocks.NewMockProvider(t)
+	mockProvider.EXPECT().Kind().Return(domain.MangaSourceMangaDex)
+	mockProvider.EXPECT().IsNewerVersionAvailable(mock.Anything, mock.Anything).Return(false, errors.New("throttled"))
+
+	factories := m.InstrumentProviders([]provider.ProviderFactory{{
+		Kind: domain.MangaSourceMangaDex,
+		New:  func() (domain.Provider, error) { return mockProvider, nil },
+	}})
+	p, err := factories[0].New()
+	require.NoError(t, err)
+	_, err = p.IsNewerVersionAvailable(context.Background(), domain.MangaEntity{})
+	assert.Error(t, err)
+	assert.Equal(t, 1.0, testutil.ToFloat64(m.providerErrors.WithLabelValues("mangadex", "is_newer_version_available")))
+	assert.Equal(t, 1, testutil.CollectAndCount(m.providerDuration))
+
+	mockNotifier := mocks.NewMockNotifier(t)
+	mockNotifier.EXPECT().NotifyForNewChapter(mock.Anything, mock.Anything, mock.Anything).Return(nil).Once()
+	mockNotifier.EXPECT().NotifyForNewChapter(mock.Anything, mock.Anything, mock.Anything).Return(errors.New("rejected")).Once()
+	n := m.InstrumentNotifier("smtp2go", mockNotifier)
+	assert.NoError(t, n.NotifyForNewChapter(context.Background(), domain.ChapterEntity{}, domain.MangaEntity{}))
+	assert.Error(t, n.NotifyForNewChapter(context.Background(), domain.ChapterEntity{}, domain.MangaEntity{}))
+	assert.Equal(t, 1.0, testutil.ToFloat64(m.notifications.WithLabelValues("smtp2go", "sent")))
+	assert.Equal(t, 1.0, testutil.ToFloat64(m.notifications.WithLabelValues("smtp2go", "failed")))
+
+	recorder := httptest.NewRecorder()
+	m.Handler().ServeHTTP(recorder, httptest.NewRequest("GET", "/metrics", nil))
+	assert.Contains(t, recorder.Body.String(), `manga_updates_notifications_total{channel="smtp2go",result="sent"} 1`)
+}
@@ -159,3 +159,49 @@ func (_c *MockProviderRouter_GetProviderForURL_Call) RunAndReturn(run func(url s
 	_c.Call.Return(run)
 	return _c
 }
+
+// Providers provides a mock function for the type MockProviderRouter
+func (_mock *MockProviderRouter) Providers() []domain.ProviderInfo {
+	ret := _mock.Called()
+
+	if len(ret) == 0 {
+		panic("no return value specified for Providers")
+	}
+
+	var r0 []domain.ProviderInfo
+	if returnFunc, ok := ret.Get(0).(func() []domain.ProviderInfo); ok {
+		r0 = returnFunc()
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]domain.ProviderInfo)
+		}
+	}
+	return r0
+}
+
+// MockProviderRouter_Providers_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'Providers'
+type MockProviderRouter_Providers_Call struct {
+	*mock.Call
+}
+
+// Providers is a helper method to define mock.On call
+func (_e *MockProviderRouter_Expecter) Providers() *MockProviderRouter_Providers_Call {
+	return &MockProviderRouter_Providers_Call{Call: _e.mock.On("Providers")}
+}
+
+func (_c *MockProviderRouter_Providers_Call) Run(run func()) *MockProviderRouter_Providers_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		run()
+	})
+	return _c
+}
+
+func (_c *MockProviderRouter_Providers_Call) Return(providerInfos []domain.ProviderInfo) *MockProviderRouter_Providers_Call {
+	_c.Call.Return(providerInfos)
+	return _c
+}
+
+func (_c *MockProviderRouter_Providers_Call) RunAndReturn(run func() []domain.ProviderInfo) *MockProviderRouter_Providers_Call {
+	_c.Call.Return(run)
+	return _c
+}
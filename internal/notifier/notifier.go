@@ -22,6 +22,7 @@ type notifierConfig struct {
 	templateID string
 	clientType string
 	recipients []string
+	logger     *slog.Logger
 }
 
 type NotifierOption func(*notifierConfig)
@@ -63,9 +64,16 @@ func WithRecipients(recipients ...string) NotifierOption {
 	}
 }
 
+// WithLogger sets the logger of the notifier, slog.Default() by default
+func WithLogger(logger *slog.Logger) NotifierOption {
+	return func(c *notifierConfig) {
+		c.logger = logger
+	}
+}
+
 func NewNotifier(opts ...NotifierOption) (Notifier, error) {
 
-	config := &notifierConfig{}
+	config := &notifierConfig{logger: slog.Default()}
 	for _, opt := range opts {
 		opt(config)
 	}
@@ -76,7 +84,7 @@ func NewNotifier(opts ...NotifierOption) (Notifier, error) {
 	case sMTP2GONotifierType:
 		return newSMTP2GONotifier(config)
 	default:
-		slog.Info("Unknown notifier type, giving a standard output notifier")
-		return standardOutNotifier{}, nil
+		config.logger.Info("Unknown notifier type, giving a standard output notifier")
+		return standardOutNotifier{logger: config.logger, recipients: config.recipients}, nil
 	}
 }
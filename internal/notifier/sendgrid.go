@@ -60,6 +60,9 @@ func (s sendgridNotifier) NotifyForNewChapter(ctx context.Context, chapter domai
 		p.SetDynamicTemplateData("manga_name", fromManga.Name)
 		p.SetDynamicTemplateData("chapter", chapter.Number)
 		p.SetDynamicTemplateData("subject", fmt.Sprintf("%s update", fromManga.Name))
+		if behind, ok := fromManga.UnreadCount(); ok {
+			p.SetDynamicTemplateData("chapters_behind", behind)
+		}
 	} else {
 		p.Subject = fmt.Sprintf("%s update", fromManga.Name)
 		m.Content = []*mail.Content{mail.NewContent("test", "test")}
@@ -7,6 +7,7 @@ import (
 	"fmt"
 	"io"
 	"net/http"
+	"strconv"
 
 	"github.com/ivan-penchev/manga-updates/internal/domain"
 )
@@ -133,11 +134,18 @@ func (s smtp2goNotifier) NotifyForNewChapter(ctx context.Context, chapter domain
 			"chapter_link": chapter.URI,
 			"subject":      subject,
 		}
+		if behind, ok := fromManga.UnreadCount(); ok {
+			templateData["chapters_behind"] = strconv.Itoa(behind)
+		}
 		email.TemplateID = s.config.templateID
 		email.TemplateData = templateData
 	} else {
 		htmlBody = fmt.Sprintf("<h1>%s Update!</h1><p>Chapter %s is now available.</p><p>Read it here: <a href=\"%s\">%s</a></p>", fromManga.Name, fmt.Sprintf("%.0f", *chapter.Number), chapter.URI, chapter.URI)
 		textBody = fmt.Sprintf("%s Update! Chapter %s is now available. Read it here: %s", fromManga.Name, fmt.Sprintf("%.0f", *chapter.Number), chapter.URI)
+		if behind, ok := fromManga.UnreadCount(); ok {
+			htmlBody += fmt.Sprintf("<p>You are %d chapters behind.</p>", behind)
+			textBody += fmt.Sprintf(" You are %d chapters behind.", behind)
+		}
 		email.HtmlBody = htmlBody
 		email.TextBody = textBody
 	}
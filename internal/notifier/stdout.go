@@ -7,13 +7,23 @@ import (
 	"github.com/ivan-penchev/manga-updates/internal/domain"
 )
 
-type standardOutNotifier struct{}
+type standardOutNotifier struct {
+	logger     *slog.Logger
+	recipients []string
+}
 
 func (s standardOutNotifier) NotifyForNewChapter(ctx context.Context, chapter domain.ChapterEntity, fromManga domain.MangaEntity) error {
-	slog.Info("Notifying about new chapter",
+	attrs := []any{
 		"mangaName", fromManga.Name,
 		"chapterNumber", chapter.Number,
 		"readUrl", chapter.URI,
-	)
+	}
+	if behind, ok := fromManga.UnreadCount(); ok {
+		attrs = append(attrs, "chaptersBehind", behind)
+	}
+	if len(s.recipients) > 0 {
+		attrs = append(attrs, "recipients", s.recipients)
+	}
+	s.logger.Info("Notifying about new chapter", attrs...)
 	return nil
 }
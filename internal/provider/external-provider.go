@@ -0,0 +1,217 @@
+package provider
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"os/exec"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/ivan-penchev/manga-updates/internal/domain"
+)
+
+const defaultExternalProviderTimeout = 30 * time.Second
+
+// Methods of the external provider protocol, see docs/external-providers.md
+const (
+	externalMethodKind                        = "kind"
+	externalMethodSupports                    = "supports"
+	externalMethodGetMangaFromURL             = "getMangaFromURL"
+	externalMethodGetLatestVersionMangaEntity = "getLatestVersionMangaEntity"
+	externalMethodSearch                      = "search"
+)
+
+type ExternalProviderConfig struct {
+	// Command is the executable implementing the protocol
+	Command string
+	Args    []string
+	// Kind is the source name of the provider, asked from the executable when empty
+	Kind domain.MangaSource
+	// URLPatterns are regular expressions answering Supports without spawning the executable
+	URLPatterns []string
+	// Timeout of a single invocation, 30 seconds when zero
+	Timeout time.Duration
+	// Logger defaults to slog.Default()
+	Logger *slog.Logger
+}
+
+type externalRequest struct {
+	Method string `json:"method"`
+	Params any    `json:"params,omitempty"`
+}
+
+type externalResponse struct {
+	Result json.RawMessage `json:"result"`
+	Error  string          `json:"error,omitempty"`
+}
+
+type externalSearchResult struct {
+	Results []domain.SearchResult `json:"results"`
+	Total   int                   `json:"total"`
+}
+
+// NewExternalProviderFactory creates a provider backed by an executable speaking
+// a JSON-over-stdin/stdout protocol, one process is spawned per call.
+func NewExternalProviderFactory(cfg ExternalProviderConfig) ProviderFactory {
+	return ProviderFactory{
+		Kind:        cfg.Kind,
+		URLPatterns: cfg.URLPatterns,
+		Logger:      cfg.Logger,
+		New: func() (domain.Provider, error) {
+			path, err := exec.LookPath(cfg.Command)
+			if err != nil {
+				return nil, fmt.Errorf("external provider command %q not found: %w", cfg.Command, err)
+			}
+
+			urlPatterns, err := compilePatterns(cfg.URLPatterns)
+			if err != nil {
+				return nil, fmt.Errorf("invalid external provider url pattern: %w", err)
+			}
+
+			timeout := cfg.Timeout
+			if timeout <= 0 {
+				timeout = defaultExternalProviderTimeout
+			}
+
+			ep := &externalProvider{
+				path:        path,
+				args:        cfg.Args,
+				kind:        cfg.Kind,
+				urlPatterns: urlPatterns,
+				timeout:     timeout,
+				logger:      loggerOrDefault(cfg.Logger),
+			}
+
+			if ep.kind == "" {
+				var kind string
+				if err := ep.call(context.Background(), externalMethodKind, nil, &kind); err != nil {
+					return nil, fmt.Errorf("failed to get kind of external provider %q: %w", cfg.Command, err)
+				}
+				if kind == "" {
+					return nil, fmt.Errorf("external provider %q returned an empty kind", cfg.Command)
+				}
+				ep.kind = domain.MangaSource(kind)
+			}
+
+			return ep, nil
+		},
+	}
+}
+
+type externalProvider struct {
+	path        string
+	args        []string
+	kind        domain.MangaSource
+	urlPatterns []*regexp.Regexp
+	timeout     time.Duration
+	logger      *slog.Logger
+}
+
+func (ep *externalProvider) Kind() domain.MangaSource {
+	return ep.kind
+}
+
+func (ep *externalProvider) Supports(url string) bool {
+	if len(ep.urlPatterns) > 0 {
+		for _, re := range ep.urlPatterns {
+			if re.MatchString(url) {
+				return true
+			}
+		}
+		return false
+	}
+
+	var supported bool
+	err := ep.call(context.Background(), externalMethodSupports, map[string]string{"url": url}, &supported)
+	if err != nil {
+		ep.logger.Warn("external provider failed to answer supports", "providerKind", ep.kind, "error", err)
+		return false
+	}
+	return supported
+}
+
+func (ep *externalProvider) GetMangaFromURL(ctx context.Context, url string) (domain.MangaEntity, error) {
+	var manga domain.MangaEntity
+	if err := ep.call(ctx, externalMethodGetMangaFromURL, map[string]string{"url": url}, &manga); err != nil {
+		return domain.MangaEntity{}, err
+	}
+	manga.Source = ep.kind
+	return manga, nil
+}
+
+func (ep *externalProvider) GetLatestVersionMangaEntity(ctx context.Context, manga domain.MangaEntity) (*domain.MangaEntity, error) {
+	var latest domain.MangaEntity
+	if err := ep.call(ctx, externalMethodGetLatestVersionMangaEntity, map[string]domain.MangaEntity{"manga": manga}, &latest); err != nil {
+		return nil, err
+	}
+	latest.Source = ep.kind
+	latest.ShouldNotify = manga.ShouldNotify
+	return &latest, nil
+}
+
+func (ep *externalProvider) IsNewerVersionAvailable(ctx context.Context, manga domain.MangaEntity) (bool, error) {
+	if manga.IsNew() {
+		logMessage := fmt.Sprintf("Manga title (%s) that we have never synced before added for update notifications", manga.Name)
+		ep.logger.Info(logMessage)
+		return true, nil
+	}
+
+	latest, err := ep.GetLatestVersionMangaEntity(ctx, manga)
+	if err != nil {
+		return false, err
+	}
+	return hasNewerChapter(manga, *latest), nil
+}
+
+func (ep *externalProvider) Search(ctx context.Context, query string, offset int) ([]domain.SearchResult, int, error) {
+	var res externalSearchResult
+	err := ep.call(ctx, externalMethodSearch, map[string]any{"query": query, "offset": offset}, &res)
+	if err != nil {
+		return nil, 0, err
+	}
+	for i := range res.Results {
+		res.Results[i].Manga.Source = ep.kind
+	}
+	return res.Results, res.Total, nil
+}
+
+func (ep *externalProvider) call(ctx context.Context, method string, params any, result any) error {
+	ctx, cancel := context.WithTimeout(ctx, ep.timeout)
+	defer cancel()
+
+	request, err := json.Marshal(externalRequest{Method: method, Params: params})
+	if err != nil {
+		return fmt.Errorf("failed to marshal %s request: %w", method, err)
+	}
+
+	var stdout, stderr bytes.Buffer
+	cmd := exec.CommandContext(ctx, ep.path, ep.args...)
+	cmd.Stdin = bytes.NewReader(request)
+	cmd.Stdout = &stdout
+	cmd.Stderr = &stderr
+
+	if err := cmd.Run(); err != nil {
+		if ctx.Err() != nil {
+			err = errors.Join(err, ctx.Err())
+		}
+		return fmt.Errorf("external provider %s failed on %s: %w: %s", ep.kind, method, err, strings.TrimSpace(stderr.String()))
+	}
+
+	var response externalResponse
+	if err := json.Unmarshal(stdout.Bytes(), &response); err != nil {
+		return fmt.Errorf("external provider %s returned an invalid response to %s: %w", ep.kind, method, err)
+	}
+	if response.Error != "" {
+		return fmt.Errorf("external provider %s failed on %s: %s", ep.kind, method, response.Error)
+	}
+	if err := json.Unmarshal(response.Result, result); err != nil {
+		return fmt.Errorf("external provider %s returned an invalid result to %s: %w", ep.kind, method, err)
+	}
+
+	return nil
+}
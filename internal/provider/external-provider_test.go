@@ -0,0 +1,144 @@
+package provider
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/ivan-penchev/manga-updates/internal/domain"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// TestExternalProviderHelperProcess is not a real test, it is the executable
+// spawned by the external provider tests, implementing the protocol for a fake site.
+func TestExternalProviderHelperProcess(t *testing.T) {
+	if os.Getenv("GO_WANT_EXTERNAL_PROVIDER_HELPER") != "1" {
+		return
+	}
+	defer os.Exit(0)
+
+	var req struct {
+		Method string          `json:"method"`
+		Params json.RawMessage `json:"params"`
+	}
+	if err := json.NewDecoder(os.Stdin).Decode(&req); err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(2)
+	}
+
+	var params struct {
+		URL   string             `json:"url"`
+		Query string             `json:"query"`
+		Manga domain.MangaEntity `json:"manga"`
+	}
+	_ = json.Unmarshal(req.Params, &params)
+
+	one, two := 1.0, 2.0
+	chapters := []domain.ChapterEntity{
+		{Number: &two, URI: "https://fake.example.com/one-piece/2"},
+		{Number: &one, URI: "https://fake.example.com/one-piece/1"},
+	}
+
+	var result any
+	switch req.Method {
+	case "kind":
+		result = "fake"
+	case "supports":
+		result = strings.Contains(params.URL, "fake.example.com")
+	case "getMangaFromURL":
+		if strings.HasSuffix(params.URL, "/slow") {
+			time.Sleep(time.Minute)
+		}
+		if strings.HasSuffix(params.URL, "/missing") {
+			_ = json.NewEncoder(os.Stdout).Encode(map[string]string{"error": "series not found"})
+			return
+		}
+		result = domain.MangaEntity{Name: "One Piece", Slug: "one-piece", ShouldNotify: true, Chapters: chapters}
+	case "getLatestVersionMangaEntity":
+		m := params.Manga
+		m.Chapters = chapters
+		result = m
+	case "search":
+		result = map[string]any{
+			"results": []domain.SearchResult{{Manga: domain.MangaEntity{Name: params.Query, Slug: "one-piece"}, URL: "https://fake.example.com/one-piece"}},
+			"total":   1,
+		}
+	default:
+		fmt.Fprintln(os.Stderr, "unknown method", req.Method)
+		os.Exit(3)
+	}
+
+	_ = json.NewEncoder(os.Stdout).Encode(map[string]any{"result": result})
+}
+
+func newTestExternalProvider(t *testing.T, cfg ExternalProviderConfig) domain.Provider {
+	t.Helper()
+	t.Setenv("GO_WANT_EXTERNAL_PROVIDER_HELPER", "1")
+
+	cfg.Command = os.Args[0]
+	cfg.Args = []string{"-test.run=^TestExternalProviderHelperProcess$"}
+
+	p, err := NewExternalProviderFactory(cfg).New()
+	require.NoError(t, err)
+	return p
+}
+
+func TestExternalProvider_Protocol(t *testing.T) {
+	p := newTestExternalProvider(t, ExternalProviderConfig{})
+	ctx := context.Background()
+
+	assert.Equal(t, domain.MangaSource("fake"), p.Kind())
+	assert.True(t, p.Supports("https://fake.example.com/one-piece"))
+	assert.False(t, p.Supports("https://mangadex.org/title/1"))
+
+	manga, err := p.GetMangaFromURL(ctx, "https://fake.example.com/one-piece")
+	require.NoError(t, err)
+	assert.Equal(t, "One Piece", manga.Name)
+	assert.Equal(t, domain.MangaSource("fake"), manga.Source)
+	require.Len(t, manga.Chapters, 2)
+	assert.Equal(t, 2.0, *manga.Chapters[0].Number)
+
+	_, err = p.GetMangaFromURL(ctx, "https://fake.example.com/missing")
+	assert.ErrorContains(t, err, "series not found")
+
+	manga.Chapters = manga.Chapters[1:]
+	manga.LastUpdate = time.Now()
+	isNewer, err := p.IsNewerVersionAvailable(ctx, manga)
+	require.NoError(t, err)
+	assert.True(t, isNewer)
+
+	results, total, err := p.Search(ctx, "one piece", 0)
+	require.NoError(t, err)
+	assert.Equal(t, 1, total)
+	assert.Equal(t, domain.MangaSource("fake"), results[0].Manga.Source)
+}
+
+func TestExternalProvider_ConfiguredKindAndPatterns(t *testing.T) {
+	p := newTestExternalProvider(t, ExternalProviderConfig{
+		Kind:        "configured",
+		URLPatterns: []string{`^https://other\.example\.com/`},
+	})
+
+	assert.Equal(t, domain.MangaSource("configured"), p.Kind())
+	assert.True(t, p.Supports("https://other.example.com/series"))
+	assert.False(t, p.Supports("https://fake.example.com/one-piece"), "patterns take precedence over the executable")
+}
+
+func TestExternalProvider_MissingCommand(t *testing.T) {
+	_, err := NewExternalProviderFactory(ExternalProviderConfig{Command: "manga-updates-provider-that-does-not-exist"}).New()
+	assert.Error(t, err)
+}
+
+func TestExternalProvider_HonorsCancellation(t *testing.T) {
+	p := newTestExternalProvider(t, ExternalProviderConfig{Timeout: time.Minute})
+
+	assertCancelledPromptly(t, func(ctx context.Context) error {
+		_, err := p.GetMangaFromURL(ctx, "https://fake.example.com/slow")
+		return err
+	})
+}
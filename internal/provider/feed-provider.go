@@ -0,0 +1,247 @@
+package provider
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"regexp"
+	"sort"
+	"strconv"
+	"time"
+
+	"github.com/ivan-penchev/manga-updates/internal/domain"
+	"github.com/ivan-penchev/manga-updates/internal/feed"
+	"github.com/ivan-penchev/manga-updates/internal/httpcache"
+)
+
+var (
+	// DefaultFeedURLPatterns matches the usual locations of RSS/Atom feeds
+	DefaultFeedURLPatterns = []string{`(?i)(\.rss|\.atom|\.xml|/feed/?|/rss/?|/atom/?)$`}
+	// DefaultFeedChapterPatterns matches titles such as "Chapter 12", "Ch. 12.5" or "#12"
+	DefaultFeedChapterPatterns = []string{
+		`(?i)\bch(?:apter|\.)?\s*(\d+(?:\.\d+)?)`,
+		`#(\d+(?:\.\d+)?)`,
+	}
+)
+
+type FeedProviderConfig struct {
+	// URLPatterns are regular expressions matched against urls in Supports
+	URLPatterns []string
+	// ChapterPatterns are regular expressions with a single capture group
+	// extracting the chapter number from an item title, tried in order.
+	ChapterPatterns []string
+	HTTPClient      *http.Client
+	// CacheDir caches responses on disk and revalidates them with conditional requests, no caching when empty
+	CacheDir string
+	// Logger defaults to slog.Default()
+	Logger *slog.Logger
+}
+
+// NewFeedProviderFactory creates a provider whose source is an arbitrary RSS/Atom feed,
+// the slug of a series tracked with it is the feed url.
+func NewFeedProviderFactory(cfg FeedProviderConfig) ProviderFactory {
+	if len(cfg.URLPatterns) == 0 {
+		cfg.URLPatterns = DefaultFeedURLPatterns
+	}
+
+	return ProviderFactory{
+		Kind:        domain.MangaSourceFeed,
+		URLPatterns: cfg.URLPatterns,
+		Logger:      cfg.Logger,
+		New: func() (domain.Provider, error) {
+			if len(cfg.ChapterPatterns) == 0 {
+				cfg.ChapterPatterns = DefaultFeedChapterPatterns
+			}
+
+			urlPatterns, err := compilePatterns(cfg.URLPatterns)
+			if err != nil {
+				return nil, fmt.Errorf("invalid feed url pattern: %w", err)
+			}
+			chapterPatterns, err := compilePatterns(cfg.ChapterPatterns)
+			if err != nil {
+				return nil, fmt.Errorf("invalid feed chapter pattern: %w", err)
+			}
+			for _, re := range chapterPatterns {
+				if re.NumSubexp() < 1 {
+					return nil, fmt.Errorf("feed chapter pattern %q has no capture group", re)
+				}
+			}
+
+			httpClient := cfg.HTTPClient
+			if httpClient == nil {
+				httpClient = &http.Client{Timeout: 10 * time.Second}
+			}
+			httpClient = httpcache.NewClient(httpClient, cfg.CacheDir, httpcache.WithLogger(loggerOrDefault(cfg.Logger)))
+
+			return &feedProvider{
+				httpClient:      httpClient,
+				urlPatterns:     urlPatterns,
+				chapterPatterns: chapterPatterns,
+				logger:          loggerOrDefault(cfg.Logger),
+			}, nil
+		},
+	}
+}
+
+type feedProvider struct {
+	httpClient      *http.Client
+	urlPatterns     []*regexp.Regexp
+	chapterPatterns []*regexp.Regexp
+	logger          *slog.Logger
+}
+
+func (*feedProvider) Kind() domain.MangaSource {
+	return domain.MangaSourceFeed
+}
+
+func (fp *feedProvider) Supports(url string) bool {
+	for _, re := range fp.urlPatterns {
+		if re.MatchString(url) {
+			return true
+		}
+	}
+	return false
+}
+
+func (fp *feedProvider) GetMangaFromURL(ctx context.Context, u string) (domain.MangaEntity, error) {
+	fetched, err := fp.GetLatestVersionMangaEntity(ctx, domain.MangaEntity{
+		Slug:         u,
+		Source:       domain.MangaSourceFeed,
+		ShouldNotify: true,
+	})
+	if err != nil {
+		return domain.MangaEntity{}, err
+	}
+	return *fetched, nil
+}
+
+func (fp *feedProvider) GetLatestVersionMangaEntity(ctx context.Context, manga domain.MangaEntity) (*domain.MangaEntity, error) {
+	f, err := fp.fetch(ctx, manga.Slug)
+	if err != nil {
+		return nil, err
+	}
+
+	name := manga.Name
+	if name == "" {
+		name = f.Title
+	}
+
+	chapters := fp.chaptersFromEntries(f.Entries)
+	lastUpdate := f.Updated
+	if lastUpdate.IsZero() {
+		lastUpdate = time.Now()
+	}
+
+	return &domain.MangaEntity{
+		Name:         name,
+		ShouldNotify: manga.ShouldNotify,
+		LastUpdate:   lastUpdate,
+		Slug:         manga.Slug,
+		Status:       manga.Status,
+		Source:       domain.MangaSourceFeed,
+		Chapters:     chapters,
+	}, nil
+}
+
+func (fp *feedProvider) IsNewerVersionAvailable(ctx context.Context, manga domain.MangaEntity) (bool, error) {
+	if manga.IsNew() {
+		logMessage := fmt.Sprintf("Manga title (%s) that we have never synced before added for update notifications", manga.Name)
+		fp.logger.Info(logMessage)
+		return true, nil
+	}
+
+	latest, err := fp.GetLatestVersionMangaEntity(ctx, manga)
+	if err != nil {
+		return false, err
+	}
+	return hasNewerChapter(manga, *latest), nil
+}
+
+func (*feedProvider) Search(ctx context.Context, query string, offset int) ([]domain.SearchResult, int, error) {
+	return nil, 0, ErrSearchNotSupported
+}
+
+func (fp *feedProvider) fetch(ctx context.Context, u string) (feed.Feed, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, u, nil)
+	if err != nil {
+		return feed.Feed{}, fmt.Errorf("failed to create feed request: %w", err)
+	}
+	req.Header.Set("Accept", "application/rss+xml, application/atom+xml, application/xml;q=0.9, */*;q=0.8")
+
+	res, err := fp.httpClient.Do(req)
+	if err != nil {
+		return feed.Feed{}, fmt.Errorf("failed to fetch feed %s: %w", u, err)
+	}
+	defer func() { _ = res.Body.Close() }()
+
+	if res.StatusCode != http.StatusOK {
+		return feed.Feed{}, fmt.Errorf("feed %s returned status code %d", u, res.StatusCode)
+	}
+
+	return feed.Parse(res.Body)
+}
+
+// chaptersFromEntries converts feed entries into chapters sorted in descending order,
+// entries without a recognizable chapter number are skipped and
+// only the newest entry is kept when several share a chapter number.
+func (fp *feedProvider) chaptersFromEntries(entries []feed.Entry) []domain.ChapterEntity {
+	sort.SliceStable(entries, func(i, j int) bool {
+		return entries[i].Published.After(entries[j].Published)
+	})
+
+	seen := make(map[float64]bool)
+	chapters := make([]domain.ChapterEntity, 0, len(entries))
+	for _, entry := range entries {
+		number, ok := fp.chapterNumber(entry.Title)
+		if !ok || seen[number] {
+			continue
+		}
+		seen[number] = true
+
+		id := entry.ID
+		published := entry.Published
+		chapter := domain.ChapterEntity{
+			Number: &number,
+			URI:    entry.Link,
+		}
+		if id != "" {
+			chapter.Slug = &id
+		}
+		if !published.IsZero() {
+			chapter.Date = &published
+		}
+		chapters = append(chapters, chapter)
+	}
+
+	sort.SliceStable(chapters, func(i, j int) bool {
+		return *chapters[i].Number > *chapters[j].Number
+	})
+	return chapters
+}
+
+func (fp *feedProvider) chapterNumber(title string) (float64, bool) {
+	for _, re := range fp.chapterPatterns {
+		matches := re.FindStringSubmatch(title)
+		if len(matches) < 2 {
+			continue
+		}
+		number, err := strconv.ParseFloat(matches[1], 64)
+		if err == nil {
+			return number, true
+		}
+	}
+	return 0, false
+}
+
+func compilePatterns(patterns []string) ([]*regexp.Regexp, error) {
+	compiled := make([]*regexp.Regexp, 0, len(patterns))
+	for _, p := range patterns {
+		re, err := regexp.Compile(p)
+		if err != nil {
+			return nil, err
+		}
+		compiled = append(compiled, re)
+	}
+	return compiled, nil
+}
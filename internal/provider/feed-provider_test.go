@@ -0,0 +1,116 @@
+package provider
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/ivan-penchev/manga-updates/internal/domain"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func newTestFeedProvider(t *testing.T, cfg FeedProviderConfig) (*feedProvider, *httptest.Server) {
+	t.Helper()
+	server := httptest.NewServer(http.FileServer(http.Dir("testdata/feed")))
+	t.Cleanup(server.Close)
+
+	p, err := NewFeedProviderFactory(cfg).New()
+	require.NoError(t, err)
+	return p.(*feedProvider), server
+}
+
+func TestFeedProvider_Supports(t *testing.T) {
+	p, _ := newTestFeedProvider(t, FeedProviderConfig{})
+
+	assert.True(t, p.Supports("https://scans.example.com/solo-leveling/feed"))
+	assert.True(t, p.Supports("https://scans.example.com/solo-leveling.rss"))
+	assert.True(t, p.Supports("https://publisher.example.com/kanmuri/atom"))
+	assert.False(t, p.Supports("https://mangadex.org/title/633d470a-4146-4dd3-b841-93dd648c23a5"))
+
+	custom, _ := newTestFeedProvider(t, FeedProviderConfig{URLPatterns: []string{`^https://custom\.example\.com/`}})
+	assert.True(t, custom.Supports("https://custom.example.com/series/1"))
+	assert.False(t, custom.Supports("https://scans.example.com/solo-leveling.rss"))
+}
+
+func TestFeedProvider_GetMangaFromURL_RSS(t *testing.T) {
+	p, server := newTestFeedProvider(t, FeedProviderConfig{})
+	url := server.URL + "/rss.xml"
+
+	manga, err := p.GetMangaFromURL(context.Background(), url)
+	require.NoError(t, err)
+
+	assert.Equal(t, "Solo Leveling", manga.Name)
+	assert.Equal(t, url, manga.Slug)
+	assert.Equal(t, domain.MangaSourceFeed, manga.Source)
+	assert.True(t, manga.ShouldNotify)
+	assert.Equal(t, time.Date(2025, time.March, 3, 10, 0, 0, 0, time.UTC), manga.LastUpdate)
+
+	require.Len(t, manga.Chapters, 3, "announcements are skipped and duplicates collapsed")
+	assert.Equal(t, 201.0, *manga.Chapters[0].Number)
+	assert.Equal(t, 200.5, *manga.Chapters[1].Number)
+	assert.Equal(t, 200.0, *manga.Chapters[2].Number)
+	assert.Equal(t, "https://scans.example.com/solo-leveling/chapter-200-v2", manga.Chapters[2].URI, "newest upload of a chapter wins")
+}
+
+func TestFeedProvider_GetMangaFromURL_Atom(t *testing.T) {
+	p, server := newTestFeedProvider(t, FeedProviderConfig{})
+
+	manga, err := p.GetMangaFromURL(context.Background(), server.URL+"/atom.xml")
+	require.NoError(t, err)
+
+	assert.Equal(t, "Kanmuri-san no Tokei Koubou", manga.Name)
+	require.Len(t, manga.Chapters, 2)
+	assert.Equal(t, 13.0, *manga.Chapters[0].Number)
+	assert.Equal(t, "https://publisher.example.com/kanmuri/13", manga.Chapters[0].URI)
+	assert.Equal(t, "urn:publisher:kanmuri:12", *manga.Chapters[1].Slug)
+	assert.Equal(t, time.Date(2025, time.February, 23, 8, 0, 0, 0, time.UTC), *manga.Chapters[1].Date)
+}
+
+func TestFeedProvider_CustomChapterPattern(t *testing.T) {
+	p, server := newTestFeedProvider(t, FeedProviderConfig{ChapterPatterns: []string{`Episode #(\d+)`}})
+
+	manga, err := p.GetMangaFromURL(context.Background(), server.URL+"/atom.xml")
+	require.NoError(t, err)
+	assert.Len(t, manga.Chapters, 2)
+
+	_, err = NewFeedProviderFactory(FeedProviderConfig{ChapterPatterns: []string{`Episode \d+`}}).New()
+	assert.Error(t, err, "patterns without a capture group are rejected")
+}
+
+func TestFeedProvider_IsNewerVersionAvailable(t *testing.T) {
+	p, server := newTestFeedProvider(t, FeedProviderConfig{})
+	url := server.URL + "/rss.xml"
+	ctx := context.Background()
+
+	manga, err := p.GetMangaFromURL(ctx, url)
+	require.NoError(t, err)
+
+	isNewer, err := p.IsNewerVersionAvailable(ctx, manga)
+	require.NoError(t, err)
+	assert.False(t, isNewer)
+
+	manga.Chapters = manga.Chapters[1:]
+	isNewer, err = p.IsNewerVersionAvailable(ctx, manga)
+	require.NoError(t, err)
+	assert.True(t, isNewer)
+
+	_, err = p.IsNewerVersionAvailable(ctx, domain.MangaEntity{
+		Slug:       server.URL + "/missing.xml",
+		LastUpdate: time.Now(),
+		Chapters:   manga.Chapters,
+	})
+	assert.Error(t, err)
+}
+
+func TestFeedProvider_HonorsCancellation(t *testing.T) {
+	p, _ := newTestFeedProvider(t, FeedProviderConfig{})
+	server := newBlockingServer(t)
+
+	assertCancelledPromptly(t, func(ctx context.Context) error {
+		_, err := p.GetMangaFromURL(ctx, server.URL+"/feed")
+		return err
+	})
+}
@@ -2,25 +2,94 @@ package provider
 
 import (
 	"context"
+	"encoding/json"
 	"errors"
 	"fmt"
 	"log/slog"
+	"net/http"
 	"net/url"
 	"regexp"
 	"sort"
 	"strconv"
 	"strings"
-	"sync"
 	"time"
 
 	m "github.com/darylhjd/mangodex"
+	"github.com/ivan-penchev/manga-updates/internal/cache"
 	"github.com/ivan-penchev/manga-updates/internal/domain"
+	"github.com/ivan-penchev/manga-updates/internal/httpcache"
+	"github.com/ivan-penchev/manga-updates/internal/ratelimit"
 )
 
+type MangaDexProviderConfig struct {
+	// RequestsPerSecond sent to the MangaDex API, no limit when zero
+	RequestsPerSecond float64
+	// CacheDir caches responses on disk and revalidates them with conditional requests, no caching when empty
+	CacheDir string
+	// Logger defaults to slog.Default()
+	Logger *slog.Logger
+}
+
 type mangaDexProvider struct {
-	mangaDexClient  *m.DexClient
-	cachedResponses map[string]*domain.MangaEntity
-	mutex           sync.RWMutex
+	// the requests are sent with this client rather than with the mangodex one, whose
+	// http.Client cannot be replaced, the responses are decoded into the mangodex types
+	httpClient *http.Client
+	apiURL     string
+	// latest holds the series whose chapters were listed, keyed by manga id
+	latest *cache.Cache[string, domain.MangaEntity]
+	logger *slog.Logger
+}
+
+// mangaDexMaxRetries of a throttled request, the transport honors their Retry-After header
+const mangaDexMaxRetries = 3
+
+// mangaDexStatusError is an error status answered by the API, reported the way the mangodex client does
+type mangaDexStatusError struct {
+	StatusCode int
+	Errors     string
+}
+
+func (e *mangaDexStatusError) Error() string {
+	if e.Errors == "" {
+		return fmt.Sprintf("non-200 status code -> (%d)", e.StatusCode)
+	}
+	return fmt.Sprintf("non-200 status code -> (%d) %s", e.StatusCode, e.Errors)
+}
+
+func (mdp *mangaDexProvider) getMangaList(ctx context.Context, params url.Values) (*m.MangaList, error) {
+	var res m.MangaList
+	err := mdp.get(ctx, m.MangaListPath, params, &res)
+	return &res, err
+}
+
+func (mdp *mangaDexProvider) getMangaChapters(ctx context.Context, id string, params url.Values) (*m.ChapterList, error) {
+	var res m.ChapterList
+	err := mdp.get(ctx, fmt.Sprintf(m.MangaChaptersPath, id), params, &res)
+	return &res, err
+}
+
+// get sends a GET request to the API and decodes the response into result, an error
+// status is returned as a *mangaDexStatusError.
+func (mdp *mangaDexProvider) get(ctx context.Context, path string, params url.Values, result any) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, mdp.apiURL+"/"+path+"?"+params.Encode(), nil)
+	if err != nil {
+		return err
+	}
+	res, err := mdp.httpClient.Do(req)
+	if err != nil {
+		return err
+	}
+	defer res.Body.Close()
+
+	if res.StatusCode != http.StatusOK {
+		statusErr := &mangaDexStatusError{StatusCode: res.StatusCode}
+		var errorResponse m.ErrorResponse
+		if err := json.NewDecoder(res.Body).Decode(&errorResponse); err == nil {
+			statusErr.Errors = errorResponse.GetErrors()
+		}
+		return statusErr
+	}
+	return json.NewDecoder(res.Body).Decode(result)
 }
 
 func (mdp *mangaDexProvider) Supports(url string) bool {
@@ -40,7 +109,7 @@ func (mdp *mangaDexProvider) GetMangaFromURL(ctx context.Context, u string) (dom
 	// Fetch details using GetMangaList with ID filter as GetManga might not exist or verify existence
 	v := url.Values{}
 	v.Add("ids[]", mangaID)
-	mangaList, err := mdp.mangaDexClient.Manga.GetMangaList(v)
+	mangaList, err := mdp.getMangaList(ctx, v)
 	if err != nil {
 		return domain.MangaEntity{}, fmt.Errorf("failed to fetch manga details: %w", err)
 	}
@@ -78,34 +147,50 @@ func (mdp *mangaDexProvider) GetMangaFromURL(ctx context.Context, u string) (dom
 	return *fetched, nil
 }
 
-func NewMangaDexProviderFactory() func() (domain.Provider, error) {
-	return func() (domain.Provider, error) {
-
-		c := m.NewDexClient()
-		v := url.Values{}
-		v.Add("title", "One Piece")
-
-		// check if the client is working
-		res, err := c.Manga.GetMangaList(v)
-
-		if err != nil || res.Data == nil || len(res.Data) == 0 {
-			return nil, errors.New("failed to create a new MangaDex client")
-		}
-
-		return &mangaDexProvider{
-			mangaDexClient:  c,
-			cachedResponses: make(map[string]*domain.MangaEntity, 0),
-			mutex:           sync.RWMutex{},
-		}, nil
+func NewMangaDexProviderFactory(cfg MangaDexProviderConfig) ProviderFactory {
+	return ProviderFactory{
+		Kind:        domain.MangaSourceMangaDex,
+		URLPatterns: []string{`mangadex\.org`},
+		Logger:      cfg.Logger,
+		New: func() (domain.Provider, error) {
+			return newMangaDexProvider(cfg, m.BaseAPI), nil
+		},
 	}
+}
 
+func newMangaDexProvider(cfg MangaDexProviderConfig, apiURL string) *mangaDexProvider {
+	logger := loggerOrDefault(cfg.Logger)
+	next := http.DefaultTransport
+	if cfg.CacheDir != "" {
+		next = httpcache.NewTransport(next, cfg.CacheDir, httpcache.WithLogger(logger))
+	}
+	return &mangaDexProvider{
+		httpClient: &http.Client{Transport: &ratelimit.Transport{
+			Next:       next,
+			Limiter:    ratelimit.NewLimiter(cfg.RequestsPerSecond, 1),
+			MaxRetries: mangaDexMaxRetries,
+			BaseDelay:  time.Second,
+			MaxDelay:   30 * time.Second,
+		}},
+		apiURL: apiURL,
+		latest: newLatestVersionCache(),
+		logger: logger,
+	}
 }
 
 // GetLatestVersionMangaEntity implements Provider.
 func (mdp *mangaDexProvider) GetLatestVersionMangaEntity(ctx context.Context, manga domain.MangaEntity) (*domain.MangaEntity, error) {
+	if latest, ok := mdp.latest.Get(manga.Slug); ok {
+		latest.Name = manga.Name
+		latest.ShouldNotify = manga.ShouldNotify
+		latest.Status = manga.Status
+		latest.Source = manga.Source
+		return &latest, nil
+	}
+
 	v := url.Values{}
 	v.Add("translatedLanguage[]", "en") // hardcode it for now
-	initialResponse, err := mdp.mangaDexClient.Chapter.GetMangaChapters(manga.Slug, v)
+	initialResponse, err := mdp.getMangaChapters(ctx, manga.Slug, v)
 
 	if err != nil || initialResponse.Data == nil || len(initialResponse.Data) == 0 {
 		return nil, errors.Join(err, errors.New("failed to get list of chapters for manga"))
@@ -114,8 +199,8 @@ func (mdp *mangaDexProvider) GetLatestVersionMangaEntity(ctx context.Context, ma
 	chapters := initialResponse.Data
 
 	for len(chapters) < initialResponse.Total {
-		v.Add("offset", strconv.Itoa(len(chapters)))
-		res, err := mdp.mangaDexClient.Chapter.GetMangaChapters(manga.Slug, v)
+		v.Set("offset", strconv.Itoa(len(chapters)))
+		res, err := mdp.getMangaChapters(ctx, manga.Slug, v)
 
 		if err != nil || res.Data == nil || len(res.Data) == 0 {
 			break
@@ -143,7 +228,7 @@ func (mdp *mangaDexProvider) GetLatestVersionMangaEntity(ctx context.Context, ma
 	if chapterEntities[0].Date != nil {
 		mangaUpdateTime = *chapterEntities[0].Date
 	}
-	return &domain.MangaEntity{
+	latest := domain.MangaEntity{
 		Name:         manga.Name,
 		ShouldNotify: manga.ShouldNotify,
 		LastUpdate:   mangaUpdateTime,
@@ -151,14 +236,25 @@ func (mdp *mangaDexProvider) GetLatestVersionMangaEntity(ctx context.Context, ma
 		Status:       manga.Status,
 		Source:       manga.Source,
 		Chapters:     chapterEntities,
-	}, nil
+	}
+	mdp.latest.Set(manga.Slug, latest)
+	return &latest, nil
 }
 
 // IsNewerVersionAvailable implements Provider.
 func (mdp *mangaDexProvider) IsNewerVersionAvailable(ctx context.Context, manga domain.MangaEntity) (bool, error) {
+	isNewer, err := mdp.isNewerVersionAvailable(ctx, manga)
+	if err != nil || isNewer {
+		// a cached chapter list predates the new chapter
+		mdp.latest.Invalidate(manga.Slug)
+	}
+	return isNewer, err
+}
+
+func (mdp *mangaDexProvider) isNewerVersionAvailable(ctx context.Context, manga domain.MangaEntity) (bool, error) {
 	if manga.IsNew() {
 		logMessage := fmt.Sprintf("Manga title (%s) that we have never synced before added for update notifications", manga.Name)
-		slog.Info(logMessage)
+		mdp.logger.Info(logMessage)
 		return true, nil
 	}
 
@@ -168,7 +264,7 @@ func (mdp *mangaDexProvider) IsNewerVersionAvailable(ctx context.Context, manga
 	v.Add("order[chapter]", "desc")
 
 	// Fetch the latest chapter for this manga from the API
-	chaptersRes, err := mdp.mangaDexClient.Chapter.GetMangaChapters(manga.Slug, v)
+	chaptersRes, err := mdp.getMangaChapters(ctx, manga.Slug, v)
 	if err != nil {
 		return false, fmt.Errorf("failed to fetch latest chapter: %w", err)
 	}
@@ -231,7 +327,7 @@ func (mdp *mangaDexProvider) Search(ctx context.Context, query string, offset in
 
 	v.Add("includes[]", "cover_art")
 
-	mangaList, err := mdp.mangaDexClient.Manga.GetMangaList(v)
+	mangaList, err := mdp.getMangaList(ctx, v)
 	if err != nil {
 		return nil, 0, fmt.Errorf("failed to search mangadex: %w", err)
 	}
@@ -324,3 +420,28 @@ func convertChapterToEntity(chapter m.Chapter) (domain.ChapterEntity, error) {
 		URI:    uri,
 	}, nil
 }
+
+// HealthCheck implements domain.HealthChecker.
+func (mdp *mangaDexProvider) HealthCheck(ctx context.Context) domain.HealthReport {
+	v := url.Values{}
+	v.Add("limit", "1")
+
+	start := time.Now()
+	res, err := mdp.getMangaList(ctx, v)
+	report := domain.HealthReport{Latency: time.Since(start)}
+
+	if err != nil {
+		report.Error = err.Error()
+		// the api itself answered with an error status
+		var statusErr *mangaDexStatusError
+		report.Reachable = errors.As(err, &statusErr)
+		return report
+	}
+
+	report.Reachable = true
+	report.SchemaValid = len(res.Data) > 0 && res.Data[0].ID != "" && res.Data[0].Type != ""
+	if !report.SchemaValid {
+		report.Error = "manga list response has no manga with an id and type"
+	}
+	return report
+}
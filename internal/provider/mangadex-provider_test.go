@@ -0,0 +1,90 @@
+package provider
+
+import (
+	"context"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestMangaDexProvider_RetriesThrottledRequestsAfterRetryAfter(t *testing.T) {
+	var calls int
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls++
+		if calls < 3 {
+			w.Header().Set("Retry-After", "0")
+			w.WriteHeader(http.StatusTooManyRequests)
+			return
+		}
+		_, _ = io.WriteString(w, `{"result":"ok","data":[],"total":0}`)
+	}))
+	defer server.Close()
+
+	// without the Retry-After header the backoff would wait for at least a second
+	mdp := newMangaDexProvider(MangaDexProviderConfig{}, server.URL)
+	start := time.Now()
+	_, err := mdp.getMangaList(context.Background(), url.Values{})
+	require.NoError(t, err)
+	assert.Equal(t, 3, calls)
+	assert.Less(t, time.Since(start), time.Second, "the delay of the server is honored")
+}
+
+func TestMangaDexProvider_GivesUpOnThrottledRequests(t *testing.T) {
+	var calls int
+	status := http.StatusTooManyRequests
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls++
+		w.Header().Set("Retry-After", "0")
+		w.WriteHeader(status)
+		_, _ = io.WriteString(w, `{"result":"error","errors":[{"title":"Too many requests"}]}`)
+	}))
+	defer server.Close()
+
+	mdp := newMangaDexProvider(MangaDexProviderConfig{}, server.URL)
+	err := mdp.get(context.Background(), "manga", url.Values{}, &struct{}{})
+	var statusErr *mangaDexStatusError
+	require.ErrorAs(t, err, &statusErr)
+	assert.Equal(t, http.StatusTooManyRequests, statusErr.StatusCode)
+	assert.ErrorContains(t, err, "non-200 status code -> (429) Too many requests")
+	assert.Equal(t, mangaDexMaxRetries+1, calls)
+
+	calls = 0
+	status = http.StatusNotFound
+	_, err = mdp.getMangaList(context.Background(), url.Values{})
+	assert.Error(t, err)
+	assert.Equal(t, 1, calls, "only throttled requests are retried")
+}
+
+func TestMangaDexProvider_ServesUnchangedResponsesFromCache(t *testing.T) {
+	var calls, notModified int
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls++
+		assert.Equal(t, "/manga/dex-id/feed", r.URL.Path)
+		if r.Header.Get("If-None-Match") == `"v1"` {
+			notModified++
+			w.WriteHeader(http.StatusNotModified)
+			return
+		}
+		w.Header().Set("ETag", `"v1"`)
+		_, _ = io.WriteString(w, `{"result":"ok","data":[{"id":"chapter-id","type":"chapter"}],"total":1}`)
+	}))
+	defer server.Close()
+
+	mdp := newMangaDexProvider(MangaDexProviderConfig{CacheDir: t.TempDir(), Logger: slog.New(slog.NewTextHandler(io.Discard, nil))}, server.URL)
+
+	for range 2 {
+		chapters, err := mdp.getMangaChapters(context.Background(), "dex-id", url.Values{})
+		require.NoError(t, err)
+		require.Len(t, chapters.Data, 1)
+		assert.Equal(t, "chapter-id", chapters.Data[0].ID)
+	}
+	assert.Equal(t, 2, calls)
+	assert.Equal(t, 1, notModified, "the second request is answered with a 304 served from the cache")
+}
@@ -2,105 +2,67 @@ package provider
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"log/slog"
+	"net/url"
 	"strings"
-	"sync"
 	"time"
 
-	"github.com/chromedp/cdproto/storage"
-	"github.com/chromedp/chromedp"
-	"github.com/chromedp/chromedp/device"
-	"github.com/go-rod/rod/lib/launcher"
+	"github.com/ivan-penchev/manga-updates/internal/cache"
 	"github.com/ivan-penchev/manga-updates/internal/domain"
 	manganelapiclient "github.com/ivan-penchev/manga-updates/internal/manganel-api-client"
+	"github.com/ivan-penchev/manga-updates/internal/ratelimit"
 )
 
 type MangaNelProviderConfig struct {
 	GraphQLEndpoint string
 	RemoteChromeURL string
+	// TokenCacheFile persists the access token across runs, no caching when empty
+	TokenCacheFile string
+	// RequestsPerSecond sent to the GraphQL API, no limit when zero
+	RequestsPerSecond float64
+	// CacheDir caches responses on disk and revalidates them with conditional requests, no caching when empty
+	CacheDir string
+	// Logger defaults to slog.Default()
+	Logger *slog.Logger
 }
 
-func NewMangaNelProviderFactory(cfg MangaNelProviderConfig) func() (domain.Provider, error) {
-	return func() (domain.Provider, error) {
-
-		// Increased timeout to allow for browser download if needed
-		ctx, cancel := context.WithTimeout(context.Background(), 2*time.Minute)
-		defer cancel()
-
-		var allocCtx context.Context
-		var cancelAlloc context.CancelFunc
-
-		remoteURL := cfg.RemoteChromeURL
-		if remoteURL != "" {
-			allocCtx, cancelAlloc = chromedp.NewRemoteAllocator(ctx, remoteURL)
-
-		} else {
-			// Always use managed browser to avoid issues with system installs (e.g. shims)
-			path, err := launcher.NewBrowser().Get()
-			if err != nil {
-				return nil, fmt.Errorf("failed to download/find browser: %w", err)
+func NewMangaNelProviderFactory(cfg MangaNelProviderConfig) ProviderFactory {
+	return ProviderFactory{
+		Kind:        domain.MangaSourceMangaNel,
+		URLPatterns: []string{`manganel\.me`},
+		Logger:      cfg.Logger,
+		New: func() (domain.Provider, error) {
+			ctx, cancel := context.WithTimeout(context.Background(), 2*time.Minute)
+			defer cancel()
+
+			logger := loggerOrDefault(cfg.Logger)
+			tokens := newMangaNelTokenSource(cfg.TokenCacheFile, cfg.RemoteChromeURL, logger)
+			if _, err := tokens.Token(ctx); err != nil {
+				logger.Warn("failed to find manganel access cookie", "error", err)
+				return nil, err
 			}
 
-			opts := append(chromedp.DefaultExecAllocatorOptions[:],
-				chromedp.ExecPath(path),
-				chromedp.Flag("no-sandbox", true),
-				chromedp.Flag("headless", true),
-				chromedp.Flag("disable-gpu", true),
-				chromedp.Flag("disable-dev-shm-usage", true),
-			)
-			allocCtx, cancelAlloc = chromedp.NewExecAllocator(ctx, opts...)
-		}
-
-		defer cancelAlloc()
-
-		// Create the chromedp context from the allocator
-		innerCtx, cancelInner := chromedp.NewContext(allocCtx)
-		defer cancelInner()
-		pageToOpen := "https://manganel.me/"
-
-		// navigate to a page, wait for an element, click
-		var mhubApiAccessToken string
-		err := chromedp.Run(innerCtx,
-			chromedp.Emulate(device.IPhone15Pro),
-			chromedp.Navigate(pageToOpen),
-			chromedp.WaitVisible("body", chromedp.ByQuery),
-			chromedp.ActionFunc(func(ctx context.Context) error {
-				cookies, err := storage.GetCookies().Do(ctx)
-				if err != nil {
-					return err
-				}
-
-				for _, cookie := range cookies {
-					if cookie.Name == "mhub_access" {
-						mhubApiAccessToken = cookie.Value
-					}
-				}
-
-				return nil
-			}),
-		)
-
-		if mhubApiAccessToken == "" || err != nil {
-			slog.Warn("failed to find manganel access cookie", "error", err)
-			return nil, err
-		}
-
-		mangaNelClient := manganelapiclient.NewMangaNelAPIClient(cfg.GraphQLEndpoint, mhubApiAccessToken)
-
-		return &mangaNelProvider{
-			mangaNelClient:  mangaNelClient,
-			cachedResponses: make(map[string]*domain.MangaEntity, 0),
-			mutex:           sync.RWMutex{},
-		}, nil
+			mangaNelClient := manganelapiclient.NewMangaNelAPIClient(cfg.GraphQLEndpoint, tokens,
+				manganelapiclient.WithRateLimiter(ratelimit.NewLimiter(cfg.RequestsPerSecond, 1)),
+				manganelapiclient.WithHTTPCacheDir(cfg.CacheDir),
+				manganelapiclient.WithLogger(logger))
+
+			return &mangaNelProvider{
+				mangaNelClient: mangaNelClient,
+				latest:         newLatestVersionCache(),
+				logger:         logger,
+			}, nil
+		},
 	}
-
 }
 
 type mangaNelProvider struct {
-	mangaNelClient  *manganelapiclient.MangaNelAPIClient
-	cachedResponses map[string]*domain.MangaEntity
-	mutex           sync.RWMutex
+	mangaNelClient *manganelapiclient.MangaNelAPIClient
+	// latest holds the series fetched while checking for updates, keyed by slug
+	latest *cache.Cache[string, domain.MangaEntity]
+	logger *slog.Logger
 }
 
 func (mp *mangaNelProvider) Supports(url string) bool {
@@ -152,24 +114,22 @@ func (mp *mangaNelProvider) GetMangaFromURL(ctx context.Context, u string) (doma
 func (mp *mangaNelProvider) IsNewerVersionAvailable(ctx context.Context, manga domain.MangaEntity) (bool, error) {
 	if manga.IsNew() {
 		logMessage := fmt.Sprintf("Manga title (%s) that we have never synced before added for update notifications", manga.Name)
-		slog.Info(logMessage)
+		mp.logger.Info(logMessage)
 		return true, nil
 	}
 
-	mangaResponse, err := mp.mangaNelClient.GetMangaSeriesFull(context.Background(), manga.Slug)
-
+	// probe without the chapter list, it is only fetched once the series changed
+	probe, err := mp.mangaNelClient.GetMangaSeriesShort(ctx, manga.Slug)
 	if err != nil {
+		mp.latest.Invalidate(manga.Slug)
 		return false, err
 	}
 
-	mangaResponse.ShouldNotify = manga.ShouldNotify
-
-	mp.mutex.Lock()
-	defer mp.mutex.Unlock()
-
-	mp.cachedResponses[manga.Slug] = mangaResponse
-
-	return manga.IsOlder(*mangaResponse), nil
+	isNewer := manga.IsOlder(*probe)
+	if cached, ok := mp.latest.Get(manga.Slug); ok && cached.IsOlder(*probe) {
+		mp.latest.Invalidate(manga.Slug)
+	}
+	return isNewer, nil
 }
 
 func (mnp *mangaNelProvider) Search(ctx context.Context, query string, offset int) ([]domain.SearchResult, int, error) {
@@ -203,17 +163,41 @@ func (*mangaNelProvider) Kind() domain.MangaSource {
 }
 
 func (mp *mangaNelProvider) GetLatestVersionMangaEntity(ctx context.Context, manga domain.MangaEntity) (*domain.MangaEntity, error) {
-	mp.mutex.RLock()
-	defer mp.mutex.RUnlock()
-
-	if cachedManga, ok := mp.cachedResponses[manga.Slug]; ok {
-		return cachedManga, nil
+	latest, ok := mp.latest.Get(manga.Slug)
+	if !ok {
+		mangaResponse, err := mp.mangaNelClient.GetMangaSeriesFull(ctx, manga.Slug)
+		if err != nil {
+			return nil, err
+		}
+		mp.latest.Set(manga.Slug, *mangaResponse)
+		latest = *mangaResponse
 	}
 
-	mangaResponse, err := mp.mangaNelClient.GetMangaSeriesFull(context.Background(), manga.Slug)
+	latest.ShouldNotify = manga.ShouldNotify
+	return &latest, nil
+}
+
+// HealthCheck implements domain.HealthChecker.
+func (mp *mangaNelProvider) HealthCheck(ctx context.Context) domain.HealthReport {
+	start := time.Now()
+	res, err := mp.mangaNelClient.Search(ctx, "one", 0)
+	report := domain.HealthReport{Latency: time.Since(start)}
+
+	authValid := !errors.Is(err, manganelapiclient.ErrUnauthorized)
+	report.AuthValid = &authValid
+
 	if err != nil {
-		return nil, err
+		report.Error = err.Error()
+		// a rejected token or a graphql error still means the api answered
+		var urlErr *url.Error
+		report.Reachable = !errors.As(err, &urlErr) || errors.Is(err, manganelapiclient.ErrUnauthorized)
+		return report
+	}
+
+	report.Reachable = true
+	report.SchemaValid = res.Search.Count > 0 && len(res.Search.Rows) > 0 && res.Search.Rows[0].Slug != ""
+	if !report.SchemaValid {
+		report.Error = "search response has no rows with a slug"
 	}
-	mangaResponse.ShouldNotify = manga.ShouldNotify
-	return mangaResponse, nil
+	return report
 }
@@ -0,0 +1,124 @@
+package provider
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/ivan-penchev/manga-updates/internal/domain"
+	manganelapiclient "github.com/ivan-penchev/manga-updates/internal/manganel-api-client"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// newBlockingServer answers no request, it only returns once the client gives up
+func newBlockingServer(t *testing.T) *httptest.Server {
+	t.Helper()
+	release := make(chan struct{})
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		select {
+		case <-r.Context().Done():
+		case <-release:
+		}
+	}))
+	// cleanups run last in first out, handlers are released before closing the server
+	t.Cleanup(server.Close)
+	t.Cleanup(func() { close(release) })
+	return server
+}
+
+// assertCancelledPromptly cancels the context shortly after call starts and
+// checks call returns the cancellation well before any client timeout.
+func assertCancelledPromptly(t *testing.T, call func(ctx context.Context) error) {
+	t.Helper()
+	ctx, cancel := context.WithCancel(context.Background())
+	time.AfterFunc(50*time.Millisecond, cancel)
+
+	start := time.Now()
+	err := call(ctx)
+	assert.ErrorIs(t, err, context.Canceled)
+	assert.Less(t, time.Since(start), 2*time.Second)
+}
+
+func TestMangaNelProvider_HonorsCancellation(t *testing.T) {
+	server := newBlockingServer(t)
+	mp := &mangaNelProvider{
+		mangaNelClient: manganelapiclient.NewMangaNelAPIClient(server.URL, manganelapiclient.StaticToken("token")),
+		latest:         newLatestVersionCache(),
+		logger:         slog.New(slog.NewTextHandler(io.Discard, nil)),
+	}
+	manga := domain.MangaEntity{Name: "Solo Leveling", Slug: "solo-leveling", Source: domain.MangaSourceMangaNel, LastUpdate: time.Now()}
+
+	assertCancelledPromptly(t, func(ctx context.Context) error {
+		_, err := mp.IsNewerVersionAvailable(ctx, manga)
+		return err
+	})
+	assertCancelledPromptly(t, func(ctx context.Context) error {
+		_, err := mp.GetLatestVersionMangaEntity(ctx, manga)
+		return err
+	})
+	assertCancelledPromptly(t, func(ctx context.Context) error {
+		_, _, err := mp.Search(ctx, "solo", 0)
+		return err
+	})
+}
+
+func TestMangaNelProvider_ProbesBeforeFetchingChapters(t *testing.T) {
+	var probes, fullFetches, latestChapter int
+	latestChapter = 1
+	updated := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		if strings.Contains(string(body), "chapters") {
+			fullFetches++
+		} else {
+			probes++
+		}
+		chapters := []map[string]any{}
+		for n := latestChapter; n >= 1; n-- {
+			chapters = append(chapters, map[string]any{"number": float64(n), "date": updated.Format(time.RFC3339)})
+		}
+		_ = json.NewEncoder(w).Encode(map[string]any{"data": map[string]any{"manga": map[string]any{
+			"title": "Solo Leveling", "slug": "solo-leveling", "status": "ongoing",
+			"updatedDate": updated.Format(time.RFC3339), "chapters": chapters,
+		}}})
+	}))
+	defer server.Close()
+
+	mp := &mangaNelProvider{
+		mangaNelClient: manganelapiclient.NewMangaNelAPIClient(server.URL, manganelapiclient.StaticToken("token")),
+		latest:         newLatestVersionCache(),
+		logger:         slog.New(slog.NewTextHandler(io.Discard, nil)),
+	}
+	ctx := context.Background()
+	manga := domain.MangaEntity{Name: "Solo Leveling", Slug: "solo-leveling", Source: domain.MangaSourceMangaNel, LastUpdate: updated}
+
+	isNewer, err := mp.IsNewerVersionAvailable(ctx, manga)
+	require.NoError(t, err)
+	assert.False(t, isNewer)
+	assert.Equal(t, 1, probes)
+	assert.Equal(t, 0, fullFetches, "the chapter list is not downloaded when nothing changed")
+
+	latest, err := mp.GetLatestVersionMangaEntity(ctx, manga)
+	require.NoError(t, err)
+	assert.Len(t, latest.Chapters, 1)
+	_, err = mp.GetLatestVersionMangaEntity(ctx, manga)
+	require.NoError(t, err)
+	assert.Equal(t, 1, fullFetches, "the latest version is cached")
+
+	latestChapter = 2
+	updated = updated.Add(time.Hour)
+	isNewer, err = mp.IsNewerVersionAvailable(ctx, manga)
+	require.NoError(t, err)
+	assert.True(t, isNewer)
+	latest, err = mp.GetLatestVersionMangaEntity(ctx, manga)
+	require.NoError(t, err)
+	assert.Len(t, latest.Chapters, 2, "a check detecting a change invalidates the cached series")
+	assert.Equal(t, 2, fullFetches)
+}
@@ -0,0 +1,260 @@
+package provider
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"net/http/cookiejar"
+	"net/url"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+
+	"github.com/chromedp/cdproto/storage"
+	"github.com/chromedp/chromedp"
+	"github.com/chromedp/chromedp/device"
+	"github.com/go-rod/rod/lib/launcher"
+	manganelapiclient "github.com/ivan-penchev/manga-updates/internal/manganel-api-client"
+)
+
+const (
+	mangaNelHomepage        = "https://manganel.me/"
+	mangaNelTokenCookie     = "mhub_access"
+	defaultMangaNelTokenTTL = 12 * time.Hour
+)
+
+// mangaNelToken is the access token persisted in the cache file
+type mangaNelToken struct {
+	Value     string    `json:"value"`
+	ExpiresAt time.Time `json:"expiresAt"`
+}
+
+func (t *mangaNelToken) valid() bool {
+	return t != nil && t.Value != "" && time.Now().Before(t.ExpiresAt)
+}
+
+// mangaNelTokenSource acquires the mhub_access cookie, first with a plain HTTP request
+// and falling back to a headless browser, and persists it across runs in a cache file.
+type mangaNelTokenSource struct {
+	cacheFile  string
+	homepage   string
+	httpClient *http.Client
+	// browser acquires the token with a headless browser, used when the HTTP flow fails
+	browser func(ctx context.Context) (*mangaNelToken, error)
+
+	logger *slog.Logger
+
+	mutex sync.Mutex
+	token *mangaNelToken
+}
+
+var _ manganelapiclient.TokenSource = (*mangaNelTokenSource)(nil)
+
+func newMangaNelTokenSource(cacheFile string, remoteChromeURL string, logger *slog.Logger) *mangaNelTokenSource {
+	return &mangaNelTokenSource{
+		logger:     logger,
+		cacheFile:  cacheFile,
+		homepage:   mangaNelHomepage,
+		httpClient: &http.Client{Timeout: 15 * time.Second},
+		browser: func(ctx context.Context) (*mangaNelToken, error) {
+			return acquireMangaNelTokenWithBrowser(ctx, remoteChromeURL)
+		},
+	}
+}
+
+// Token implements manganelapiclient.TokenSource
+func (ts *mangaNelTokenSource) Token(ctx context.Context) (string, error) {
+	ts.mutex.Lock()
+	defer ts.mutex.Unlock()
+
+	if ts.token.valid() {
+		return ts.token.Value, nil
+	}
+
+	if cached := ts.loadCache(); cached.valid() {
+		ts.token = cached
+		return cached.Value, nil
+	}
+
+	return ts.acquire(ctx)
+}
+
+// Refresh implements manganelapiclient.TokenSource
+func (ts *mangaNelTokenSource) Refresh(ctx context.Context) (string, error) {
+	ts.mutex.Lock()
+	defer ts.mutex.Unlock()
+
+	ts.token = nil
+	return ts.acquire(ctx)
+}
+
+func (ts *mangaNelTokenSource) acquire(ctx context.Context) (string, error) {
+	token, err := ts.acquireWithHTTP(ctx)
+	if err != nil {
+		ts.logger.Info("failed to get manganel access token over http, falling back to a browser", "error", err)
+
+		var browserErr error
+		token, browserErr = ts.browser(ctx)
+		if browserErr != nil {
+			return "", errors.Join(err, browserErr)
+		}
+	}
+
+	ts.token = token
+	ts.saveCache(token)
+	return token.Value, nil
+}
+
+func (ts *mangaNelTokenSource) acquireWithHTTP(ctx context.Context) (*mangaNelToken, error) {
+	jar, err := cookiejar.New(nil)
+	if err != nil {
+		return nil, err
+	}
+	client := *ts.httpClient
+	client.Jar = jar
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, ts.homepage, nil)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("User-Agent", manganelapiclient.UserAgent)
+	req.Header.Set("Accept", "text/html,application/xhtml+xml")
+
+	res, err := client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer func() { _ = res.Body.Close() }()
+
+	// the cookie can be set by any response of the redirect chain,
+	// Set-Cookie headers of the final response carry the expiry.
+	var expiresAt time.Time
+	for _, cookie := range res.Cookies() {
+		if cookie.Name == mangaNelTokenCookie {
+			expiresAt = cookieExpiry(cookie)
+		}
+	}
+
+	homepage, _ := url.Parse(ts.homepage)
+	for _, cookie := range jar.Cookies(homepage) {
+		if cookie.Name == mangaNelTokenCookie && cookie.Value != "" {
+			if expiresAt.IsZero() {
+				expiresAt = time.Now().Add(defaultMangaNelTokenTTL)
+			}
+			return &mangaNelToken{Value: cookie.Value, ExpiresAt: expiresAt}, nil
+		}
+	}
+
+	return nil, fmt.Errorf("%s cookie not set by %s (status code %d)", mangaNelTokenCookie, ts.homepage, res.StatusCode)
+}
+
+func cookieExpiry(cookie *http.Cookie) time.Time {
+	if cookie.MaxAge > 0 {
+		return time.Now().Add(time.Duration(cookie.MaxAge) * time.Second)
+	}
+	if !cookie.Expires.IsZero() {
+		return cookie.Expires
+	}
+	return time.Time{}
+}
+
+func (ts *mangaNelTokenSource) loadCache() *mangaNelToken {
+	if ts.cacheFile == "" {
+		return nil
+	}
+	content, err := os.ReadFile(ts.cacheFile)
+	if err != nil {
+		return nil
+	}
+	var token mangaNelToken
+	if err := json.Unmarshal(content, &token); err != nil {
+		ts.logger.Warn("ignoring malformed manganel token cache", "file", ts.cacheFile, "error", err)
+		return nil
+	}
+	return &token
+}
+
+func (ts *mangaNelTokenSource) saveCache(token *mangaNelToken) {
+	if ts.cacheFile == "" {
+		return
+	}
+	content, _ := json.Marshal(token)
+	if err := os.MkdirAll(filepath.Dir(ts.cacheFile), 0700); err != nil {
+		ts.logger.Warn("failed to create manganel token cache directory", "file", ts.cacheFile, "error", err)
+		return
+	}
+	if err := os.WriteFile(ts.cacheFile, content, 0600); err != nil {
+		ts.logger.Warn("failed to write manganel token cache", "file", ts.cacheFile, "error", err)
+	}
+}
+
+func acquireMangaNelTokenWithBrowser(ctx context.Context, remoteURL string) (*mangaNelToken, error) {
+	// Increased timeout to allow for browser download if needed
+	ctx, cancel := context.WithTimeout(ctx, 2*time.Minute)
+	defer cancel()
+
+	var allocCtx context.Context
+	var cancelAlloc context.CancelFunc
+
+	if remoteURL != "" {
+		allocCtx, cancelAlloc = chromedp.NewRemoteAllocator(ctx, remoteURL)
+
+	} else {
+		// Always use managed browser to avoid issues with system installs (e.g. shims)
+		path, err := launcher.NewBrowser().Get()
+		if err != nil {
+			return nil, fmt.Errorf("failed to download/find browser: %w", err)
+		}
+
+		opts := append(chromedp.DefaultExecAllocatorOptions[:],
+			chromedp.ExecPath(path),
+			chromedp.Flag("no-sandbox", true),
+			chromedp.Flag("headless", true),
+			chromedp.Flag("disable-gpu", true),
+			chromedp.Flag("disable-dev-shm-usage", true),
+		)
+		allocCtx, cancelAlloc = chromedp.NewExecAllocator(ctx, opts...)
+	}
+
+	defer cancelAlloc()
+
+	// Create the chromedp context from the allocator
+	innerCtx, cancelInner := chromedp.NewContext(allocCtx)
+	defer cancelInner()
+
+	// navigate to a page, wait for an element, click
+	var token *mangaNelToken
+	err := chromedp.Run(innerCtx,
+		chromedp.Emulate(device.IPhone15Pro),
+		chromedp.Navigate(mangaNelHomepage),
+		chromedp.WaitVisible("body", chromedp.ByQuery),
+		chromedp.ActionFunc(func(ctx context.Context) error {
+			cookies, err := storage.GetCookies().Do(ctx)
+			if err != nil {
+				return err
+			}
+
+			for _, cookie := range cookies {
+				if cookie.Name == mangaNelTokenCookie {
+					expiresAt := time.Now().Add(defaultMangaNelTokenTTL)
+					if !cookie.Session && cookie.Expires > 0 {
+						expiresAt = time.Unix(int64(cookie.Expires), 0)
+					}
+					token = &mangaNelToken{Value: cookie.Value, ExpiresAt: expiresAt}
+				}
+			}
+
+			return nil
+		}),
+	)
+
+	if token == nil || token.Value == "" || err != nil {
+		return nil, errors.Join(fmt.Errorf("failed to find manganel access cookie"), err)
+	}
+
+	return token, nil
+}
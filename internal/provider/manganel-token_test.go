@@ -0,0 +1,90 @@
+package provider
+
+import (
+	"context"
+	"errors"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func newTestMangaNelTokenSource(t *testing.T, cacheFile string, handler http.HandlerFunc) (*mangaNelTokenSource, *atomic.Int32) {
+	t.Helper()
+	server := httptest.NewServer(handler)
+	t.Cleanup(server.Close)
+
+	browserCalls := &atomic.Int32{}
+	ts := newMangaNelTokenSource(cacheFile, "", slog.New(slog.NewTextHandler(io.Discard, nil)))
+	ts.homepage = server.URL + "/"
+	ts.browser = func(ctx context.Context) (*mangaNelToken, error) {
+		browserCalls.Add(1)
+		return &mangaNelToken{Value: "from-browser", ExpiresAt: time.Now().Add(time.Hour)}, nil
+	}
+	return ts, browserCalls
+}
+
+func TestMangaNelTokenSource_HTTPFlowAndCache(t *testing.T) {
+	cacheFile := filepath.Join(t.TempDir(), "token.json")
+	var homepageCalls atomic.Int32
+	handler := func(w http.ResponseWriter, r *http.Request) {
+		homepageCalls.Add(1)
+		http.SetCookie(w, &http.Cookie{Name: mangaNelTokenCookie, Value: "from-http", Path: "/", MaxAge: 3600})
+	}
+
+	ts, browserCalls := newTestMangaNelTokenSource(t, cacheFile, handler)
+	token, err := ts.Token(context.Background())
+	require.NoError(t, err)
+	assert.Equal(t, "from-http", token)
+	assert.Zero(t, browserCalls.Load())
+
+	// a new process reuses the cached token without any request
+	next, _ := newTestMangaNelTokenSource(t, cacheFile, handler)
+	token, err = next.Token(context.Background())
+	require.NoError(t, err)
+	assert.Equal(t, "from-http", token)
+	assert.Equal(t, int32(1), homepageCalls.Load())
+	assert.WithinDuration(t, time.Now().Add(time.Hour), next.token.ExpiresAt, time.Minute)
+
+	// refreshing acquires a new token regardless of the cache
+	_, err = next.Refresh(context.Background())
+	require.NoError(t, err)
+	assert.Equal(t, int32(2), homepageCalls.Load())
+}
+
+func TestMangaNelTokenSource_ExpiredCacheIsIgnored(t *testing.T) {
+	cacheFile := filepath.Join(t.TempDir(), "token.json")
+	ts, _ := newTestMangaNelTokenSource(t, cacheFile, func(w http.ResponseWriter, r *http.Request) {
+		http.SetCookie(w, &http.Cookie{Name: mangaNelTokenCookie, Value: "fresh", Path: "/"})
+	})
+	ts.saveCache(&mangaNelToken{Value: "stale", ExpiresAt: time.Now().Add(-time.Minute)})
+
+	token, err := ts.Token(context.Background())
+	require.NoError(t, err)
+	assert.Equal(t, "fresh", token)
+	assert.WithinDuration(t, time.Now().Add(defaultMangaNelTokenTTL), ts.token.ExpiresAt, time.Minute)
+}
+
+func TestMangaNelTokenSource_FallsBackToBrowser(t *testing.T) {
+	ts, browserCalls := newTestMangaNelTokenSource(t, "", func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusForbidden)
+	})
+
+	token, err := ts.Token(context.Background())
+	require.NoError(t, err)
+	assert.Equal(t, "from-browser", token)
+	assert.Equal(t, int32(1), browserCalls.Load())
+
+	ts.browser = func(ctx context.Context) (*mangaNelToken, error) {
+		return nil, errors.New("browser unavailable")
+	}
+	_, err = ts.Refresh(context.Background())
+	assert.ErrorContains(t, err, "browser unavailable")
+}
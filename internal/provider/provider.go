@@ -4,40 +4,99 @@ import (
 	"errors"
 	"fmt"
 	"log/slog"
+	"time"
 
+	"github.com/ivan-penchev/manga-updates/internal/cache"
 	"github.com/ivan-penchev/manga-updates/internal/domain"
 )
 
+// ErrSearchNotSupported is returned by providers whose source cannot be searched
+var ErrSearchNotSupported = errors.New("search is not supported by this provider")
+
+// Latest versions fetched by the providers are cached so checking a series and then
+// fetching its latest version costs a single request, the ttl keeps long-lived
+// processes from serving stale series. The cached series are copied in and out as
+// the update checker modifies the series it gets in place.
+const (
+	latestVersionCacheTTL  = 10 * time.Minute
+	latestVersionCacheSize = 1000
+)
+
+func newLatestVersionCache() *cache.Cache[string, domain.MangaEntity] {
+	return cache.New(latestVersionCacheTTL, latestVersionCacheSize, cache.WithCopy[string](func(m domain.MangaEntity) domain.MangaEntity {
+		return m.Clone()
+	}))
+}
+
+// ProviderFactory registers a provider with the router,
+// the provider is only created the first time it is needed.
+type ProviderFactory struct {
+	// Kind is the source served by the provider, when empty the provider
+	// has to be created to find out which source it serves.
+	Kind domain.MangaSource
+	// URLPatterns are regular expressions matching the urls supported by the provider,
+	// they route urls without creating the provider. When empty the provider is
+	// created to answer Supports.
+	URLPatterns []string
+	New         func() (domain.Provider, error)
+	// Logger receives the logs of the router about the provider, slog.Default() when nil
+	Logger *slog.Logger
+}
+
 // Create a new router and sets one provider per source
-func NewProviderRouter(providerFactories ...func() (domain.Provider, error)) (domain.ProviderRouter, error) {
+func NewProviderRouter(providerFactories ...ProviderFactory) (domain.ProviderRouter, error) {
 	if len(providerFactories) == 0 {
 		return nil, fmt.Errorf("no provider factories provided")
 	}
-	providersMap := make(map[domain.MangaSource]domain.Provider)
-	var initErrors error
+
+	router := &providerRouter{
+		byKind: make(map[domain.MangaSource]*providerEntry),
+	}
 
 	for _, factory := range providerFactories {
-		provider, err := factory()
+		entry := &providerEntry{factory: factory}
+		patterns, err := compilePatterns(factory.URLPatterns)
 		if err != nil {
-			initErrors = errors.Join(initErrors, err)
-			continue
+			// the provider is registered as unavailable, the other providers keep working
+			entry.invalid = fmt.Errorf("invalid url pattern: %w", err)
+			loggerOrDefault(factory.Logger).Warn("provider has an invalid url pattern", "providerKind", factory.Kind, "error", err)
 		}
+		entry.patterns = patterns
 
-		source := provider.Kind()
-		if _, exists := providersMap[source]; exists {
-			// Warn about duplicate providers but do not treat as a critical error
-			slog.Warn("Trying to add a provider that already exists", "providerKind", source)
-			continue
+		if factory.Kind != "" {
+			if _, exists := router.byKind[factory.Kind]; exists {
+				// Warn about duplicate providers but do not treat as a critical error
+				loggerOrDefault(factory.Logger).Warn("Trying to add a provider that already exists", "providerKind", factory.Kind)
+				continue
+			}
+			router.byKind[factory.Kind] = entry
 		}
 
-		providersMap[source] = provider
+		router.entries = append(router.entries, entry)
+	}
+
+	return router, nil
+}
+
+// loggerOrDefault returns logger, or slog.Default() when it is nil
+func loggerOrDefault(logger *slog.Logger) *slog.Logger {
+	if logger == nil {
+		return slog.Default()
 	}
+	return logger
+}
 
-	if initErrors != nil {
-		return nil, initErrors
+// hasNewerChapter reports whether the newest chapter of latest is missing from manga
+func hasNewerChapter(manga domain.MangaEntity, latest domain.MangaEntity) bool {
+	if len(latest.Chapters) == 0 || latest.Chapters[0].Number == nil {
+		return false
 	}
 
-	return &providerRouter{
-		providers: providersMap,
-	}, nil
+	newest := *latest.Chapters[0].Number
+	for _, c := range manga.Chapters {
+		if c.Number != nil && *c.Number == newest {
+			return false
+		}
+	}
+	return true
 }
@@ -2,27 +2,144 @@ package provider
 
 import (
 	"fmt"
+	"regexp"
+	"sync"
 
 	"github.com/ivan-penchev/manga-updates/internal/domain"
 )
 
 type providerRouter struct {
-	providers map[domain.MangaSource]domain.Provider
+	// entries keeps the registration order, used when routing urls
+	entries []*providerEntry
+
+	mutex  sync.Mutex
+	byKind map[domain.MangaSource]*providerEntry
+}
+
+// providerEntry lazily creates a provider, exactly once
+type providerEntry struct {
+	factory  ProviderFactory
+	patterns []*regexp.Regexp
+	// invalid is the error of a factory that cannot be used, e.g. with an invalid url pattern,
+	// the provider is never created and the entry does not route any url
+	invalid error
+
+	once     sync.Once
+	provider domain.Provider
+	err      error
+}
+
+func (e *providerEntry) get() (domain.Provider, error) {
+	if e.invalid != nil {
+		return nil, e.invalid
+	}
+	e.once.Do(func() {
+		loggerOrDefault(e.factory.Logger).Debug("Initializing provider", "providerKind", e.factory.Kind)
+		e.provider, e.err = e.factory.New()
+		if e.err == nil && e.factory.Kind != "" && e.provider.Kind() != e.factory.Kind {
+			e.provider, e.err = nil, fmt.Errorf("provider registered as %s reports kind %s", e.factory.Kind, e.provider.Kind())
+		}
+	})
+	return e.provider, e.err
+}
+
+// available returns the provider, or a ProviderUnavailableError with the cause of its failed initialization
+func (e *providerEntry) available() (domain.Provider, error) {
+	provider, err := e.get()
+	if err != nil {
+		return nil, &domain.ProviderUnavailableError{Kind: e.factory.Kind, Cause: err}
+	}
+	return provider, nil
+}
+
+func (e *providerEntry) supports(url string) (bool, error) {
+	if len(e.patterns) > 0 {
+		for _, re := range e.patterns {
+			if re.MatchString(url) {
+				return true, nil
+			}
+		}
+		return false, nil
+	}
+
+	provider, err := e.get()
+	if err != nil {
+		return false, err
+	}
+	return provider.Supports(url), nil
 }
 
 func (p *providerRouter) GetProvider(manga domain.MangaEntity) (domain.Provider, error) {
-	provider, ok := p.providers[manga.Source]
+	entry, ok := p.entryForKind(manga.Source)
 	if !ok {
 		return nil, fmt.Errorf("provider for %s not found", manga.Source)
 	}
-	return provider, nil
+
+	return entry.available()
 }
 
 func (p *providerRouter) GetProviderForURL(url string) (domain.Provider, error) {
-	for _, provider := range p.providers {
-		if provider.Supports(url) {
-			return provider, nil
+	for _, entry := range p.entries {
+		ok, err := entry.supports(url)
+		if err != nil {
+			loggerOrDefault(entry.factory.Logger).Warn("provider failed to initialize while routing url", "providerKind", entry.factory.Kind, "url", url, "error", err)
+			continue
+		}
+		if !ok {
+			continue
 		}
+
+		return entry.available()
 	}
 	return nil, fmt.Errorf("no provider found for url: %s", url)
 }
+
+func (p *providerRouter) Providers() []domain.ProviderInfo {
+	infos := make([]domain.ProviderInfo, 0, len(p.entries))
+	for _, entry := range p.entries {
+		info := domain.ProviderInfo{
+			Kind:        entry.factory.Kind,
+			URLPatterns: entry.factory.URLPatterns,
+		}
+		if entry.invalid != nil {
+			info.InitError = entry.invalid
+		} else if info.Kind == "" {
+			provider, err := entry.get()
+			if err != nil {
+				info.InitError = err
+			} else {
+				info.Kind = provider.Kind()
+			}
+		}
+		infos = append(infos, info)
+	}
+	return infos
+}
+
+// entryForKind finds the entry registered for a source, creating providers
+// registered without a kind until one reports the source.
+func (p *providerRouter) entryForKind(kind domain.MangaSource) (*providerEntry, bool) {
+	p.mutex.Lock()
+	defer p.mutex.Unlock()
+
+	if entry, ok := p.byKind[kind]; ok {
+		return entry, true
+	}
+
+	for _, entry := range p.entries {
+		if entry.factory.Kind != "" {
+			continue
+		}
+		provider, err := entry.get()
+		if err != nil {
+			continue
+		}
+		if _, exists := p.byKind[provider.Kind()]; !exists {
+			p.byKind[provider.Kind()] = entry
+		}
+		if provider.Kind() == kind {
+			return entry, true
+		}
+	}
+	return nil, false
+}
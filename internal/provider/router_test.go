@@ -1,50 +1,175 @@
 package provider
 
 import (
+	"errors"
 	"testing"
 
 	"github.com/ivan-penchev/manga-updates/internal/domain"
 	"github.com/ivan-penchev/manga-updates/internal/mocks"
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 )
 
+// countingFactory registers a provider and counts how many times it was created
+func countingFactory(kind domain.MangaSource, patterns []string, provider domain.Provider, err error, calls *int) ProviderFactory {
+	return ProviderFactory{
+		Kind:        kind,
+		URLPatterns: patterns,
+		New: func() (domain.Provider, error) {
+			*calls++
+			if err != nil {
+				return nil, err
+			}
+			return provider, nil
+		},
+	}
+}
+
 func TestProviderRouter_GetProviderForURL(t *testing.T) {
 	mockProvider1 := mocks.NewMockProvider(t)
 	mockProvider2 := mocks.NewMockProvider(t)
+	mockProvider1.EXPECT().Kind().Return(domain.MangaSourceMangaNel).Maybe()
 
-	// Setup router with mocks
-	router := &providerRouter{
-		providers: map[domain.MangaSource]domain.Provider{
-			domain.MangaSourceMangaNel: mockProvider1,
-			domain.MangaSourceMangaDex: mockProvider2,
-		},
-	}
+	var calls1, calls2 int
+	router, err := NewProviderRouter(
+		countingFactory(domain.MangaSourceMangaDex, []string{`mangadex\.org`}, mockProvider2, nil, &calls2),
+		countingFactory(domain.MangaSourceMangaNel, []string{`manganel\.me`}, mockProvider1, nil, &calls1),
+	)
+	require.NoError(t, err)
 
 	url := "https://manganel.me/manga-123"
 
-	// Mock Provider 1 supports it
-	mockProvider1.EXPECT().Supports(url).Return(true)
-	// Provider 2 might be called or not depending on map iteration order
-	mockProvider2.EXPECT().Supports(url).Return(false).Maybe()
-
 	p, err := router.GetProviderForURL(url)
 	assert.NoError(t, err)
 	assert.Equal(t, mockProvider1, p)
+	assert.Equal(t, 1, calls1)
+	assert.Equal(t, 0, calls2, "providers not matching the url are never created")
 }
 
 func TestProviderRouter_GetProviderForURL_NotFound(t *testing.T) {
 	mockProvider1 := mocks.NewMockProvider(t)
-	router := &providerRouter{
-		providers: map[domain.MangaSource]domain.Provider{
-			domain.MangaSourceMangaNel: mockProvider1,
-		},
-	}
+	var calls int
+	router, err := NewProviderRouter(
+		countingFactory(domain.MangaSourceMangaNel, []string{`manganel\.me`}, mockProvider1, nil, &calls),
+	)
+	require.NoError(t, err)
 
 	url := "https://unknown.com/manga"
-	mockProvider1.EXPECT().Supports(url).Return(false)
 
 	p, err := router.GetProviderForURL(url)
 	assert.Error(t, err)
 	assert.Nil(t, p)
 	assert.Contains(t, err.Error(), "no provider found")
+	assert.Equal(t, 0, calls)
+}
+
+func TestProviderRouter_GetProviderForURL_AsksProvidersWithoutPatterns(t *testing.T) {
+	mockProvider := mocks.NewMockProvider(t)
+	url := "https://external.example.com/manga"
+	mockProvider.EXPECT().Kind().Return("external")
+	mockProvider.EXPECT().Supports(url).Return(true)
+
+	var calls int
+	router, err := NewProviderRouter(countingFactory("external", nil, mockProvider, nil, &calls))
+	require.NoError(t, err)
+
+	p, err := router.GetProviderForURL(url)
+	assert.NoError(t, err)
+	assert.Equal(t, mockProvider, p)
+}
+
+func TestProviderRouter_GetProvider_InitializesOnce(t *testing.T) {
+	mockProvider := mocks.NewMockProvider(t)
+	mockProvider.EXPECT().Kind().Return(domain.MangaSourceMangaDex)
+
+	var calls, failingCalls int
+	router, err := NewProviderRouter(
+		countingFactory(domain.MangaSourceMangaDex, []string{`mangadex\.org`}, mockProvider, nil, &calls),
+		countingFactory(domain.MangaSourceMangaNel, []string{`manganel\.me`}, nil, errors.New("browser unavailable"), &failingCalls),
+	)
+	require.NoError(t, err)
+
+	for range 3 {
+		p, err := router.GetProvider(domain.MangaEntity{Source: domain.MangaSourceMangaDex})
+		assert.NoError(t, err)
+		assert.Equal(t, mockProvider, p)
+	}
+	assert.Equal(t, 1, calls)
+	assert.Equal(t, 0, failingCalls, "a run touching only mangadex never creates manganel")
+
+	for range 2 {
+		_, err = router.GetProvider(domain.MangaEntity{Source: domain.MangaSourceMangaNel})
+		var unavailable *domain.ProviderUnavailableError
+		require.ErrorAs(t, err, &unavailable)
+		assert.Equal(t, domain.MangaSourceMangaNel, unavailable.Kind)
+		assert.ErrorContains(t, unavailable.Cause, "browser unavailable")
+	}
+	assert.Equal(t, 1, failingCalls, "a failed initialization is not retried")
+
+	_, err = router.GetProvider(domain.MangaEntity{Source: "unknown"})
+	assert.ErrorContains(t, err, "not found")
+}
+
+func TestProviderRouter_GetProvider_ResolvesFactoriesWithoutKind(t *testing.T) {
+	mockProvider := mocks.NewMockProvider(t)
+	mockProvider.EXPECT().Kind().Return("external")
+
+	var calls int
+	router, err := NewProviderRouter(countingFactory("", nil, mockProvider, nil, &calls))
+	require.NoError(t, err)
+
+	p, err := router.GetProvider(domain.MangaEntity{Source: "external"})
+	assert.NoError(t, err)
+	assert.Equal(t, mockProvider, p)
+
+	_, err = router.GetProvider(domain.MangaEntity{Source: "external"})
+	assert.NoError(t, err)
+	assert.Equal(t, 1, calls)
+}
+
+func TestProviderRouter_InvalidURLPatternOnlyDisablesItsProvider(t *testing.T) {
+	mockProvider := mocks.NewMockProvider(t)
+	mockProvider.EXPECT().Kind().Return(domain.MangaSourceMangaDex)
+
+	var calls, brokenCalls int
+	router, err := NewProviderRouter(
+		countingFactory("scraper", []string{`(unclosed`}, nil, nil, &brokenCalls),
+		countingFactory(domain.MangaSourceMangaDex, []string{`mangadex\.org`}, mockProvider, nil, &calls),
+	)
+	require.NoError(t, err, "a provider with an invalid pattern does not fail the router")
+
+	p, err := router.GetProviderForURL("https://mangadex.org/title/1")
+	require.NoError(t, err)
+	assert.Equal(t, mockProvider, p)
+
+	_, err = router.GetProvider(domain.MangaEntity{Source: "scraper"})
+	var unavailable *domain.ProviderUnavailableError
+	require.ErrorAs(t, err, &unavailable)
+	assert.ErrorContains(t, unavailable.Cause, "invalid url pattern")
+	assert.ErrorContains(t, router.Providers()[0].InitError, "invalid url pattern")
+	assert.Equal(t, 0, brokenCalls, "the provider with an invalid pattern is never created")
+}
+
+func TestProviderRouter_Providers(t *testing.T) {
+	kindless := mocks.NewMockProvider(t)
+	kindless.EXPECT().Kind().Return(domain.MangaSource("custom"))
+	mangadex := mocks.NewMockProvider(t)
+
+	var kindlessCalls, mangadexCalls, brokenCalls int
+	router, err := NewProviderRouter(
+		countingFactory(domain.MangaSourceMangaDex, []string{`mangadex\.org`}, mangadex, nil, &mangadexCalls),
+		countingFactory("", []string{`custom\.example`}, kindless, nil, &kindlessCalls),
+		countingFactory("", nil, nil, errors.New("boom"), &brokenCalls),
+	)
+	require.NoError(t, err)
+
+	infos := router.Providers()
+	require.Len(t, infos, 3)
+	assert.Equal(t, domain.MangaSourceMangaDex, infos[0].Kind)
+	assert.Equal(t, []string{`mangadex\.org`}, infos[0].URLPatterns)
+	assert.Equal(t, domain.MangaSource("custom"), infos[1].Kind)
+	assert.NoError(t, infos[1].InitError)
+	assert.Error(t, infos[2].InitError)
+	assert.Equal(t, 0, mangadexCalls, "providers registered with a kind are not created")
+	assert.Equal(t, 1, kindlessCalls)
 }
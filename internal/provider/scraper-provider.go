@@ -0,0 +1,292 @@
+package provider
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"net/url"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/PuerkitoBio/goquery"
+	"github.com/ivan-penchev/manga-updates/internal/domain"
+	"github.com/ivan-penchev/manga-updates/internal/httpcache"
+)
+
+const defaultChapterNumberPattern = `(\d+(?:\.\d+)?)`
+
+// ScraperSelectors are the CSS selectors locating the data on a series page
+type ScraperSelectors struct {
+	Title  string
+	Status string
+	// ChapterList selects one element per chapter,
+	// the remaining chapter selectors are relative to it.
+	ChapterList   string
+	ChapterNumber string
+	// ChapterLink selects the element holding the href of the chapter,
+	// the chapter element itself is used when empty.
+	ChapterLink string
+	ChapterDate string
+}
+
+type ScraperProviderConfig struct {
+	Kind domain.MangaSource
+	// URLPattern is a regular expression matching the series pages of the site
+	URLPattern string
+	Selectors  ScraperSelectors
+	// ChapterNumberPattern extracts the number from the chapter number text,
+	// the first capture group is used.
+	ChapterNumberPattern string
+	// DateFormat is a Go time layout, e.g. "Jan 02, 2006"
+	DateFormat string
+	// DateAttribute reads the date from an attribute (e.g. "datetime") instead of the text
+	DateAttribute string
+	HTTPClient    *http.Client
+	// CacheDir caches responses on disk and revalidates them with conditional requests, no caching when empty
+	CacheDir string
+	// Logger defaults to slog.Default()
+	Logger *slog.Logger
+}
+
+// NewScraperProviderFactory creates a provider for sites with a predictable HTML layout,
+// defined entirely by configuration. The slug of a series tracked with it is the page url.
+func NewScraperProviderFactory(cfg ScraperProviderConfig) ProviderFactory {
+	if cfg.URLPattern == "" {
+		// an empty pattern matches every url, the scraper is registered without routing any
+		err := errors.New("scraper provider requires a url pattern")
+		return ProviderFactory{
+			Kind:   cfg.Kind,
+			Logger: cfg.Logger,
+			New:    func() (domain.Provider, error) { return nil, err },
+		}
+	}
+
+	return ProviderFactory{
+		Kind:        cfg.Kind,
+		URLPatterns: []string{cfg.URLPattern},
+		Logger:      cfg.Logger,
+		New: func() (domain.Provider, error) {
+			if cfg.Kind == "" {
+				return nil, errors.New("scraper provider requires a kind")
+			}
+			if cfg.Selectors.Title == "" || cfg.Selectors.ChapterList == "" || cfg.Selectors.ChapterNumber == "" {
+				return nil, fmt.Errorf("scraper provider %s requires title, chapter list and chapter number selectors", cfg.Kind)
+			}
+
+			urlPattern, err := regexp.Compile(cfg.URLPattern)
+			if err != nil {
+				return nil, fmt.Errorf("scraper provider %s has an invalid url pattern: %w", cfg.Kind, err)
+			}
+
+			if cfg.ChapterNumberPattern == "" {
+				cfg.ChapterNumberPattern = defaultChapterNumberPattern
+			}
+			chapterNumberPattern, err := regexp.Compile(cfg.ChapterNumberPattern)
+			if err != nil {
+				return nil, fmt.Errorf("scraper provider %s has an invalid chapter number pattern: %w", cfg.Kind, err)
+			}
+			if chapterNumberPattern.NumSubexp() < 1 {
+				return nil, fmt.Errorf("scraper provider %s chapter number pattern has no capture group", cfg.Kind)
+			}
+
+			httpClient := cfg.HTTPClient
+			if httpClient == nil {
+				httpClient = &http.Client{Timeout: 10 * time.Second}
+			}
+			httpClient = httpcache.NewClient(httpClient, cfg.CacheDir, httpcache.WithLogger(loggerOrDefault(cfg.Logger)))
+
+			return &scraperProvider{
+				kind:                 cfg.Kind,
+				urlPattern:           urlPattern,
+				selectors:            cfg.Selectors,
+				chapterNumberPattern: chapterNumberPattern,
+				dateFormat:           cfg.DateFormat,
+				dateAttribute:        cfg.DateAttribute,
+				httpClient:           httpClient,
+				logger:               loggerOrDefault(cfg.Logger),
+			}, nil
+		},
+	}
+}
+
+type scraperProvider struct {
+	kind                 domain.MangaSource
+	urlPattern           *regexp.Regexp
+	selectors            ScraperSelectors
+	chapterNumberPattern *regexp.Regexp
+	dateFormat           string
+	dateAttribute        string
+	httpClient           *http.Client
+	logger               *slog.Logger
+}
+
+func (sp *scraperProvider) Kind() domain.MangaSource {
+	return sp.kind
+}
+
+func (sp *scraperProvider) Supports(url string) bool {
+	return sp.urlPattern.MatchString(url)
+}
+
+func (sp *scraperProvider) GetMangaFromURL(ctx context.Context, u string) (domain.MangaEntity, error) {
+	fetched, err := sp.GetLatestVersionMangaEntity(ctx, domain.MangaEntity{
+		Slug:         u,
+		Source:       sp.kind,
+		ShouldNotify: true,
+	})
+	if err != nil {
+		return domain.MangaEntity{}, err
+	}
+	return *fetched, nil
+}
+
+func (sp *scraperProvider) GetLatestVersionMangaEntity(ctx context.Context, manga domain.MangaEntity) (*domain.MangaEntity, error) {
+	pageURL, err := url.Parse(manga.Slug)
+	if err != nil {
+		return nil, fmt.Errorf("invalid page url %s: %w", manga.Slug, err)
+	}
+
+	doc, err := sp.fetch(ctx, pageURL.String())
+	if err != nil {
+		return nil, err
+	}
+
+	name := strings.TrimSpace(doc.Find(sp.selectors.Title).First().Text())
+	if name == "" {
+		return nil, fmt.Errorf("could not find title of %s using selector %q", manga.Slug, sp.selectors.Title)
+	}
+
+	status := manga.Status
+	if sp.selectors.Status != "" {
+		status = parseScrapedStatus(doc.Find(sp.selectors.Status).First().Text())
+	}
+
+	chapters := sp.parseChapters(doc, pageURL)
+
+	lastUpdate := time.Now()
+	if len(chapters) > 0 && chapters[0].Date != nil {
+		lastUpdate = *chapters[0].Date
+	}
+
+	return &domain.MangaEntity{
+		Name:         name,
+		ShouldNotify: manga.ShouldNotify,
+		LastUpdate:   lastUpdate,
+		Slug:         manga.Slug,
+		Status:       status,
+		Source:       sp.kind,
+		Chapters:     chapters,
+	}, nil
+}
+
+func (sp *scraperProvider) IsNewerVersionAvailable(ctx context.Context, manga domain.MangaEntity) (bool, error) {
+	if manga.IsNew() {
+		logMessage := fmt.Sprintf("Manga title (%s) that we have never synced before added for update notifications", manga.Name)
+		sp.logger.Info(logMessage)
+		return true, nil
+	}
+
+	latest, err := sp.GetLatestVersionMangaEntity(ctx, manga)
+	if err != nil {
+		return false, err
+	}
+	return hasNewerChapter(manga, *latest), nil
+}
+
+func (*scraperProvider) Search(ctx context.Context, query string, offset int) ([]domain.SearchResult, int, error) {
+	return nil, 0, ErrSearchNotSupported
+}
+
+func (sp *scraperProvider) fetch(ctx context.Context, u string) (*goquery.Document, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, u, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create request: %w", err)
+	}
+	req.Header.Set("Accept", "text/html")
+
+	res, err := sp.httpClient.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch %s: %w", u, err)
+	}
+	defer func() { _ = res.Body.Close() }()
+
+	if res.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("%s returned status code %d", u, res.StatusCode)
+	}
+
+	doc, err := goquery.NewDocumentFromReader(res.Body)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse html of %s: %w", u, err)
+	}
+	return doc, nil
+}
+
+func (sp *scraperProvider) parseChapters(doc *goquery.Document, pageURL *url.URL) []domain.ChapterEntity {
+	seen := make(map[float64]bool)
+	chapters := make([]domain.ChapterEntity, 0)
+
+	doc.Find(sp.selectors.ChapterList).Each(func(_ int, s *goquery.Selection) {
+		numberText := strings.TrimSpace(s.Find(sp.selectors.ChapterNumber).First().Text())
+		matches := sp.chapterNumberPattern.FindStringSubmatch(numberText)
+		if len(matches) < 2 {
+			sp.logger.Debug("skipping chapter without a number", "providerKind", sp.kind, "text", numberText)
+			return
+		}
+		number, err := strconv.ParseFloat(matches[1], 64)
+		if err != nil || seen[number] {
+			return
+		}
+		seen[number] = true
+
+		chapter := domain.ChapterEntity{Number: &number}
+
+		link := s
+		if sp.selectors.ChapterLink != "" {
+			link = s.Find(sp.selectors.ChapterLink).First()
+		}
+		if href, ok := link.Attr("href"); ok {
+			if ref, err := pageURL.Parse(href); err == nil {
+				chapter.URI = ref.String()
+				slug := ref.Path
+				chapter.Slug = &slug
+			}
+		}
+
+		if sp.selectors.ChapterDate != "" && sp.dateFormat != "" {
+			dateSelection := s.Find(sp.selectors.ChapterDate).First()
+			dateText := strings.TrimSpace(dateSelection.Text())
+			if sp.dateAttribute != "" {
+				dateText, _ = dateSelection.Attr(sp.dateAttribute)
+			}
+			if date, err := time.Parse(sp.dateFormat, strings.TrimSpace(dateText)); err == nil {
+				chapter.Date = &date
+			} else {
+				sp.logger.Debug("failed to parse chapter date", "providerKind", sp.kind, "text", dateText, "error", err)
+			}
+		}
+
+		chapters = append(chapters, chapter)
+	})
+
+	sort.SliceStable(chapters, func(i, j int) bool {
+		return *chapters[i].Number > *chapters[j].Number
+	})
+	return chapters
+}
+
+func parseScrapedStatus(text string) domain.MangaStatus {
+	status := strings.ToLower(strings.TrimSpace(text))
+	switch {
+	case strings.Contains(status, "complete"), strings.Contains(status, "finished"):
+		return domain.MangaStatusComplete
+	case strings.Contains(status, "ongoing"), strings.Contains(status, "publishing"):
+		return domain.MangaStatusOngoing
+	default:
+		return domain.MangaStatus(status)
+	}
+}
@@ -0,0 +1,148 @@
+package provider
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/ivan-penchev/manga-updates/internal/domain"
+	"github.com/ivan-penchev/manga-updates/internal/mocks"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func testScraperConfig() ScraperProviderConfig {
+	return ScraperProviderConfig{
+		Kind:       "small-manga",
+		URLPattern: `^http://127\.0\.0\.1:\d+/series/`,
+		Selectors: ScraperSelectors{
+			Title:         "h1.series-title",
+			Status:        ".series-status",
+			ChapterList:   "ul.chapter-list li.chapter",
+			ChapterNumber: ".chapter-name",
+			ChapterLink:   "a.chapter-link",
+			ChapterDate:   "time.chapter-date",
+		},
+		DateFormat: "Jan 02, 2006",
+	}
+}
+
+func newTestScraperServer(t *testing.T) *httptest.Server {
+	t.Helper()
+	mux := http.NewServeMux()
+	mux.HandleFunc("/series/greatest-estate-developer", func(w http.ResponseWriter, r *http.Request) {
+		http.ServeFile(w, r, "testdata/scraper/series.html")
+	})
+	server := httptest.NewServer(mux)
+	t.Cleanup(server.Close)
+	return server
+}
+
+func TestScraperProvider_GetMangaFromURL(t *testing.T) {
+	server := newTestScraperServer(t)
+	p, err := NewScraperProviderFactory(testScraperConfig()).New()
+	require.NoError(t, err)
+
+	url := server.URL + "/series/greatest-estate-developer"
+	require.True(t, p.Supports(url))
+	assert.False(t, p.Supports("https://mangadex.org/title/1"))
+
+	manga, err := p.GetMangaFromURL(context.Background(), url)
+	require.NoError(t, err)
+
+	assert.Equal(t, "The Greatest Estate Developer", manga.Name)
+	assert.Equal(t, url, manga.Slug)
+	assert.Equal(t, domain.MangaSource("small-manga"), manga.Source)
+	assert.Equal(t, domain.MangaStatusOngoing, manga.Status)
+	assert.Equal(t, time.Date(2025, time.March, 4, 0, 0, 0, 0, time.UTC), manga.LastUpdate)
+
+	require.Len(t, manga.Chapters, 3, "entries without a chapter number are skipped")
+	assert.Equal(t, 152.0, *manga.Chapters[0].Number)
+	assert.Equal(t, server.URL+"/read/greatest-estate-developer/chapter-152", manga.Chapters[0].URI, "relative links are resolved")
+	assert.Equal(t, 151.5, *manga.Chapters[1].Number)
+	assert.Equal(t, "https://cdn.small-manga.example.com/greatest-estate-developer/151", manga.Chapters[2].URI)
+	assert.Equal(t, time.Date(2025, time.February, 20, 0, 0, 0, 0, time.UTC), *manga.Chapters[2].Date)
+}
+
+func TestScraperProvider_DateAttribute(t *testing.T) {
+	server := newTestScraperServer(t)
+	cfg := testScraperConfig()
+	cfg.DateFormat = time.DateOnly
+	cfg.DateAttribute = "datetime"
+	p, err := NewScraperProviderFactory(cfg).New()
+	require.NoError(t, err)
+
+	manga, err := p.GetMangaFromURL(context.Background(), server.URL+"/series/greatest-estate-developer")
+	require.NoError(t, err)
+	require.NotNil(t, manga.Chapters[1].Date)
+	assert.Equal(t, time.Date(2025, time.February, 27, 0, 0, 0, 0, time.UTC), *manga.Chapters[1].Date)
+}
+
+func TestScraperProvider_IsNewerVersionAvailable(t *testing.T) {
+	server := newTestScraperServer(t)
+	p, err := NewScraperProviderFactory(testScraperConfig()).New()
+	require.NoError(t, err)
+	ctx := context.Background()
+
+	manga, err := p.GetMangaFromURL(ctx, server.URL+"/series/greatest-estate-developer")
+	require.NoError(t, err)
+
+	isNewer, err := p.IsNewerVersionAvailable(ctx, manga)
+	require.NoError(t, err)
+	assert.False(t, isNewer)
+
+	manga.Chapters = manga.Chapters[1:]
+	isNewer, err = p.IsNewerVersionAvailable(ctx, manga)
+	require.NoError(t, err)
+	assert.True(t, isNewer)
+}
+
+func TestScraperProvider_InvalidConfig(t *testing.T) {
+	cfg := testScraperConfig()
+	cfg.Kind = ""
+	_, err := NewScraperProviderFactory(cfg).New()
+	assert.Error(t, err)
+
+	cfg = testScraperConfig()
+	cfg.Selectors.ChapterList = ""
+	_, err = NewScraperProviderFactory(cfg).New()
+	assert.Error(t, err)
+
+	cfg = testScraperConfig()
+	cfg.URLPattern = ""
+	factory := NewScraperProviderFactory(cfg)
+	assert.Empty(t, factory.URLPatterns, "an empty pattern does not route every url to the scraper")
+	_, err = factory.New()
+	assert.EqualError(t, err, "scraper provider requires a url pattern")
+}
+
+func TestScraperProvider_EmptyURLPatternDoesNotCaptureURLs(t *testing.T) {
+	mockProvider := mocks.NewMockProvider(t)
+	cfg := testScraperConfig()
+	cfg.URLPattern = ""
+	router, err := NewProviderRouter(
+		NewScraperProviderFactory(cfg),
+		ProviderFactory{Kind: "other", URLPatterns: []string{`other\.example`}, New: func() (domain.Provider, error) {
+			return mockProvider, nil
+		}},
+	)
+	require.NoError(t, err)
+
+	mockProvider.EXPECT().Kind().Return("other")
+	p, err := router.GetProviderForURL("https://other.example/series/1")
+	require.NoError(t, err)
+	assert.Equal(t, mockProvider, p, "the url reaches the provider registered after the scraper")
+}
+
+func TestScraperProvider_HonorsCancellation(t *testing.T) {
+	server := newBlockingServer(t)
+	p, err := NewScraperProviderFactory(testScraperConfig()).New()
+	require.NoError(t, err)
+
+	assertCancelledPromptly(t, func(ctx context.Context) error {
+		_, err := p.GetMangaFromURL(ctx, server.URL+"/series/greatest-estate-developer")
+		return err
+	})
+}
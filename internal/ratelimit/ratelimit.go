@@ -0,0 +1,210 @@
+// Package ratelimit keeps providers polite towards the sites they scrape:
+// a token bucket limiting the request rate, Retry-After aware retries of
+// throttled responses and exponential backoff with jitter.
+package ratelimit
+
+import (
+	"context"
+	"io"
+	"math/rand/v2"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+)
+
+// Limiter is a token bucket refilled at a fixed number of requests per second.
+// A nil Limiter does not limit anything.
+type Limiter struct {
+	mutex  sync.Mutex
+	rate   float64
+	burst  float64
+	tokens float64
+	last   time.Time
+	now    func() time.Time
+}
+
+// NewLimiter returns a limiter allowing rps requests per second with bursts of
+// up to burst requests, it returns nil (no limit) when rps is not positive.
+func NewLimiter(rps float64, burst int) *Limiter {
+	if rps <= 0 {
+		return nil
+	}
+	if burst < 1 {
+		burst = 1
+	}
+	return &Limiter{
+		rate:   rps,
+		burst:  float64(burst),
+		tokens: float64(burst),
+		now:    time.Now,
+	}
+}
+
+// Wait blocks until a request may be sent or the context is done.
+func (l *Limiter) Wait(ctx context.Context) error {
+	if l == nil {
+		return ctx.Err()
+	}
+	delay := l.reserve()
+	return Sleep(ctx, delay)
+}
+
+// reserve takes a token and returns how long the caller has to wait for it,
+// the token is taken even when it is not available yet so concurrent callers queue up.
+func (l *Limiter) reserve() time.Duration {
+	l.mutex.Lock()
+	defer l.mutex.Unlock()
+
+	now := l.now()
+	if !l.last.IsZero() {
+		l.tokens += now.Sub(l.last).Seconds() * l.rate
+		if l.tokens > l.burst {
+			l.tokens = l.burst
+		}
+	}
+	l.last = now
+
+	l.tokens--
+	if l.tokens >= 0 {
+		return 0
+	}
+	return time.Duration(-l.tokens / l.rate * float64(time.Second))
+}
+
+// Backoff returns the delay before retry attempt (starting at 1): an exponential
+// backoff from base capped at max, with full jitter so clients do not retry in lockstep.
+func Backoff(attempt int, base, max time.Duration) time.Duration {
+	if attempt < 1 {
+		attempt = 1
+	}
+	delay := base
+	for i := 1; i < attempt && delay < max; i++ {
+		delay *= 2
+	}
+	if delay > max {
+		delay = max
+	}
+	if delay <= 0 {
+		return 0
+	}
+	return delay/2 + rand.N(delay/2+1)
+}
+
+// RetryAfter parses the Retry-After header, given either in seconds or as an HTTP date.
+func RetryAfter(header http.Header, now time.Time) (time.Duration, bool) {
+	value := header.Get("Retry-After")
+	if value == "" {
+		return 0, false
+	}
+	if seconds, err := strconv.Atoi(value); err == nil && seconds >= 0 {
+		return time.Duration(seconds) * time.Second, true
+	}
+	if date, err := http.ParseTime(value); err == nil {
+		delay := date.Sub(now)
+		if delay < 0 {
+			delay = 0
+		}
+		return delay, true
+	}
+	return 0, false
+}
+
+// Sleep waits for d or until the context is done.
+func Sleep(ctx context.Context, d time.Duration) error {
+	if d <= 0 {
+		return ctx.Err()
+	}
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-timer.C:
+		return nil
+	}
+}
+
+// Transport is an http.RoundTripper waiting on a Limiter before every request
+// and retrying throttled responses (429 and 503), honoring their Retry-After header.
+type Transport struct {
+	Next    http.RoundTripper
+	Limiter *Limiter
+	// MaxRetries of a throttled request, the last throttled response is returned once exceeded
+	MaxRetries int
+	// BaseDelay and MaxDelay bound the backoff used when the response has no Retry-After header
+	BaseDelay time.Duration
+	MaxDelay  time.Duration
+	// AttemptTimeout bounds every attempt, from sending the request to closing the response
+	// body, the waits between attempts are not included. No bound when zero.
+	AttemptTimeout time.Duration
+}
+
+func (t *Transport) RoundTrip(req *http.Request) (*http.Response, error) {
+	next := t.Next
+	if next == nil {
+		next = http.DefaultTransport
+	}
+	ctx := req.Context()
+
+	for attempt := 0; ; attempt++ {
+		if err := t.Limiter.Wait(ctx); err != nil {
+			return nil, err
+		}
+
+		attemptReq := req
+		if attempt > 0 && req.Body != nil {
+			body, err := req.GetBody()
+			if err != nil {
+				return nil, err
+			}
+			attemptReq = req.Clone(ctx)
+			attemptReq.Body = body
+		}
+
+		cancel := context.CancelFunc(func() {})
+		if t.AttemptTimeout > 0 {
+			var attemptCtx context.Context
+			attemptCtx, cancel = context.WithTimeout(ctx, t.AttemptTimeout)
+			attemptReq = attemptReq.WithContext(attemptCtx)
+		}
+
+		res, err := next.RoundTrip(attemptReq)
+		if err != nil {
+			cancel()
+			return nil, err
+		}
+		if !isThrottled(res) || attempt >= t.MaxRetries || (req.Body != nil && req.GetBody == nil) {
+			// the deadline of the attempt covers reading the body
+			res.Body = cancelOnClose{ReadCloser: res.Body, cancel: cancel}
+			return res, nil
+		}
+		cancel()
+
+		delay, ok := RetryAfter(res.Header, time.Now())
+		if !ok {
+			delay = Backoff(attempt+1, t.BaseDelay, t.MaxDelay)
+		}
+		_ = res.Body.Close()
+		if err := Sleep(ctx, delay); err != nil {
+			return nil, err
+		}
+	}
+}
+
+// cancelOnClose releases the context of an attempt once its response body is closed
+type cancelOnClose struct {
+	io.ReadCloser
+	cancel context.CancelFunc
+}
+
+func (c cancelOnClose) Close() error {
+	err := c.ReadCloser.Close()
+	c.cancel()
+	return err
+}
+
+func isThrottled(res *http.Response) bool {
+	return res.StatusCode == http.StatusTooManyRequests ||
+		(res.StatusCode == http.StatusServiceUnavailable && res.Header.Get("Retry-After") != "")
+}
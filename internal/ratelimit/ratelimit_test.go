@@ -0,0 +1,141 @@
+package ratelimit
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestLimiter_Reserve(t *testing.T) {
+	now := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	l := NewLimiter(2, 2)
+	l.now = func() time.Time { return now }
+
+	assert.Zero(t, l.reserve(), "burst is available right away")
+	assert.Zero(t, l.reserve())
+	assert.Equal(t, 500*time.Millisecond, l.reserve(), "third request waits for a token")
+	assert.Equal(t, time.Second, l.reserve(), "callers queue up behind each other")
+
+	now = now.Add(10 * time.Second)
+	assert.Zero(t, l.reserve(), "bucket refills over time")
+}
+
+func TestLimiter_NilDoesNotLimit(t *testing.T) {
+	l := NewLimiter(0, 1)
+	assert.Nil(t, l)
+	assert.NoError(t, l.Wait(context.Background()))
+}
+
+func TestLimiter_WaitCancelled(t *testing.T) {
+	l := NewLimiter(0.001, 1)
+	require.NoError(t, l.Wait(context.Background()))
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	assert.ErrorIs(t, l.Wait(ctx), context.Canceled)
+}
+
+func TestBackoff(t *testing.T) {
+	for attempt := 1; attempt <= 10; attempt++ {
+		delay := Backoff(attempt, 100*time.Millisecond, time.Second)
+		expected := 100 * time.Millisecond << (attempt - 1)
+		if expected > time.Second {
+			expected = time.Second
+		}
+		assert.GreaterOrEqual(t, delay, expected/2)
+		assert.LessOrEqual(t, delay, expected)
+	}
+}
+
+func TestRetryAfter(t *testing.T) {
+	now := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+
+	delay, ok := RetryAfter(http.Header{"Retry-After": []string{"3"}}, now)
+	assert.True(t, ok)
+	assert.Equal(t, 3*time.Second, delay)
+
+	delay, ok = RetryAfter(http.Header{"Retry-After": []string{now.Add(time.Minute).Format(http.TimeFormat)}}, now)
+	assert.True(t, ok)
+	assert.Equal(t, time.Minute, delay)
+
+	_, ok = RetryAfter(http.Header{}, now)
+	assert.False(t, ok)
+
+	_, ok = RetryAfter(http.Header{"Retry-After": []string{"soon"}}, now)
+	assert.False(t, ok)
+}
+
+func TestTransport_RetriesThrottledRequests(t *testing.T) {
+	var calls atomic.Int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		assert.Equal(t, "query", string(body), "the body is replayed on retries")
+		if calls.Add(1) < 3 {
+			w.Header().Set("Retry-After", "0")
+			w.WriteHeader(http.StatusTooManyRequests)
+			return
+		}
+		_, _ = w.Write([]byte("ok"))
+	}))
+	defer server.Close()
+
+	client := &http.Client{Transport: &Transport{MaxRetries: 3}}
+	res, err := client.Post(server.URL, "text/plain", strings.NewReader("query"))
+	require.NoError(t, err)
+	defer res.Body.Close()
+
+	assert.Equal(t, http.StatusOK, res.StatusCode)
+	assert.Equal(t, int32(3), calls.Load())
+}
+
+func TestTransport_GivesUpAfterMaxRetries(t *testing.T) {
+	var calls atomic.Int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls.Add(1)
+		w.WriteHeader(http.StatusTooManyRequests)
+	}))
+	defer server.Close()
+
+	client := &http.Client{Transport: &Transport{MaxRetries: 2, BaseDelay: time.Millisecond, MaxDelay: time.Millisecond}}
+	res, err := client.Get(server.URL)
+	require.NoError(t, err)
+	defer res.Body.Close()
+
+	assert.Equal(t, http.StatusTooManyRequests, res.StatusCode)
+	assert.Equal(t, int32(3), calls.Load())
+}
+
+func TestTransport_AttemptTimeoutExcludesWaits(t *testing.T) {
+	var calls atomic.Int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch calls.Add(1) {
+		case 1:
+			w.WriteHeader(http.StatusTooManyRequests)
+		case 2:
+			_, _ = w.Write([]byte("ok"))
+		default:
+			<-r.Context().Done()
+		}
+	}))
+	defer server.Close()
+
+	// the backoff after the throttled response is longer than the timeout of an attempt
+	client := &http.Client{Transport: &Transport{MaxRetries: 1, BaseDelay: 200 * time.Millisecond, MaxDelay: 200 * time.Millisecond, AttemptTimeout: 50 * time.Millisecond}}
+	res, err := client.Get(server.URL)
+	require.NoError(t, err)
+	body, err := io.ReadAll(res.Body)
+	require.NoError(t, err)
+	require.NoError(t, res.Body.Close())
+	assert.Equal(t, "ok", string(body))
+
+	_, err = client.Get(server.URL)
+	assert.ErrorIs(t, err, context.DeadlineExceeded, "an attempt without answer times out")
+}
@@ -0,0 +1,107 @@
+package store
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/ivan-penchev/manga-updates/internal/domain"
+)
+
+// Layout is how the data files of the series are organized in the data folder
+type Layout string
+
+const (
+	// LayoutAuto uses the layout of the series already in the data folder, flat when it is empty
+	LayoutAuto Layout = ""
+	// LayoutFlat stores every series as <title>.json
+	LayoutFlat Layout = "flat"
+	// LayoutDirectory stores every series as <title>/data.json, as in the git workflow
+	LayoutDirectory Layout = "directory"
+	// LayoutSource stores every series as <source>/<title>.json
+	LayoutSource Layout = "source"
+)
+
+const directoryDataFile = "data.json"
+
+// ParseLayout validates a layout name, an empty name selects LayoutAuto
+func ParseLayout(name string) (Layout, error) {
+	switch layout := Layout(name); layout {
+	case LayoutAuto, LayoutFlat, LayoutDirectory, LayoutSource:
+		return layout, nil
+	default:
+		return "", fmt.Errorf("unknown store layout %q, expected flat, directory or source", name)
+	}
+}
+
+// DetectLayout returns the layout used by most of the data files found under location,
+// ok is false when there is none.
+func DetectLayout(location string) (layout Layout, ok bool) {
+	counts := make(map[Layout]int)
+	for _, file := range glob(location, isDataFile) {
+		rel, err := filepath.Rel(location, file)
+		if err != nil {
+			continue
+		}
+		switch parts := strings.Split(filepath.ToSlash(rel), "/"); {
+		case len(parts) == 1:
+			counts[LayoutFlat]++
+		case len(parts) == 2 && parts[1] == directoryDataFile:
+			counts[LayoutDirectory]++
+		case len(parts) == 2:
+			counts[LayoutSource]++
+		}
+	}
+
+	for _, candidate := range []Layout{LayoutFlat, LayoutDirectory, LayoutSource} {
+		if counts[candidate] > counts[layout] {
+			layout = candidate
+		}
+	}
+	return layout, counts[layout] > 0
+}
+
+// seriesPath returns where a new series is stored in the layout, the folder or file
+// is named after the title of the series and falls back to its slug.
+func seriesPath(location string, layout Layout, manga domain.MangaEntity, suffix string) string {
+	name := sanitizeName(manga.Name)
+	if name == "" {
+		name = sanitizeFilename(manga.Slug)
+	}
+	name += suffix
+
+	switch layout {
+	case LayoutDirectory:
+		return filepath.Join(location, name, directoryDataFile)
+	case LayoutSource:
+		return filepath.Join(location, sanitizeFilename(string(manga.Source)), name+".json")
+	default:
+		return filepath.Join(location, name+".json")
+	}
+}
+
+// availableSeriesPath returns the first path of the layout not used yet, titles shared
+// by several series are told apart by their source and then by a counter.
+func availableSeriesPath(location string, layout Layout, manga domain.MangaEntity) string {
+	path := seriesPath(location, layout, manga, "")
+	source := "-" + sanitizeFilename(string(manga.Source))
+	for i := 1; exists(path); i++ {
+		suffix := source
+		if i > 1 {
+			suffix = fmt.Sprintf("%s-%d", source, i)
+		}
+		path = seriesPath(location, layout, manga, suffix)
+	}
+	return path
+}
+
+func exists(path string) bool {
+	_, err := os.Stat(path)
+	return err == nil
+}
+
+// sanitizeName turns a title into a lowercase file name, "Solo Leveling: Ragnarok" becomes "solo-leveling-ragnarok"
+func sanitizeName(title string) string {
+	return strings.ToLower(sanitizeFilename(title))
+}
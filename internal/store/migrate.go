@@ -0,0 +1,159 @@
+package store
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"sort"
+
+	"github.com/ivan-penchev/manga-updates/internal/domain"
+)
+
+// CurrentSchemaVersion is the version of the series data files written by this build,
+// files without a schemaVersion field are version 0.
+const CurrentSchemaVersion = 1
+
+// migration upgrades a decoded data file from version from to from+1,
+// returning a description of every change made.
+type migration struct {
+	from  int
+	apply func(doc map[string]any) []string
+}
+
+// migrations are applied in order, each one upgrading a single version.
+var migrations = []migration{
+	{from: 0, apply: migrateV0ToV1},
+}
+
+// migrateV0ToV1 drops the unused latestChapter field and moves chapter numbers
+// from the "name" key to the "number" key.
+func migrateV0ToV1(doc map[string]any) []string {
+	var changes []string
+	if _, ok := doc["latestChapter"]; ok {
+		delete(doc, "latestChapter")
+		changes = append(changes, "dropped latestChapter")
+	}
+
+	chapters, _ := doc["chapters"].([]any)
+	renamed := 0
+	for _, c := range chapters {
+		chapter, ok := c.(map[string]any)
+		if !ok {
+			continue
+		}
+		name, ok := chapter["name"]
+		if !ok {
+			continue
+		}
+		if _, ok := chapter["number"]; !ok {
+			chapter["number"] = name
+		}
+		delete(chapter, "name")
+		renamed++
+	}
+	if renamed > 0 {
+		changes = append(changes, fmt.Sprintf("renamed the number key of %d chapters", renamed))
+	}
+	return changes
+}
+
+// persistedSeries is the on-disk representation of a series
+type persistedSeries struct {
+	SchemaVersion int `json:"schemaVersion"`
+	domain.MangaEntity
+}
+
+// Upgrade describes the migration of a single data file
+type Upgrade struct {
+	Location string
+	From     int
+	To       int
+	Changes  []string
+}
+
+// decodeSeries migrates a data file to the current schema version and decodes it.
+func decodeSeries(raw []byte) (domain.MangaEntity, Upgrade, error) {
+	var doc map[string]any
+	if err := json.Unmarshal(raw, &doc); err != nil {
+		return domain.MangaEntity{}, Upgrade{}, err
+	}
+
+	upgrade, err := migrate(doc)
+	if err != nil {
+		return domain.MangaEntity{}, upgrade, err
+	}
+
+	migrated, err := json.Marshal(doc)
+	if err != nil {
+		return domain.MangaEntity{}, upgrade, err
+	}
+	var series persistedSeries
+	if err := json.Unmarshal(migrated, &series); err != nil {
+		return domain.MangaEntity{}, upgrade, err
+	}
+	return series.MangaEntity, upgrade, nil
+}
+
+// migrate applies the pending migrations to a decoded data file
+func migrate(doc map[string]any) (Upgrade, error) {
+	version := 0
+	if v, ok := doc["schemaVersion"].(float64); ok {
+		version = int(v)
+	}
+	upgrade := Upgrade{From: version, To: version}
+	if version > CurrentSchemaVersion {
+		return upgrade, fmt.Errorf("schema version %d is newer than the supported version %d", version, CurrentSchemaVersion)
+	}
+
+	for _, m := range migrations {
+		if m.from != upgrade.To {
+			continue
+		}
+		upgrade.Changes = append(upgrade.Changes, m.apply(doc)...)
+		upgrade.To = m.from + 1
+	}
+	doc["schemaVersion"] = upgrade.To
+	return upgrade, nil
+}
+
+// UpgradeFiles migrates every data file found under location to the current
+// schema version, rewriting them in place unless dryRun is set.
+// Only the files needing a migration are returned.
+func UpgradeFiles(location string, dryRun bool) ([]Upgrade, error) {
+	files := glob(location, isDataFile)
+	sort.Strings(files)
+
+	var upgrades []Upgrade
+	for _, file := range files {
+		raw, err := os.ReadFile(file)
+		if err != nil {
+			return upgrades, err
+		}
+		manga, upgrade, err := decodeSeries(raw)
+		if err != nil {
+			return upgrades, fmt.Errorf("failed to migrate %s: %w", file, err)
+		}
+		if upgrade.From == upgrade.To {
+			continue
+		}
+		upgrade.Location = file
+		upgrades = append(upgrades, upgrade)
+
+		if dryRun {
+			continue
+		}
+		if err := writeSeries(file, manga); err != nil {
+			return upgrades, fmt.Errorf("failed to write %s: %w", file, err)
+		}
+	}
+	return upgrades, nil
+}
+
+// writeSeries persists a series at the current schema version
+func writeSeries(location string, manga domain.MangaEntity) error {
+	file, err := json.MarshalIndent(persistedSeries{SchemaVersion: CurrentSchemaVersion, MangaEntity: manga}, "", " ")
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(location, file, 0644)
+}
@@ -0,0 +1,74 @@
+package store
+
+import (
+	"context"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const legacySeries = `{
+  "name": "Unexpected Accident",
+  "shouldNotify": true,
+  "lastUpdate": null,
+  "slug": "unexpected-accident",
+  "status": "",
+  "latestChapter": null,
+  "source": "manganel",
+  "chapters": [{"name": 2, "slug": "c2", "date": null, "uri": "nel/2"}]
+}`
+
+func TestUpgradeFiles(t *testing.T) {
+	dir := t.TempDir()
+	legacyPath := filepath.Join(dir, "unexpected-accident", "data.json")
+	require.NoError(t, os.MkdirAll(filepath.Dir(legacyPath), 0755))
+	require.NoError(t, os.WriteFile(legacyPath, []byte(legacySeries), 0644))
+
+	s := NewStore(dir)
+	series := s.GetMangaSeries(context.Background())
+	require.Contains(t, series, legacyPath)
+	require.Len(t, series[legacyPath].Chapters, 1)
+	assert.Equal(t, 2.0, *series[legacyPath].Chapters[0].Number, "old files are migrated when read")
+
+	upgrades, err := UpgradeFiles(dir, true)
+	require.NoError(t, err)
+	require.Len(t, upgrades, 1)
+	assert.Equal(t, Upgrade{
+		Location: legacyPath,
+		From:     0,
+		To:       CurrentSchemaVersion,
+		Changes:  []string{"dropped latestChapter", "renamed the number key of 1 chapters"},
+	}, upgrades[0])
+	raw, err := os.ReadFile(legacyPath)
+	require.NoError(t, err)
+	assert.JSONEq(t, legacySeries, string(raw), "a dry run leaves the files untouched")
+
+	_, err = UpgradeFiles(dir, false)
+	require.NoError(t, err)
+	raw, err = os.ReadFile(legacyPath)
+	require.NoError(t, err)
+	var doc map[string]any
+	require.NoError(t, json.Unmarshal(raw, &doc))
+	assert.Equal(t, float64(CurrentSchemaVersion), doc["schemaVersion"])
+	assert.NotContains(t, doc, "latestChapter")
+	assert.Equal(t, 2.0, doc["chapters"].([]any)[0].(map[string]any)["number"])
+
+	upgrades, err = UpgradeFiles(dir, false)
+	require.NoError(t, err)
+	assert.Empty(t, upgrades, "upgraded files are left alone")
+}
+
+func TestGetMangaSeries_SkipsNewerSchemaVersions(t *testing.T) {
+	dir := t.TempDir()
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "future.json"),
+		[]byte(`{"schemaVersion": 99, "name": "Future", "slug": "future", "source": "manganel"}`), 0644))
+
+	assert.Empty(t, NewStore(dir).GetMangaSeries(context.Background()))
+
+	_, err := UpgradeFiles(dir, true)
+	assert.ErrorContains(t, err, "newer than the supported version")
+}
@@ -2,11 +2,12 @@ package store
 
 import (
 	"context"
-	"encoding/json"
 	"fmt"
 	"io/fs"
 	"os"
 	"path/filepath"
+	"regexp"
+	"strings"
 
 	"log/slog"
 
@@ -21,49 +22,67 @@ type Store interface {
 
 type fileStore struct {
 	location string
+	layout   Layout
+	logger   *slog.Logger
+}
+
+type Option func(*fileStore)
+
+// WithLayout sets how new series are organized in the data folder,
+// LayoutAuto follows the layout of the series already there.
+func WithLayout(layout Layout) Option {
+	return func(f *fileStore) {
+		f.layout = layout
+	}
+}
+
+// WithLogger sets the logger reporting the unreadable series files, slog.Default() by default
+func WithLogger(logger *slog.Logger) Option {
+	return func(f *fileStore) {
+		f.logger = logger
+	}
 }
 
 // PersistestMangaTitle implements Store
 func (f *fileStore) PersistMangaTitle(ctx context.Context, location string, mangaTitle domain.MangaEntity) error {
-	file, _ := json.MarshalIndent(mangaTitle, "", " ")
-	return os.WriteFile(location, file, 0644)
+	if err := os.MkdirAll(filepath.Dir(location), 0755); err != nil {
+		return err
+	}
+	return writeSeries(location, mangaTitle)
 }
 
 func (f *fileStore) AddManga(ctx context.Context, manga domain.MangaEntity) error {
-	// sanitized slug
-	filename := fmt.Sprintf("%s.json", manga.Slug)
-	// We want to save it in f.location
-	fullPath := filepath.Join(f.location, filename)
-
-	// Delegate to Persist currently
-	// Note: Check for existence?
-	if _, err := os.Stat(fullPath); err == nil {
-		return fmt.Errorf("manga with slug %s already exists at %s", manga.Slug, fullPath)
+	for path, existing := range f.GetMangaSeries(ctx) {
+		if existing.Source == manga.Source && existing.Slug == manga.Slug {
+			return fmt.Errorf("manga with slug %s already exists at %s", manga.Slug, path)
+		}
 	}
 
-	return f.PersistMangaTitle(ctx, fullPath, manga)
+	layout := f.layout
+	if layout == LayoutAuto {
+		layout, _ = DetectLayout(f.location)
+	}
+	return f.PersistMangaTitle(ctx, availableSeriesPath(f.location, layout, manga), manga)
 }
 
 // GetMangaSeries returns the file location and file data
 func (f *fileStore) GetMangaSeries(ctx context.Context) map[string]domain.MangaEntity {
 	persistedMangaSeries := make(map[string]domain.MangaEntity, 0)
-	files := glob(f.location, func(s string) bool {
-		return filepath.Ext(s) == ".json"
-	})
+	files := glob(f.location, isDataFile)
 	for _, file := range files {
 		byteValue, err := os.ReadFile(file)
 		if err != nil {
-			slog.Error("Cant open file")
+			f.logger.Error("Cant open file", "file", file, "error", err)
 			continue
 		}
-		var mangaSeries domain.MangaEntity
-		err = json.Unmarshal(byteValue, &mangaSeries)
+		// older files are migrated in memory, they are rewritten on the next persist
+		mangaSeries, _, err := decodeSeries(byteValue)
 		if err != nil {
-			slog.Error("File is not in correct structure")
+			f.logger.Error("File is not in correct structure", "file", file, "error", err)
 			continue
 		}
 		if mangaSeries.Slug == "" || mangaSeries.Slug == "<insert id string>" {
-			slog.Error("Slug for the manga title is not formatted correctly")
+			f.logger.Error("Slug for the manga title is not formatted correctly", "file", file)
 			continue
 		}
 		persistedMangaSeries[file] = mangaSeries
@@ -72,10 +91,26 @@ func (f *fileStore) GetMangaSeries(ctx context.Context) map[string]domain.MangaE
 	return persistedMangaSeries
 }
 
-func NewStore(location string) Store {
-	return &fileStore{
+func NewStore(location string, opts ...Option) Store {
+	f := &fileStore{
 		location: location,
+		logger:   slog.Default(),
 	}
+	for _, opt := range opts {
+		opt(f)
+	}
+	return f
+}
+
+var unsafeFilenameChars = regexp.MustCompile(`[^A-Za-z0-9._-]+`)
+
+func sanitizeFilename(name string) string {
+	name = strings.TrimPrefix(strings.TrimPrefix(name, "https://"), "http://")
+	return strings.Trim(unsafeFilenameChars.ReplaceAllString(name, "-"), "-.")
+}
+
+func isDataFile(path string) bool {
+	return filepath.Ext(path) == ".json"
 }
 
 func glob(root string, fn func(string) bool) []string {
@@ -0,0 +1,70 @@
+package store
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/ivan-penchev/manga-updates/internal/domain"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestFileStore_AddMangaLayouts(t *testing.T) {
+	ctx := context.Background()
+	solo := domain.MangaEntity{Name: "Solo Leveling: Ragnarok", Slug: "32d76d19-8a05-4db0-9fc2-e0b0648fe9d0", Source: domain.MangaSourceMangaDex}
+	soloNel := domain.MangaEntity{Name: "Solo Leveling: Ragnarok", Slug: "solo-leveling-ragnarok", Source: domain.MangaSourceMangaNel}
+	untitled := domain.MangaEntity{Name: "俺だけレベルアップな件", Slug: "https://scans.example.com/feed", Source: domain.MangaSourceFeed}
+
+	tests := []struct {
+		layout   Layout
+		expected []string
+	}{
+		{LayoutFlat, []string{"solo-leveling-ragnarok.json", "solo-leveling-ragnarok-manganel.json", "scans.example.com-feed.json"}},
+		{LayoutDirectory, []string{"solo-leveling-ragnarok/data.json", "solo-leveling-ragnarok-manganel/data.json", "scans.example.com-feed/data.json"}},
+		{LayoutSource, []string{"mangadex/solo-leveling-ragnarok.json", "manganel/solo-leveling-ragnarok.json", "feed/scans.example.com-feed.json"}},
+	}
+	for _, tt := range tests {
+		t.Run(string(tt.layout), func(t *testing.T) {
+			dir := t.TempDir()
+			s := NewStore(dir, WithLayout(tt.layout))
+			for _, manga := range []domain.MangaEntity{solo, soloNel, untitled} {
+				require.NoError(t, s.AddManga(ctx, manga))
+			}
+			for _, path := range tt.expected {
+				assert.FileExists(t, filepath.Join(dir, filepath.FromSlash(path)))
+			}
+			assert.Len(t, s.GetMangaSeries(ctx), 3)
+
+			assert.ErrorContains(t, s.AddManga(ctx, solo), "already exists", "series are unique per source and slug")
+		})
+	}
+}
+
+func TestFileStore_AddMangaFollowsExistingLayout(t *testing.T) {
+	ctx := context.Background()
+	dir := t.TempDir()
+	existing := filepath.Join(dir, "unexpected-accident", "data.json")
+	require.NoError(t, os.MkdirAll(filepath.Dir(existing), 0755))
+	require.NoError(t, os.WriteFile(existing, []byte(`{"name": "Unexpected Accident", "slug": "unexpected-accident", "source": "manganel"}`), 0644))
+
+	layout, ok := DetectLayout(dir)
+	assert.True(t, ok)
+	assert.Equal(t, LayoutDirectory, layout)
+
+	require.NoError(t, NewStore(dir).AddManga(ctx, domain.MangaEntity{Name: "Berserk", Slug: "berserk", Source: domain.MangaSourceMangaNel}))
+	assert.FileExists(t, filepath.Join(dir, "berserk", "data.json"))
+
+	_, ok = DetectLayout(t.TempDir())
+	assert.False(t, ok)
+}
+
+func TestParseLayout(t *testing.T) {
+	layout, err := ParseLayout("")
+	assert.NoError(t, err)
+	assert.Equal(t, LayoutAuto, layout)
+
+	_, err = ParseLayout("nested")
+	assert.Error(t, err)
+}
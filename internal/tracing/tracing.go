@@ -0,0 +1,100 @@
+// Package tracing sets up the OpenTelemetry tracer provider of the application.
+//
+// Tracing is disabled unless an OTLP endpoint is configured, the update runs are
+// then traced with a no-op provider which costs next to nothing.
+package tracing
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"go.opentelemetry.io/otel/exporters/otlp/otlptrace/otlptracehttp"
+	"go.opentelemetry.io/otel/sdk/resource"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+	semconv "go.opentelemetry.io/otel/semconv/v1.26.0"
+	"go.opentelemetry.io/otel/trace"
+	"go.opentelemetry.io/otel/trace/noop"
+)
+
+const defaultServiceName = "manga-updates"
+
+type Config struct {
+	// Endpoint is the URL or the host:port of the OTLP/HTTP collector, tracing is disabled when empty
+	Endpoint string
+	// Insecure sends the spans over plain HTTP
+	Insecure bool
+	// Headers are sent along every export, e.g. an API key of the collector
+	Headers map[string]string
+	// ServiceName defaults to manga-updates
+	ServiceName string
+	// SampleRatio is the share of the runs traced, every run is traced when zero
+	SampleRatio float64
+}
+
+// Provider is the tracer provider of the application, spans still buffered
+// are exported on Shutdown.
+type Provider struct {
+	trace.TracerProvider
+	shutdown func(ctx context.Context) error
+}
+
+// New returns a provider exporting the spans to the configured OTLP endpoint,
+// or a no-op provider when no endpoint is configured.
+func New(ctx context.Context, cfg Config) (*Provider, error) {
+	if cfg.Endpoint == "" {
+		return NewNoop(), nil
+	}
+
+	exporterOptions := []otlptracehttp.Option{otlptracehttp.WithEndpoint(cfg.Endpoint)}
+	if strings.Contains(cfg.Endpoint, "://") {
+		exporterOptions = []otlptracehttp.Option{otlptracehttp.WithEndpointURL(cfg.Endpoint)}
+	}
+	if cfg.Insecure {
+		exporterOptions = append(exporterOptions, otlptracehttp.WithInsecure())
+	}
+	if len(cfg.Headers) > 0 {
+		exporterOptions = append(exporterOptions, otlptracehttp.WithHeaders(cfg.Headers))
+	}
+	exporter, err := otlptracehttp.New(ctx, exporterOptions...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create OTLP exporter: %w", err)
+	}
+
+	serviceName := cfg.ServiceName
+	if serviceName == "" {
+		serviceName = defaultServiceName
+	}
+	sampler := sdktrace.AlwaysSample()
+	if cfg.SampleRatio > 0 && cfg.SampleRatio < 1 {
+		sampler = sdktrace.TraceIDRatioBased(cfg.SampleRatio)
+	}
+
+	tp := sdktrace.NewTracerProvider(
+		sdktrace.WithBatcher(exporter),
+		sdktrace.WithSampler(sdktrace.ParentBased(sampler)),
+		sdktrace.WithResource(resource.NewSchemaless(semconv.ServiceName(serviceName))),
+	)
+	return &Provider{TracerProvider: tp, shutdown: tp.Shutdown}, nil
+}
+
+// NewNoop returns a provider discarding every span
+func NewNoop() *Provider {
+	return &Provider{
+		TracerProvider: noop.NewTracerProvider(),
+		shutdown:       func(context.Context) error { return nil },
+	}
+}
+
+// NewInMemory returns a provider keeping the ended spans in memory, for tests
+func NewInMemory() (*Provider, *tracetest.InMemoryExporter) {
+	exporter := tracetest.NewInMemoryExporter()
+	tp := sdktrace.NewTracerProvider(sdktrace.WithSyncer(exporter))
+	return &Provider{TracerProvider: tp, shutdown: tp.Shutdown}, exporter
+}
+
+// Shutdown exports the spans still buffered and stops the provider
+func (p *Provider) Shutdown(ctx context.Context) error {
+	return p.shutdown(ctx)
+}
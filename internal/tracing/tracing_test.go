@@ -0,0 +1,30 @@
+package tracing
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNew_NoopWithoutEndpoint(t *testing.T) {
+	tp, err := New(context.Background(), Config{})
+	require.NoError(t, err)
+
+	_, span := tp.Tracer("test").Start(context.Background(), "span")
+	span.End()
+	assert.False(t, span.SpanContext().IsValid(), "spans of the no-op provider are not recorded")
+	assert.NoError(t, tp.Shutdown(context.Background()))
+}
+
+func TestNewInMemory_KeepsEndedSpans(t *testing.T) {
+	tp, exporter := NewInMemory()
+
+	_, span := tp.Tracer("test").Start(context.Background(), "span")
+	span.End()
+
+	spans := exporter.GetSpans()
+	require.Len(t, spans, 1)
+	assert.Equal(t, "span", spans[0].Name)
+}
@@ -0,0 +1,86 @@
+package updatechecker
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/ivan-penchev/manga-updates/internal/domain"
+)
+
+// checkLinkedSeries checks a series published by several sources. Every source is
+// checked in priority order and the chapter lists of those with new chapters are merged
+// by chapter number, keeping the chapter of whichever source published it first.
+// A source failing or being unavailable only fails the series when no source could be checked.
+func (ucs *UpdateCheckerService) checkLinkedSeries(ctx context.Context, path string, manga domain.MangaEntity, summary *RunSummary) {
+	sources := manga.AllSources()
+	latest := manga
+	var changed bool
+	var checked int
+	var unavailable, failed []error
+
+	for _, ref := range sources {
+		onSource := manga.OnSource(ref)
+		provider, err := ucs.providers.GetProvider(onSource)
+		if err != nil {
+			ucs.logger.Warn("skipping source, provider is unavailable", "manga", manga.Name, "source", ref.Source, "error", err)
+			unavailable = append(unavailable, err)
+			continue
+		}
+
+		sourceLatest, err := ucs.latestFromSource(ctx, provider, onSource)
+		if err != nil {
+			ucs.logger.Warn("failed to check source", "manga", manga.Name, "source", ref.Source, "error", err)
+			failed = append(failed, fmt.Errorf("%s: %w", ref.Source, err))
+			continue
+		}
+		checked++
+		if sourceLatest == nil {
+			continue
+		}
+
+		changed = true
+		latest.Chapters = domain.MergeChapters(latest.Chapters, sourceLatest.Chapters)
+		if sourceLatest.LastUpdate.After(latest.LastUpdate) {
+			latest.LastUpdate = sourceLatest.LastUpdate
+		}
+		if ref.Source == manga.Source && sourceLatest.Status != "" {
+			latest.Status = sourceLatest.Status
+		}
+	}
+
+	if len(unavailable) == len(sources) {
+		summary.Skipped = append(summary.Skipped, SeriesFailure{Location: path, Manga: manga, Err: errors.Join(unavailable...)})
+		return
+	}
+	summary.Checked++
+	if checked == 0 {
+		summary.Failed = append(summary.Failed, SeriesFailure{Location: path, Manga: manga, Err: errors.Join(append(failed, unavailable...)...)})
+		return
+	}
+	if !changed {
+		summary.Unchanged = append(summary.Unchanged, SeriesUpdate{Location: path, Manga: manga})
+		return
+	}
+
+	ucs.recordLatestVersion(ctx, path, manga, latest, manga.GetMissingChaptersByNumber(latest), summary)
+}
+
+// latestFromSource returns the latest version of the series on a source, nil when it has nothing new
+func (ucs *UpdateCheckerService) latestFromSource(ctx context.Context, provider domain.Provider, manga domain.MangaEntity) (*domain.MangaEntity, error) {
+	var isNewer bool
+	err := ucs.traceProviderCall(ctx, "IsNewerVersionAvailable", manga.Source, func(ctx context.Context) (err error) {
+		isNewer, err = provider.IsNewerVersionAvailable(ctx, manga)
+		return err
+	})
+	if err != nil || !isNewer {
+		return nil, err
+	}
+
+	var latest *domain.MangaEntity
+	err = ucs.traceProviderCall(ctx, "GetLatestVersionMangaEntity", manga.Source, func(ctx context.Context) (err error) {
+		latest, err = provider.GetLatestVersionMangaEntity(ctx, manga)
+		return err
+	})
+	return latest, err
+}
@@ -0,0 +1,114 @@
+package updatechecker
+
+import (
+	"context"
+	"errors"
+	"io"
+	"log/slog"
+	"testing"
+	"time"
+
+	"github.com/ivan-penchev/manga-updates/internal/domain"
+	"github.com/ivan-penchev/manga-updates/internal/mocks"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+func datedChapter(number float64, date time.Time, uri string) domain.ChapterEntity {
+	return domain.ChapterEntity{Number: &number, Date: &date, URI: uri}
+}
+
+func TestCheckForUpdates_MergesLinkedSources(t *testing.T) {
+	day := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	mockStore := mocks.NewMockStore(t)
+	mockNotifier := mocks.NewMockNotifier(t)
+	mockRouter := mocks.NewMockProviderRouter(t)
+	nelProvider := mocks.NewMockProvider(t)
+	dexProvider := mocks.NewMockProvider(t)
+
+	manga := domain.MangaEntity{
+		Name: "Solo Leveling", Slug: "solo-leveling", Source: domain.MangaSourceMangaNel, ShouldNotify: true, LastUpdate: day,
+		Chapters: []domain.ChapterEntity{datedChapter(10, day, "nel/10")},
+		Sources:  []domain.SourceRef{{Source: domain.MangaSourceMangaDex, Slug: "dex-id"}},
+	}
+	onNel := manga.OnSource(domain.SourceRef{Source: domain.MangaSourceMangaNel, Slug: "solo-leveling"})
+	onDex := manga.OnSource(manga.Sources[0])
+
+	nelLatest := onNel
+	nelLatest.LastUpdate = day.Add(48 * time.Hour)
+	nelLatest.Chapters = []domain.ChapterEntity{datedChapter(11, day.Add(48*time.Hour), "nel/11"), datedChapter(10, day, "nel/10")}
+	dexLatest := onDex
+	dexLatest.LastUpdate = day.Add(72 * time.Hour)
+	dexLatest.Chapters = []domain.ChapterEntity{datedChapter(12, day.Add(72*time.Hour), "dex/12"), datedChapter(11, day.Add(24*time.Hour), "dex/11")}
+
+	mockStore.EXPECT().GetMangaSeries(mock.Anything).Return(map[string]domain.MangaEntity{"solo.json": manga})
+	mockRouter.EXPECT().GetProvider(onNel).Return(nelProvider, nil)
+	mockRouter.EXPECT().GetProvider(onDex).Return(dexProvider, nil)
+	nelProvider.EXPECT().IsNewerVersionAvailable(mock.Anything, onNel).Return(true, nil)
+	nelProvider.EXPECT().GetLatestVersionMangaEntity(mock.Anything, onNel).Return(&nelLatest, nil)
+	dexProvider.EXPECT().IsNewerVersionAvailable(mock.Anything, onDex).Return(true, nil)
+	dexProvider.EXPECT().GetLatestVersionMangaEntity(mock.Anything, onDex).Return(&dexLatest, nil)
+
+	var persisted domain.MangaEntity
+	mockStore.EXPECT().PersistMangaTitle(mock.Anything, "solo.json", mock.Anything).RunAndReturn(func(ctx context.Context, location string, m domain.MangaEntity) error {
+		persisted = m
+		return nil
+	})
+	mockNotifier.EXPECT().NotifyForNewChapter(mock.Anything, mock.Anything, mock.Anything).RunAndReturn(func(ctx context.Context, chapter domain.ChapterEntity, m domain.MangaEntity) error {
+		assert.Equal(t, "dex/11", chapter.URI, "the oldest new chapter, from the source publishing it first")
+		assert.Len(t, m.Chapters, 3, "notifications describe the latest version of the series")
+		return nil
+	})
+
+	observer := &recordingObserver{}
+	service, err := NewUpdateCheckerService(mockNotifier, mockStore, mockRouter, slog.New(slog.NewTextHandler(io.Discard, nil)), WithRunObserver(observer))
+	require.NoError(t, err)
+	require.NoError(t, service.CheckForUpdates(context.Background()))
+
+	assert.Equal(t, domain.MangaSourceMangaNel, persisted.Source, "the primary source is kept")
+	assert.Equal(t, manga.Sources, persisted.Sources, "linked sources are kept")
+	assert.Equal(t, day.Add(72*time.Hour), persisted.LastUpdate)
+	require.Len(t, persisted.Chapters, 3)
+	assert.Equal(t, []string{"dex/12", "dex/11", "nel/10"}, []string{persisted.Chapters[0].URI, persisted.Chapters[1].URI, persisted.Chapters[2].URI})
+
+	require.Len(t, observer.summaries[0].Updated, 1)
+	assert.Len(t, observer.summaries[0].Updated[0].NewChapters, 2)
+}
+
+func TestCheckForUpdates_FailsOverToLinkedSource(t *testing.T) {
+	day := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	mockStore := mocks.NewMockStore(t)
+	mockRouter := mocks.NewMockProviderRouter(t)
+	dexProvider := mocks.NewMockProvider(t)
+
+	manga := domain.MangaEntity{
+		Name: "Solo Leveling", Slug: "solo-leveling", Source: domain.MangaSourceMangaNel, LastUpdate: day,
+		Chapters: []domain.ChapterEntity{datedChapter(10, day, "nel/10")},
+		Sources:  []domain.SourceRef{{Source: domain.MangaSourceMangaDex, Slug: "dex-id"}},
+	}
+	onDex := manga.OnSource(manga.Sources[0])
+	dexLatest := onDex
+	dexLatest.Chapters = []domain.ChapterEntity{datedChapter(11, day, "dex/11"), datedChapter(10, day, "dex/10")}
+
+	mockStore.EXPECT().GetMangaSeries(mock.Anything).Return(map[string]domain.MangaEntity{"solo.json": manga})
+	mockRouter.EXPECT().GetProvider(mock.MatchedBy(func(m domain.MangaEntity) bool { return m.Source == domain.MangaSourceMangaNel })).
+		Return(nil, &domain.ProviderUnavailableError{Kind: domain.MangaSourceMangaNel, Cause: errors.New("browser unavailable")})
+	mockRouter.EXPECT().GetProvider(onDex).Return(dexProvider, nil)
+	dexProvider.EXPECT().IsNewerVersionAvailable(mock.Anything, onDex).Return(true, nil)
+	dexProvider.EXPECT().GetLatestVersionMangaEntity(mock.Anything, onDex).Return(&dexLatest, nil)
+	mockStore.EXPECT().PersistMangaTitle(mock.Anything, "solo.json", mock.Anything).Return(nil)
+
+	observer := &recordingObserver{}
+	service, err := NewUpdateCheckerService(nil, mockStore, mockRouter, slog.New(slog.NewTextHandler(io.Discard, nil)), WithRunObserver(observer))
+	require.NoError(t, err)
+	require.NoError(t, service.CheckForUpdates(context.Background()))
+
+	summary := observer.summaries[0]
+	assert.Equal(t, 1, summary.Checked)
+	assert.Empty(t, summary.Skipped)
+	assert.Empty(t, summary.Failed)
+	require.Len(t, summary.Updated, 1)
+	require.Len(t, summary.Updated[0].NewChapters, 1)
+	assert.Equal(t, "dex/11", summary.Updated[0].NewChapters[0].URI)
+}
@@ -2,9 +2,13 @@ package updatechecker
 
 import (
 	"context"
+	"fmt"
 	"log/slog"
+	"time"
 
 	"github.com/ivan-penchev/manga-updates/internal/domain"
+	"go.opentelemetry.io/otel/trace"
+	"go.opentelemetry.io/otel/trace/noop"
 )
 
 type Notifier interface {
@@ -16,74 +20,227 @@ type Store interface {
 	PersistMangaTitle(ctx context.Context, location string, mangaTitle domain.MangaEntity) error
 }
 
+// RunObserver is invoked once at the end of every CheckForUpdates run,
+// e.g. to publish feeds of the chapters detected during the run.
+type RunObserver interface {
+	RunCompleted(ctx context.Context, summary RunSummary) error
+}
+
+// RunSummary describes the outcome of a single CheckForUpdates run.
+type RunSummary struct {
+	StartedAt  time.Time
+	FinishedAt time.Time
+	Checked    int
+	Updated    []SeriesUpdate
+	// Unchanged lists the series checked without finding new chapters
+	Unchanged []SeriesUpdate
+	// Skipped lists the series whose provider is unavailable
+	Skipped []SeriesFailure
+	// Failed lists the series whose check failed
+	Failed []SeriesFailure
+}
+
+// SeriesFailure holds why a series could not be checked during a run.
+type SeriesFailure struct {
+	Location string
+	Manga    domain.MangaEntity
+	Err      error
+}
+
+// SeriesUpdate holds the chapters newly detected for a series during a run.
+type SeriesUpdate struct {
+	Location    string
+	Manga       domain.MangaEntity
+	NewChapters []domain.ChapterEntity
+}
+
 type UpdateCheckerService struct {
 	notifier  Notifier
 	store     Store
 	providers domain.ProviderRouter
 	logger    *slog.Logger
+	observers []RunObserver
+	// providerTimeout bounds every provider call, no bound when zero
+	providerTimeout time.Duration
+	tracer          trace.Tracer
+	// subscribers receive the notifications of their series, every notification goes to notifier when empty
+	subscribers []Subscriber
+}
+
+type Option func(*UpdateCheckerService)
+
+// WithRunObserver registers an observer that is notified after each run
+func WithRunObserver(observer RunObserver) Option {
+	return func(ucs *UpdateCheckerService) {
+		ucs.observers = append(ucs.observers, observer)
+	}
+}
+
+// WithProviderTimeout bounds every provider call, a series whose check times out is
+// reported as failed and the run goes on with the next one.
+func WithProviderTimeout(timeout time.Duration) Option {
+	return func(ucs *UpdateCheckerService) {
+		ucs.providerTimeout = timeout
+	}
 }
 
-func NewUpdateCheckerService(notifier Notifier, store Store, providers domain.ProviderRouter, logger *slog.Logger) (*UpdateCheckerService, error) {
-	return &UpdateCheckerService{
+func NewUpdateCheckerService(notifier Notifier, store Store, providers domain.ProviderRouter, logger *slog.Logger, opts ...Option) (*UpdateCheckerService, error) {
+	ucs := &UpdateCheckerService{
 		notifier:  notifier,
 		store:     store,
 		providers: providers,
 		logger:    logger,
-	}, nil
+		tracer:    noop.NewTracerProvider().Tracer(tracerName),
+	}
+	for _, opt := range opts {
+		opt(ucs)
+	}
+	return ucs, nil
 }
 
-func (ucs *UpdateCheckerService) CheckForUpdates(ctx context.Context) error {
-	persistedMangaSeries := ucs.store.GetMangaSeries(ctx)
+func (ucs *UpdateCheckerService) CheckForUpdates(ctx context.Context) (err error) {
+	summary := RunSummary{StartedAt: time.Now()}
+	ctx, span := ucs.tracer.Start(ctx, "CheckForUpdates")
+	defer func() { endRunSpan(span, summary, err) }()
 
-	if len(persistedMangaSeries) == 0 {
-		return nil
-	}
+	persistedMangaSeries := ucs.store.GetMangaSeries(ctx)
+	span.SetAttributes(AttrSeriesCount.Int(len(persistedMangaSeries)))
 
 	for path, manga := range persistedMangaSeries {
-		ucs.logger.Info("Looking at", "mangaName", manga.Name, "dataPath", path)
-		provider, err := ucs.providers.GetProvider(manga)
-
-		if err != nil {
-			return err
+		if ctx.Err() != nil {
+			// the run was cancelled or hit its deadline, the series left are not checked
+			summary.Failed = append(summary.Failed, SeriesFailure{Location: path, Manga: manga, Err: ctx.Err()})
+			continue
 		}
+		ucs.checkSeries(ctx, path, manga, &summary)
+	}
 
-		IsNewerVersionAvailable, err := provider.IsNewerVersionAvailable(ctx, manga)
-		if err != nil {
-			ucs.logger.Error("failed to check for newer version", "manga", manga.Name, "error", err)
-			continue
+	summary.FinishedAt = time.Now()
+	ucs.logSummary(summary)
+	// observers still get the updates found before an interruption
+	observerCtx := context.WithoutCancel(ctx)
+	for _, observer := range ucs.observers {
+		if err := observer.RunCompleted(observerCtx, summary); err != nil {
+			ucs.logger.Error("run observer failed", "error", err)
 		}
+	}
 
-		if IsNewerVersionAvailable {
-			mangaResponse, err := provider.GetLatestVersionMangaEntity(ctx, manga)
+	if err := ctx.Err(); err != nil {
+		return fmt.Errorf("update run interrupted: %w", err)
+	}
+	return nil
+}
 
-			if err != nil {
-				ucs.logger.Error("failed to get latest version", "manga", manga, "error", err)
-				continue
-			}
+// checkSeries checks a single series and adds its outcome to the summary
+func (ucs *UpdateCheckerService) checkSeries(ctx context.Context, path string, manga domain.MangaEntity, summary *RunSummary) {
+	ctx, span := ucs.tracer.Start(ctx, "check series", trace.WithAttributes(mangaAttributes(manga)...))
+	before := *summary
+	defer func() { endSeriesSpan(span, before, *summary) }()
 
-			err = ucs.store.PersistMangaTitle(ctx, path, *mangaResponse)
-			if err != nil {
-				ucs.logger.Error("failed to persist manga", "manga", manga, "error", err)
-				continue
-			}
+	ucs.logger.Info("Looking at", "mangaName", manga.Name, "dataPath", path)
+	if len(manga.Sources) > 0 {
+		ucs.checkLinkedSeries(ctx, path, manga, summary)
+		return
+	}
+
+	provider, err := ucs.providers.GetProvider(manga)
+
+	if err != nil {
+		// only the series of this provider are affected, keep checking the others
+		ucs.logger.Warn("skipping manga, provider is unavailable", "manga", manga.Name, "source", manga.Source, "error", err)
+		summary.Skipped = append(summary.Skipped, SeriesFailure{Location: path, Manga: manga, Err: err})
+		return
+	}
+	summary.Checked++
+
+	var IsNewerVersionAvailable bool
+	err = ucs.traceProviderCall(ctx, "IsNewerVersionAvailable", manga.Source, func(ctx context.Context) (err error) {
+		IsNewerVersionAvailable, err = provider.IsNewerVersionAvailable(ctx, manga)
+		return err
+	})
+	if err != nil {
+		ucs.logger.Error("failed to check for newer version", "manga", manga.Name, "error", err)
+		summary.Failed = append(summary.Failed, SeriesFailure{Location: path, Manga: manga, Err: err})
+		return
+	}
+
+	if !IsNewerVersionAvailable {
+		summary.Unchanged = append(summary.Unchanged, SeriesUpdate{Location: path, Manga: manga})
+		return
+	}
+
+	var mangaResponse *domain.MangaEntity
+	err = ucs.traceProviderCall(ctx, "GetLatestVersionMangaEntity", manga.Source, func(ctx context.Context) (err error) {
+		mangaResponse, err = provider.GetLatestVersionMangaEntity(ctx, manga)
+		return err
+	})
+	if err != nil {
+		ucs.logger.Error("failed to get latest version", "manga", manga, "error", err)
+		summary.Failed = append(summary.Failed, SeriesFailure{Location: path, Manga: manga, Err: err})
+		return
+	}
+
+	ucs.recordLatestVersion(ctx, path, manga, *mangaResponse, manga.GetMissingChapters(*mangaResponse), summary)
+}
 
-			if manga.ShouldNotify {
-				chaptersMissing := manga.GetMissingChapters(*mangaResponse)
-				ucs.logger.Info("Manga has new chapters", "mangaName", manga.Name, "numberOfNewChapters", len(chaptersMissing))
-				if len(chaptersMissing) > 0 {
-
-					// If we have multiple simultatnions updates they will be ordered descending
-					// meaning the newest one will be first, and the olders updates will be last.
-					// Take the oldest one by taking the last index.
-					indexToTake := len(chaptersMissing) - 1
-					err := ucs.notifier.NotifyForNewChapter(ctx, chaptersMissing[indexToTake], manga)
-					if err != nil {
-						slog.Error("failed to notify for manga", "manga", manga, "error", err)
-					}
-				}
+// recordLatestVersion persists the latest version of a series and notifies about its new chapters
+func (ucs *UpdateCheckerService) recordLatestVersion(ctx context.Context, path string, manga domain.MangaEntity, latest domain.MangaEntity, chaptersMissing []domain.ChapterEntity, summary *RunSummary) {
+	latest.KeepUserFields(manga)
+	err := ucs.store.PersistMangaTitle(ctx, path, latest)
+	if err != nil {
+		ucs.logger.Error("failed to persist manga", "manga", manga, "error", err)
+		summary.Failed = append(summary.Failed, SeriesFailure{Location: path, Manga: manga, Err: err})
+		return
+	}
+
+	if len(chaptersMissing) > 0 {
+		summary.Updated = append(summary.Updated, SeriesUpdate{
+			Location:    path,
+			Manga:       latest,
+			NewChapters: chaptersMissing,
+		})
+	} else {
+		summary.Unchanged = append(summary.Unchanged, SeriesUpdate{Location: path, Manga: latest})
+	}
+
+	if manga.ShouldNotify {
+		ucs.logger.Info("Manga has new chapters", "mangaName", manga.Name, "numberOfNewChapters", len(chaptersMissing))
+		if len(chaptersMissing) > 0 {
+
+			// If we have multiple simultatnions updates they will be ordered descending
+			// meaning the newest one will be first, and the olders updates will be last.
+			// Take the oldest one by taking the last index.
+			indexToTake := len(chaptersMissing) - 1
+			err := ucs.notify(ctx, chaptersMissing[indexToTake], latest)
+			if err != nil {
+				ucs.logger.Error("failed to notify for manga", "manga", manga, "error", err)
 			}
 		}
 	}
+}
+
+func (ucs *UpdateCheckerService) providerContext(ctx context.Context) (context.Context, context.CancelFunc) {
+	if ucs.providerTimeout <= 0 {
+		return context.WithCancel(ctx)
+	}
+	return context.WithTimeout(ctx, ucs.providerTimeout)
+}
 
-	return nil
+func (ucs *UpdateCheckerService) logSummary(summary RunSummary) {
+	skipped := make([]string, 0, len(summary.Skipped))
+	for _, s := range summary.Skipped {
+		skipped = append(skipped, s.Manga.Name)
+	}
+	failed := make([]string, 0, len(summary.Failed))
+	for _, f := range summary.Failed {
+		failed = append(failed, f.Manga.Name)
+	}
+
+	ucs.logger.Info("Update run summary",
+		"checked", summary.Checked,
+		"updated", len(summary.Updated),
+		"skipped", skipped,
+		"failed", failed,
+		"durationInSeconds", summary.FinishedAt.Sub(summary.StartedAt).Seconds(),
+	)
 }
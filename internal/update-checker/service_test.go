@@ -0,0 +1,219 @@
+package updatechecker
+
+import (
+	"context"
+	"errors"
+	"io"
+	"log/slog"
+	"testing"
+	"time"
+
+	"github.com/ivan-penchev/manga-updates/internal/domain"
+	"github.com/ivan-penchev/manga-updates/internal/mocks"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+type recordingObserver struct {
+	summaries []RunSummary
+}
+
+func (r *recordingObserver) RunCompleted(ctx context.Context, summary RunSummary) error {
+	r.summaries = append(r.summaries, summary)
+	return nil
+}
+
+func chapters(numbers ...float64) []domain.ChapterEntity {
+	result := make([]domain.ChapterEntity, 0, len(numbers))
+	for _, n := range numbers {
+		result = append(result, domain.ChapterEntity{Number: &n, URI: "https://example.com"})
+	}
+	return result
+}
+
+func TestCheckForUpdates_SkipsSeriesOfUnavailableProvider(t *testing.T) {
+	ctx := context.Background()
+	mockStore := mocks.NewMockStore(t)
+	mockNotifier := mocks.NewMockNotifier(t)
+	mockRouter := mocks.NewMockProviderRouter(t)
+	mockProvider := mocks.NewMockProvider(t)
+
+	dex := domain.MangaEntity{Name: "Dex", Slug: "dex", Source: domain.MangaSourceMangaDex, ShouldNotify: true, LastUpdate: time.Now(), Chapters: chapters(1)}
+	nel := domain.MangaEntity{Name: "Nel", Slug: "nel", Source: domain.MangaSourceMangaNel, ShouldNotify: true, LastUpdate: time.Now(), Chapters: chapters(1)}
+	latestDex := dex
+	latestDex.Chapters = chapters(2, 1)
+
+	mockStore.EXPECT().GetMangaSeries(mock.Anything).Return(map[string]domain.MangaEntity{"dex.json": dex, "nel.json": nel})
+	unavailable := &domain.ProviderUnavailableError{Kind: domain.MangaSourceMangaNel, Cause: errors.New("browser unavailable")}
+	mockRouter.EXPECT().GetProvider(nel).Return(nil, unavailable)
+	mockRouter.EXPECT().GetProvider(dex).Return(mockProvider, nil)
+	mockProvider.EXPECT().IsNewerVersionAvailable(mock.Anything, dex).Return(true, nil)
+	mockProvider.EXPECT().GetLatestVersionMangaEntity(mock.Anything, dex).Return(&latestDex, nil)
+	mockStore.EXPECT().PersistMangaTitle(mock.Anything, "dex.json", latestDex).Return(nil)
+	mockNotifier.EXPECT().NotifyForNewChapter(mock.Anything, latestDex.Chapters[0], latestDex).Return(nil)
+
+	observer := &recordingObserver{}
+	service, err := NewUpdateCheckerService(mockNotifier, mockStore, mockRouter, slog.New(slog.NewTextHandler(io.Discard, nil)), WithRunObserver(observer))
+	require.NoError(t, err)
+
+	require.NoError(t, service.CheckForUpdates(ctx))
+
+	require.Len(t, observer.summaries, 1)
+	summary := observer.summaries[0]
+	assert.Equal(t, 1, summary.Checked)
+	require.Len(t, summary.Updated, 1)
+	assert.Equal(t, "dex.json", summary.Updated[0].Location)
+	require.Len(t, summary.Skipped, 1)
+	assert.Equal(t, "nel.json", summary.Skipped[0].Location)
+	assert.ErrorIs(t, summary.Skipped[0].Err, unavailable.Cause)
+	assert.Empty(t, summary.Failed)
+}
+
+func TestCheckForUpdates_ReportsFailedChecks(t *testing.T) {
+	ctx := context.Background()
+	mockStore := mocks.NewMockStore(t)
+	mockRouter := mocks.NewMockProviderRouter(t)
+	mockProvider := mocks.NewMockProvider(t)
+
+	dex := domain.MangaEntity{Name: "Dex", Slug: "dex", Source: domain.MangaSourceMangaDex}
+	mockStore.EXPECT().GetMangaSeries(mock.Anything).Return(map[string]domain.MangaEntity{"dex.json": dex})
+	mockRouter.EXPECT().GetProvider(dex).Return(mockProvider, nil)
+	mockProvider.EXPECT().IsNewerVersionAvailable(mock.Anything, dex).Return(false, errors.New("timeout"))
+
+	observer := &recordingObserver{}
+	service, err := NewUpdateCheckerService(mocks.NewMockNotifier(t), mockStore, mockRouter, slog.New(slog.NewTextHandler(io.Discard, nil)), WithRunObserver(observer))
+	require.NoError(t, err)
+
+	require.NoError(t, service.CheckForUpdates(ctx))
+
+	require.Len(t, observer.summaries, 1)
+	assert.Equal(t, 1, observer.summaries[0].Checked)
+	require.Len(t, observer.summaries[0].Failed, 1)
+	assert.EqualError(t, observer.summaries[0].Failed[0].Err, "timeout")
+}
+
+func TestCheckForUpdates_ProviderTimeout(t *testing.T) {
+	mockStore := mocks.NewMockStore(t)
+	mockRouter := mocks.NewMockProviderRouter(t)
+	mockProvider := mocks.NewMockProvider(t)
+
+	dex := domain.MangaEntity{Name: "Dex", Slug: "dex", Source: domain.MangaSourceMangaDex}
+	mockStore.EXPECT().GetMangaSeries(mock.Anything).Return(map[string]domain.MangaEntity{"dex.json": dex})
+	mockRouter.EXPECT().GetProvider(dex).Return(mockProvider, nil)
+	mockProvider.EXPECT().IsNewerVersionAvailable(mock.Anything, dex).RunAndReturn(func(ctx context.Context, manga domain.MangaEntity) (bool, error) {
+		<-ctx.Done()
+		return false, ctx.Err()
+	})
+
+	observer := &recordingObserver{}
+	service, err := NewUpdateCheckerService(nil, mockStore, mockRouter, slog.New(slog.NewTextHandler(io.Discard, nil)),
+		WithRunObserver(observer), WithProviderTimeout(20*time.Millisecond))
+	require.NoError(t, err)
+
+	require.NoError(t, service.CheckForUpdates(context.Background()), "a timed out series does not fail the run")
+	require.Len(t, observer.summaries[0].Failed, 1)
+	assert.ErrorIs(t, observer.summaries[0].Failed[0].Err, context.DeadlineExceeded)
+}
+
+func TestCheckForUpdates_StopsWhenCancelled(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	mockStore := mocks.NewMockStore(t)
+	mockRouter := mocks.NewMockProviderRouter(t)
+	mockProvider := mocks.NewMockProvider(t)
+
+	series := map[string]domain.MangaEntity{
+		"a.json": {Name: "A", Slug: "a", Source: domain.MangaSourceMangaDex},
+		"b.json": {Name: "B", Slug: "b", Source: domain.MangaSourceMangaDex},
+		"c.json": {Name: "C", Slug: "c", Source: domain.MangaSourceMangaDex},
+	}
+	mockStore.EXPECT().GetMangaSeries(mock.Anything).Return(series)
+	mockRouter.EXPECT().GetProvider(mock.Anything).Return(mockProvider, nil).Once()
+	mockProvider.EXPECT().IsNewerVersionAvailable(mock.Anything, mock.Anything).RunAndReturn(func(callCtx context.Context, manga domain.MangaEntity) (bool, error) {
+		cancel()
+		<-callCtx.Done()
+		return false, callCtx.Err()
+	}).Once()
+
+	observer := &recordingObserver{}
+	service, err := NewUpdateCheckerService(nil, mockStore, mockRouter, slog.New(slog.NewTextHandler(io.Discard, nil)), WithRunObserver(observer))
+	require.NoError(t, err)
+
+	start := time.Now()
+	err = service.CheckForUpdates(ctx)
+	assert.ErrorIs(t, err, context.Canceled)
+	assert.Less(t, time.Since(start), time.Second)
+
+	require.Len(t, observer.summaries, 1, "observers are notified of interrupted runs")
+	summary := observer.summaries[0]
+	assert.Equal(t, 1, summary.Checked)
+	assert.Len(t, summary.Failed, 3)
+}
+
+func TestCheckForUpdates_KeepsReadMarker(t *testing.T) {
+	mockStore := mocks.NewMockStore(t)
+	mockNotifier := mocks.NewMockNotifier(t)
+	mockRouter := mocks.NewMockProviderRouter(t)
+	mockProvider := mocks.NewMockProvider(t)
+
+	readAt := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	dex := domain.MangaEntity{Name: "Dex", Slug: "dex", Source: domain.MangaSourceMangaDex, ShouldNotify: true, LastUpdate: readAt,
+		Chapters: chapters(2, 1), Read: &domain.ReadMarker{Chapter: 1, ReadAt: readAt}}
+	latestDex := domain.MangaEntity{Name: "Dex", Slug: "dex", Source: domain.MangaSourceMangaDex, ShouldNotify: true, LastUpdate: time.Now(),
+		Chapters: chapters(3, 2, 1)}
+
+	mockStore.EXPECT().GetMangaSeries(mock.Anything).Return(map[string]domain.MangaEntity{"dex.json": dex})
+	mockRouter.EXPECT().GetProvider(dex).Return(mockProvider, nil)
+	mockProvider.EXPECT().IsNewerVersionAvailable(mock.Anything, dex).Return(true, nil)
+	mockProvider.EXPECT().GetLatestVersionMangaEntity(mock.Anything, dex).Return(&latestDex, nil)
+	mockStore.EXPECT().PersistMangaTitle(mock.Anything, "dex.json", mock.Anything).RunAndReturn(func(ctx context.Context, location string, m domain.MangaEntity) error {
+		assert.Equal(t, dex.Read, m.Read, "the read marker survives the update")
+		return nil
+	})
+	mockNotifier.EXPECT().NotifyForNewChapter(mock.Anything, mock.Anything, mock.Anything).RunAndReturn(func(ctx context.Context, chapter domain.ChapterEntity, m domain.MangaEntity) error {
+		unread, ok := m.UnreadCount()
+		assert.True(t, ok)
+		assert.Equal(t, 2, unread)
+		return nil
+	})
+
+	service, err := NewUpdateCheckerService(mockNotifier, mockStore, mockRouter, slog.New(slog.NewTextHandler(io.Discard, nil)))
+	require.NoError(t, err)
+	require.NoError(t, service.CheckForUpdates(context.Background()))
+}
+
+func TestCheckForUpdates_ReportsUnchangedSeries(t *testing.T) {
+	mockStore := mocks.NewMockStore(t)
+	mockRouter := mocks.NewMockProviderRouter(t)
+	mockProvider := mocks.NewMockProvider(t)
+
+	dex := domain.MangaEntity{Name: "Dex", Slug: "dex", Source: domain.MangaSourceMangaDex, LastUpdate: time.Now(), Chapters: chapters(1)}
+	mockStore.EXPECT().GetMangaSeries(mock.Anything).Return(map[string]domain.MangaEntity{"dex.json": dex})
+	mockRouter.EXPECT().GetProvider(dex).Return(mockProvider, nil)
+	mockProvider.EXPECT().IsNewerVersionAvailable(mock.Anything, dex).Return(false, nil)
+
+	observer := &recordingObserver{}
+	service, err := NewUpdateCheckerService(mocks.NewMockNotifier(t), mockStore, mockRouter, slog.New(slog.NewTextHandler(io.Discard, nil)), WithRunObserver(observer))
+	require.NoError(t, err)
+	require.NoError(t, service.CheckForUpdates(context.Background()))
+
+	summary := observer.summaries[0]
+	assert.Empty(t, summary.Updated)
+	require.Len(t, summary.Unchanged, 1)
+	assert.Equal(t, "dex.json", summary.Unchanged[0].Location)
+}
+
+func TestCheckForUpdates_NotifiesObserversWithoutSeries(t *testing.T) {
+	mockStore := mocks.NewMockStore(t)
+	mockStore.EXPECT().GetMangaSeries(mock.Anything).Return(map[string]domain.MangaEntity{})
+
+	observer := &recordingObserver{}
+	service, err := NewUpdateCheckerService(mocks.NewMockNotifier(t), mockStore, mocks.NewMockProviderRouter(t), slog.New(slog.NewTextHandler(io.Discard, nil)), WithRunObserver(observer))
+	require.NoError(t, err)
+	require.NoError(t, service.CheckForUpdates(context.Background()))
+
+	require.Len(t, observer.summaries, 1)
+	assert.Zero(t, observer.summaries[0].Checked)
+	assert.False(t, observer.summaries[0].FinishedAt.IsZero())
+}
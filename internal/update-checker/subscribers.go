@@ -0,0 +1,85 @@
+package updatechecker
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"slices"
+
+	"github.com/ivan-penchev/manga-updates/internal/domain"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// Subscriber is a user notified about the new chapters of the series it is subscribed to.
+type Subscriber struct {
+	Name     string
+	Notifier Notifier
+	// Series lists the series the user is subscribed to, on top of the series listing
+	// the user in their subscribers.
+	Series []domain.SourceRef
+}
+
+// SubscribedTo reports whether the subscriber is notified about manga, a series is
+// matched on any of its sources since slugs are only unique within a source.
+func (s Subscriber) SubscribedTo(manga domain.MangaEntity) bool {
+	if manga.IsSubscribed(s.Name) {
+		return true
+	}
+	for _, ref := range manga.AllSources() {
+		if slices.Contains(s.Series, ref) {
+			return true
+		}
+	}
+	return false
+}
+
+// WithSubscribers routes the notifications of every series to its subscribers only,
+// the notifier of the service receives the series nobody is subscribed to.
+func WithSubscribers(subscribers ...Subscriber) Option {
+	return func(ucs *UpdateCheckerService) {
+		ucs.subscribers = append(ucs.subscribers, subscribers...)
+	}
+}
+
+// recipientsOf returns the subscribers of manga, nil when nobody is subscribed to it
+func (ucs *UpdateCheckerService) recipientsOf(manga domain.MangaEntity) []Subscriber {
+	var recipients []Subscriber
+	for _, subscriber := range ucs.subscribers {
+		if subscriber.SubscribedTo(manga) {
+			recipients = append(recipients, subscriber)
+		}
+	}
+	return recipients
+}
+
+// notify sends the notification of a chapter to the subscribers of the series, a
+// failure to reach one of them does not keep the others from being notified.
+func (ucs *UpdateCheckerService) notify(ctx context.Context, chapter domain.ChapterEntity, manga domain.MangaEntity) error {
+	recipients := ucs.recipientsOf(manga)
+	if len(recipients) == 0 {
+		return ucs.notifyOne(ctx, ucs.notifier, chapter, manga)
+	}
+
+	var errs []error
+	for _, recipient := range recipients {
+		if err := ucs.notifyOne(ctx, recipient.Notifier, chapter, manga, AttrSubscriber.String(recipient.Name)); err != nil {
+			errs = append(errs, fmt.Errorf("subscriber %s: %w", recipient.Name, err))
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// notifyOne sends a single notification in its own span
+func (ucs *UpdateCheckerService) notifyOne(ctx context.Context, notifier Notifier, chapter domain.ChapterEntity, manga domain.MangaEntity, extra ...attribute.KeyValue) error {
+	attributes := append(mangaAttributes(manga), extra...)
+	if chapter.Number != nil {
+		attributes = append(attributes, AttrChapterNumber.Float64(*chapter.Number))
+	}
+	ctx, span := ucs.tracer.Start(ctx, "notify", trace.WithAttributes(attributes...))
+	defer span.End()
+
+	err := notifier.NotifyForNewChapter(ctx, chapter, manga)
+	recordError(span, err)
+	return err
+}
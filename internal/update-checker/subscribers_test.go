@@ -0,0 +1,75 @@
+package updatechecker
+
+import (
+	"context"
+	"errors"
+	"io"
+	"log/slog"
+	"testing"
+	"time"
+
+	"github.com/ivan-penchev/manga-updates/internal/domain"
+	"github.com/ivan-penchev/manga-updates/internal/mocks"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCheckForUpdates_NotifiesSubscribersOnly(t *testing.T) {
+	ctx := context.Background()
+	mockStore := mocks.NewMockStore(t)
+	defaultNotifier := mocks.NewMockNotifier(t)
+	aliceNotifier := mocks.NewMockNotifier(t)
+	bobNotifier := mocks.NewMockNotifier(t)
+	carolNotifier := mocks.NewMockNotifier(t)
+	mockRouter := mocks.NewMockProviderRouter(t)
+	mockProvider := mocks.NewMockProvider(t)
+
+	solo := domain.MangaEntity{Name: "Solo Leveling", Slug: "solo-leveling", Source: domain.MangaSourceMangaDex, ShouldNotify: true, LastUpdate: time.Now(), Chapters: chapters(1), Subscribers: []string{"alice"}}
+	orphan := domain.MangaEntity{Name: "Orphan", Slug: "orphan", Source: domain.MangaSourceMangaDex, ShouldNotify: true, LastUpdate: time.Now(), Chapters: chapters(1)}
+	latestSolo := solo
+	latestSolo.Chapters = chapters(2, 1)
+	latestOrphan := orphan
+	latestOrphan.Chapters = chapters(2, 1)
+
+	mockStore.EXPECT().GetMangaSeries(mock.Anything).Return(map[string]domain.MangaEntity{"solo.json": solo, "orphan.json": orphan})
+	mockRouter.EXPECT().GetProvider(mock.Anything).Return(mockProvider, nil)
+	mockProvider.EXPECT().IsNewerVersionAvailable(mock.Anything, mock.Anything).Return(true, nil)
+	mockProvider.EXPECT().GetLatestVersionMangaEntity(mock.Anything, solo).Return(&latestSolo, nil)
+	mockProvider.EXPECT().GetLatestVersionMangaEntity(mock.Anything, orphan).Return(&latestOrphan, nil)
+	mockStore.EXPECT().PersistMangaTitle(mock.Anything, mock.Anything, mock.Anything).Return(nil)
+
+	// alice subscribed with add --user, bob through the series of the config, carol to the same slug on another source
+	aliceNotifier.EXPECT().NotifyForNewChapter(mock.Anything, latestSolo.Chapters[0], latestSolo).Return(nil)
+	bobNotifier.EXPECT().NotifyForNewChapter(mock.Anything, latestSolo.Chapters[0], latestSolo).Return(nil)
+	defaultNotifier.EXPECT().NotifyForNewChapter(mock.Anything, latestOrphan.Chapters[0], latestOrphan).Return(nil)
+
+	service, err := NewUpdateCheckerService(defaultNotifier, mockStore, mockRouter, slog.New(slog.NewTextHandler(io.Discard, nil)),
+		WithSubscribers(
+			Subscriber{Name: "alice", Notifier: aliceNotifier},
+			Subscriber{Name: "bob", Notifier: bobNotifier, Series: []domain.SourceRef{{Source: domain.MangaSourceMangaDex, Slug: "solo-leveling"}}},
+			Subscriber{Name: "carol", Notifier: carolNotifier, Series: []domain.SourceRef{{Source: domain.MangaSourceMangaNel, Slug: "solo-leveling"}}},
+		))
+	require.NoError(t, err)
+
+	require.NoError(t, service.CheckForUpdates(ctx))
+}
+
+func TestNotify_ReachesEverySubscriberDespiteFailures(t *testing.T) {
+	aliceNotifier := mocks.NewMockNotifier(t)
+	bobNotifier := mocks.NewMockNotifier(t)
+	manga := domain.MangaEntity{Name: "Solo Leveling", Slug: "solo-leveling", Subscribers: []string{"alice", "bob"}}
+	chapter := chapters(2)[0]
+
+	failure := errors.New("mailbox unavailable")
+	aliceNotifier.EXPECT().NotifyForNewChapter(mock.Anything, chapter, manga).Return(failure)
+	bobNotifier.EXPECT().NotifyForNewChapter(mock.Anything, chapter, manga).Return(nil)
+
+	service, err := NewUpdateCheckerService(nil, nil, nil, slog.New(slog.NewTextHandler(io.Discard, nil)),
+		WithSubscribers(Subscriber{Name: "alice", Notifier: aliceNotifier}, Subscriber{Name: "bob", Notifier: bobNotifier}))
+	require.NoError(t, err)
+
+	err = service.notify(context.Background(), chapter, manga)
+	assert.ErrorIs(t, err, failure)
+	assert.ErrorContains(t, err, "subscriber alice")
+}
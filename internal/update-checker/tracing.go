@@ -0,0 +1,97 @@
+package updatechecker
+
+import (
+	"context"
+
+	"github.com/ivan-penchev/manga-updates/internal/domain"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/trace"
+)
+
+const tracerName = "github.com/ivan-penchev/manga-updates/internal/update-checker"
+
+// Span attributes of the update runs
+const (
+	AttrMangaName       = attribute.Key("manga.name")
+	AttrMangaSource     = attribute.Key("manga.source")
+	AttrMangaSlug       = attribute.Key("manga.slug")
+	AttrProvider        = attribute.Key("provider")
+	AttrOutcome         = attribute.Key("outcome")
+	AttrNewChapters     = attribute.Key("chapters.new")
+	AttrChapterNumber   = attribute.Key("chapter.number")
+	AttrSubscriber      = attribute.Key("subscriber")
+	AttrSeriesCount     = attribute.Key("series.count")
+	AttrSeriesChecked   = attribute.Key("series.checked")
+	AttrSeriesUpdated   = attribute.Key("series.updated")
+	AttrSeriesUnchanged = attribute.Key("series.unchanged")
+	AttrSeriesSkipped   = attribute.Key("series.skipped")
+	AttrSeriesFailed    = attribute.Key("series.failed")
+)
+
+// WithTracerProvider traces the runs, a span per run with child spans per series,
+// provider call and notification. Runs are not traced by default.
+func WithTracerProvider(tp trace.TracerProvider) Option {
+	return func(ucs *UpdateCheckerService) {
+		ucs.tracer = tp.Tracer(tracerName)
+	}
+}
+
+func mangaAttributes(manga domain.MangaEntity) []attribute.KeyValue {
+	return []attribute.KeyValue{
+		AttrMangaName.String(manga.Name),
+		AttrMangaSource.String(string(manga.Source)),
+		AttrMangaSlug.String(manga.Slug),
+	}
+}
+
+// traceProviderCall runs a provider call bounded by the provider timeout in its own span
+func (ucs *UpdateCheckerService) traceProviderCall(ctx context.Context, operation string, source domain.MangaSource, call func(ctx context.Context) error) error {
+	ctx, span := ucs.tracer.Start(ctx, "provider."+operation, trace.WithAttributes(AttrProvider.String(string(source))))
+	defer span.End()
+
+	callCtx, cancel := ucs.providerContext(ctx)
+	defer cancel()
+	err := call(callCtx)
+	recordError(span, err)
+	return err
+}
+
+// endSeriesSpan annotates the span of a series check with what the check added to the summary
+func endSeriesSpan(span trace.Span, before, after RunSummary) {
+	defer span.End()
+	switch {
+	case len(after.Updated) > len(before.Updated):
+		span.SetAttributes(AttrOutcome.String("updated"), AttrNewChapters.Int(len(after.Updated[len(after.Updated)-1].NewChapters)))
+	case len(after.Unchanged) > len(before.Unchanged):
+		span.SetAttributes(AttrOutcome.String("unchanged"))
+	case len(after.Skipped) > len(before.Skipped):
+		span.SetAttributes(AttrOutcome.String("skipped"))
+		if err := after.Skipped[len(after.Skipped)-1].Err; err != nil {
+			span.AddEvent("provider unavailable", trace.WithAttributes(attribute.String("error", err.Error())))
+		}
+	case len(after.Failed) > len(before.Failed):
+		span.SetAttributes(AttrOutcome.String("failed"))
+		recordError(span, after.Failed[len(after.Failed)-1].Err)
+	}
+}
+
+func endRunSpan(span trace.Span, summary RunSummary, err error) {
+	span.SetAttributes(
+		AttrSeriesChecked.Int(summary.Checked),
+		AttrSeriesUpdated.Int(len(summary.Updated)),
+		AttrSeriesUnchanged.Int(len(summary.Unchanged)),
+		AttrSeriesSkipped.Int(len(summary.Skipped)),
+		AttrSeriesFailed.Int(len(summary.Failed)),
+	)
+	recordError(span, err)
+	span.End()
+}
+
+func recordError(span trace.Span, err error) {
+	if err == nil {
+		return
+	}
+	span.RecordError(err)
+	span.SetStatus(codes.Error, err.Error())
+}
@@ -0,0 +1,89 @@
+package updatechecker
+
+import (
+	"context"
+	"errors"
+	"io"
+	"log/slog"
+	"testing"
+	"time"
+
+	"github.com/ivan-penchev/manga-updates/internal/domain"
+	"github.com/ivan-penchev/manga-updates/internal/mocks"
+	"github.com/ivan-penchev/manga-updates/internal/tracing"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+)
+
+func spanAttribute(span tracetest.SpanStub, key attribute.Key) attribute.Value {
+	for _, kv := range span.Attributes {
+		if kv.Key == key {
+			return kv.Value
+		}
+	}
+	return attribute.Value{}
+}
+
+func TestCheckForUpdates_TracesRun(t *testing.T) {
+	ctx := context.Background()
+	mockStore := mocks.NewMockStore(t)
+	mockNotifier := mocks.NewMockNotifier(t)
+	mockRouter := mocks.NewMockProviderRouter(t)
+	mockProvider := mocks.NewMockProvider(t)
+
+	dex := domain.MangaEntity{Name: "Dex", Slug: "dex", Source: domain.MangaSourceMangaDex, ShouldNotify: true, LastUpdate: time.Now(), Chapters: chapters(1)}
+	broken := domain.MangaEntity{Name: "Broken", Slug: "broken", Source: domain.MangaSourceMangaDex}
+	latestDex := dex
+	latestDex.Chapters = chapters(3, 2, 1)
+
+	mockStore.EXPECT().GetMangaSeries(mock.Anything).Return(map[string]domain.MangaEntity{"dex.json": dex, "broken.json": broken})
+	mockRouter.EXPECT().GetProvider(mock.Anything).Return(mockProvider, nil)
+	mockProvider.EXPECT().IsNewerVersionAvailable(mock.Anything, dex).Return(true, nil)
+	mockProvider.EXPECT().IsNewerVersionAvailable(mock.Anything, broken).Return(false, errors.New("boom"))
+	mockProvider.EXPECT().GetLatestVersionMangaEntity(mock.Anything, dex).Return(&latestDex, nil)
+	mockStore.EXPECT().PersistMangaTitle(mock.Anything, "dex.json", latestDex).Return(nil)
+	mockNotifier.EXPECT().NotifyForNewChapter(mock.Anything, latestDex.Chapters[1], latestDex).Return(nil)
+
+	tp, exporter := tracing.NewInMemory()
+	defer tp.Shutdown(ctx)
+	service, err := NewUpdateCheckerService(mockNotifier, mockStore, mockRouter, slog.New(slog.NewTextHandler(io.Discard, nil)), WithTracerProvider(tp))
+	require.NoError(t, err)
+
+	require.NoError(t, service.CheckForUpdates(ctx))
+
+	spans := make(map[string][]tracetest.SpanStub)
+	for _, span := range exporter.GetSpans() {
+		spans[span.Name] = append(spans[span.Name], span)
+	}
+
+	require.Len(t, spans["CheckForUpdates"], 1)
+	run := spans["CheckForUpdates"][0]
+	assert.Equal(t, int64(2), spanAttribute(run, AttrSeriesCount).AsInt64())
+	assert.Equal(t, int64(1), spanAttribute(run, AttrSeriesUpdated).AsInt64())
+	assert.Equal(t, int64(1), spanAttribute(run, AttrSeriesFailed).AsInt64())
+
+	require.Len(t, spans["check series"], 2)
+	for _, series := range spans["check series"] {
+		assert.Equal(t, run.SpanContext.SpanID(), series.Parent.SpanID())
+		switch spanAttribute(series, AttrMangaName).AsString() {
+		case "Dex":
+			assert.Equal(t, "updated", spanAttribute(series, AttrOutcome).AsString())
+			assert.Equal(t, int64(2), spanAttribute(series, AttrNewChapters).AsInt64())
+		case "Broken":
+			assert.Equal(t, "failed", spanAttribute(series, AttrOutcome).AsString())
+			assert.Equal(t, codes.Error, series.Status.Code)
+		default:
+			t.Errorf("unexpected series span %v", series.Attributes)
+		}
+	}
+
+	assert.Len(t, spans["provider.IsNewerVersionAvailable"], 2)
+	require.Len(t, spans["provider.GetLatestVersionMangaEntity"], 1)
+	assert.Equal(t, string(domain.MangaSourceMangaDex), spanAttribute(spans["provider.GetLatestVersionMangaEntity"][0], AttrProvider).AsString())
+	require.Len(t, spans["notify"], 1)
+	assert.Equal(t, 2.0, spanAttribute(spans["notify"][0], AttrChapterNumber).AsFloat64())
+}